@@ -0,0 +1,276 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/uber-go/atomic"
+)
+
+const _defaultQueueSize = 1024
+
+var errAsyncClosed = errors.New("can't write to a closed AsyncWriteSyncer")
+
+// An OverflowPolicy determines what an AsyncWriteSyncer does with writes
+// when its queue is full.
+type OverflowPolicy int
+
+const (
+	// BlockWhenFull makes writes wait until there's room in the queue. No
+	// writes are lost, but a slow destination eventually slows down logging
+	// callers.
+	BlockWhenFull OverflowPolicy = iota
+	// DropNewest discards the write that would overflow the queue.
+	DropNewest
+	// DropOldest discards the oldest queued write to make room for the new
+	// one.
+	DropOldest
+)
+
+// An AsyncOption configures an AsyncWriteSyncer.
+type AsyncOption interface {
+	apply(*AsyncWriteSyncer)
+}
+
+type asyncOptionFunc func(*AsyncWriteSyncer)
+
+func (f asyncOptionFunc) apply(s *AsyncWriteSyncer) {
+	f(s)
+}
+
+// QueueSize sets the maximum number of writes an AsyncWriteSyncer buffers
+// before applying its OverflowPolicy. The default is 1024; non-positive sizes
+// are ignored.
+func QueueSize(n int) AsyncOption {
+	return asyncOptionFunc(func(s *AsyncWriteSyncer) {
+		if n > 0 {
+			s.size = n
+		}
+	})
+}
+
+// OnOverflow sets the AsyncWriteSyncer's OverflowPolicy. The default is
+// BlockWhenFull.
+func OnOverflow(policy OverflowPolicy) AsyncOption {
+	return asyncOptionFunc(func(s *AsyncWriteSyncer) {
+		s.policy = policy
+	})
+}
+
+// An AsyncWriteSyncer decouples logging from I/O: writes are copied into a
+// bounded in-memory queue and written to the underlying WriteSyncer by a
+// background goroutine. Since the write happens later, errors from the
+// underlying WriteSyncer are reported by the next call to Sync. To bound
+// memory use, only the first and last errors since the previous Sync are
+// kept, along with a count of failed writes.
+//
+// Writes that are discarded because the queue is full still report success,
+// so that loggers don't treat them as I/O errors; use Dropped to monitor them.
+//
+// AsyncWriteSyncers are safe for concurrent use. Call Close to flush any
+// queued writes and stop the background goroutine.
+type AsyncWriteSyncer struct {
+	ws      WriteSyncer
+	size    int
+	policy  OverflowPolicy
+	dropped *atomic.Uint64
+
+	// Serializes calls to the underlying WriteSyncer, which may not be safe
+	// for concurrent use, between the drain goroutine and Sync.
+	wsMu sync.Mutex
+
+	sync.Mutex
+	cond     *sync.Cond
+	queue    [][]byte
+	spare    [][]byte
+	enqueued uint64 // total writes accepted into the queue
+	finished uint64 // total queued writes written or dropped
+	firstErr error  // first write error since the last Sync
+	lastErr  error  // most recent write error since the last Sync
+	failed   uint64
+	closed   bool
+	stopped  chan struct{}
+}
+
+// NewAsyncWriteSyncer wraps a WriteSyncer and starts the background goroutine
+// that drains the queue.
+func NewAsyncWriteSyncer(ws WriteSyncer, options ...AsyncOption) *AsyncWriteSyncer {
+	s := &AsyncWriteSyncer{
+		ws:      ws,
+		size:    _defaultQueueSize,
+		policy:  BlockWhenFull,
+		dropped: atomic.NewUint64(0),
+		stopped: make(chan struct{}),
+	}
+	for _, opt := range options {
+		opt.apply(s)
+	}
+	s.cond = sync.NewCond(&s.Mutex)
+	go s.drain()
+	return s
+}
+
+// Write copies the supplied bytes into the queue. If the queue is full, it
+// blocks or drops a write, depending on the OverflowPolicy.
+func (s *AsyncWriteSyncer) Write(bs []byte) (int, error) {
+	// Encoders re-use their buffers, so we must copy.
+	buf := make([]byte, len(bs))
+	copy(buf, bs)
+
+	s.Lock()
+	defer s.Unlock()
+
+	for !s.closed && len(s.queue) >= s.size {
+		switch s.policy {
+		case DropNewest:
+			s.dropped.Inc()
+			return len(bs), nil
+		case DropOldest:
+			s.queue[0] = nil
+			s.queue = s.queue[1:]
+			s.finished++
+			s.dropped.Inc()
+		default:
+			s.cond.Wait()
+		}
+	}
+	if s.closed {
+		return 0, errAsyncClosed
+	}
+
+	s.queue = append(s.queue, buf)
+	s.enqueued++
+	s.cond.Broadcast()
+	return len(bs), nil
+}
+
+// Sync waits until everything queued before the call has been written (or
+// dropped), then syncs the underlying WriteSyncer. It returns any errors
+// encountered while writing since the last call to Sync.
+func (s *AsyncWriteSyncer) Sync() error {
+	var errs multiError
+	if err := s.flush(); err != nil {
+		errs = append(errs, err)
+	}
+	s.wsMu.Lock()
+	err := s.ws.Sync()
+	s.wsMu.Unlock()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errs.asError()
+}
+
+// Dropped returns the number of writes discarded because the queue was full.
+func (s *AsyncWriteSyncer) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close flushes all queued writes, stops the background goroutine, and syncs
+// the underlying WriteSyncer. Subsequent writes return an error.
+func (s *AsyncWriteSyncer) Close() error {
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.cond.Broadcast()
+	s.Unlock()
+
+	<-s.stopped
+	return s.Sync()
+}
+
+// flush waits for the queued writes, then returns and clears the write
+// errors.
+func (s *AsyncWriteSyncer) flush() error {
+	s.Lock()
+	defer s.Unlock()
+
+	target := s.enqueued
+	for s.finished < target {
+		s.cond.Wait()
+	}
+	var err error
+	switch s.failed {
+	case 0:
+	case 1:
+		err = s.firstErr
+	default:
+		err = fmt.Errorf("%d writes failed; first error: %v; last error: %v", s.failed, s.firstErr, s.lastErr)
+	}
+	s.firstErr, s.lastErr, s.failed = nil, nil, 0
+	return err
+}
+
+func (s *AsyncWriteSyncer) drain() {
+	defer close(s.stopped)
+	for {
+		s.Lock()
+		for len(s.queue) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if len(s.queue) == 0 {
+			// Closed, and nothing left to write.
+			s.Unlock()
+			return
+		}
+		batch := s.queue
+		s.queue, s.spare = s.spare[:0], nil
+		// Writers may be waiting for room in the queue.
+		s.cond.Broadcast()
+		s.Unlock()
+
+		var (
+			first, last error
+			failed      uint64
+		)
+		s.wsMu.Lock()
+		for i, bs := range batch {
+			if _, err := s.ws.Write(bs); err != nil {
+				if first == nil {
+					first = err
+				}
+				last = err
+				failed++
+			}
+			batch[i] = nil
+		}
+		s.wsMu.Unlock()
+
+		s.Lock()
+		s.finished += uint64(len(batch))
+		if failed > 0 {
+			if s.failed == 0 {
+				s.firstErr = first
+			}
+			s.lastErr = last
+			s.failed += failed
+		}
+		s.spare = batch[:0]
+		s.cond.Broadcast()
+		s.Unlock()
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedWriter blocks every write until the gate is opened, which lets tests
+// fill an AsyncWriteSyncer's queue deterministically.
+type gatedWriter struct {
+	sync.Mutex
+	bytes.Buffer
+
+	gate    chan struct{}
+	started chan struct{}
+	once    sync.Once
+	err     error
+	synced  int
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{
+		gate:    make(chan struct{}),
+		started: make(chan struct{}),
+	}
+}
+
+func (w *gatedWriter) Write(bs []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	<-w.gate
+	w.Lock()
+	defer w.Unlock()
+	if w.err != nil {
+		return 0, w.err
+	}
+	return w.Buffer.Write(bs)
+}
+
+func (w *gatedWriter) Sync() error {
+	w.Lock()
+	defer w.Unlock()
+	w.synced++
+	return nil
+}
+
+func (w *gatedWriter) String() string {
+	w.Lock()
+	defer w.Unlock()
+	return w.Buffer.String()
+}
+
+func (w *gatedWriter) open() {
+	close(w.gate)
+}
+
+func withAsyncWriter(t testing.TB, opts []AsyncOption, f func(*AsyncWriteSyncer, *gatedWriter)) {
+	w := newGatedWriter()
+	s := NewAsyncWriteSyncer(w, opts...)
+	defer func() {
+		select {
+		case <-w.gate:
+		default:
+			w.open()
+		}
+		assert.NoError(t, s.Close(), "Unexpected error closing AsyncWriteSyncer.")
+	}()
+	f(s, w)
+}
+
+// fillQueue writes a first entry and waits for the background goroutine to
+// pick it up, then fills the queue with the remaining entries.
+func fillQueue(t testing.TB, s *AsyncWriteSyncer, w *gatedWriter, entries ...string) {
+	s.Write([]byte(entries[0]))
+	<-w.started
+	for _, e := range entries[1:] {
+		n, err := s.Write([]byte(e))
+		require.NoError(t, err, "Unexpected error writing to AsyncWriteSyncer.")
+		require.Equal(t, len(e), n, "Unexpected number of bytes written.")
+	}
+}
+
+func TestAsyncWriteSyncerSync(t *testing.T) {
+	withAsyncWriter(t, nil, func(s *AsyncWriteSyncer, w *gatedWriter) {
+		buf := []byte("foo\n")
+		s.Write(buf)
+		// Mutating the input shouldn't affect queued writes.
+		copy(buf, "bar\n")
+		s.Write(buf)
+
+		w.open()
+		require.NoError(t, s.Sync(), "Unexpected error syncing.")
+		assert.Equal(t, "foo\nbar\n", w.String(), "Expected Sync to wait for queued writes.")
+		assert.Equal(t, 1, w.synced, "Expected Sync to sync the underlying WriteSyncer.")
+		assert.Equal(t, uint64(0), s.Dropped(), "Unexpected dropped writes.")
+	})
+}
+
+func TestAsyncWriteSyncerDropNewest(t *testing.T) {
+	opts := []AsyncOption{QueueSize(2), OnOverflow(DropNewest)}
+	withAsyncWriter(t, opts, func(s *AsyncWriteSyncer, w *gatedWriter) {
+		fillQueue(t, s, w, "1", "2", "3", "4", "5")
+		assert.Equal(t, uint64(2), s.Dropped(), "Unexpected number of dropped writes.")
+
+		w.open()
+		require.NoError(t, s.Sync(), "Unexpected error syncing.")
+		assert.Equal(t, "123", w.String(), "Expected the newest writes to be dropped.")
+	})
+}
+
+func TestAsyncWriteSyncerDropOldest(t *testing.T) {
+	opts := []AsyncOption{QueueSize(2), OnOverflow(DropOldest)}
+	withAsyncWriter(t, opts, func(s *AsyncWriteSyncer, w *gatedWriter) {
+		fillQueue(t, s, w, "1", "2", "3", "4", "5")
+		assert.Equal(t, uint64(2), s.Dropped(), "Unexpected number of dropped writes.")
+
+		w.open()
+		require.NoError(t, s.Sync(), "Unexpected error syncing.")
+		assert.Equal(t, "145", w.String(), "Expected the oldest queued writes to be dropped.")
+	})
+}
+
+func TestAsyncWriteSyncerBlockWhenFull(t *testing.T) {
+	withAsyncWriter(t, []AsyncOption{QueueSize(1)}, func(s *AsyncWriteSyncer, w *gatedWriter) {
+		fillQueue(t, s, w, "1", "2")
+
+		done := make(chan struct{})
+		go func() {
+			s.Write([]byte("3"))
+			close(done)
+		}()
+		select {
+		case <-done:
+			t.Fatal("Expected write to block while the queue is full.")
+		default:
+		}
+
+		w.open()
+		<-done
+		require.NoError(t, s.Sync(), "Unexpected error syncing.")
+		assert.Equal(t, "123", w.String(), "Expected no writes to be lost.")
+		assert.Equal(t, uint64(0), s.Dropped(), "Unexpected dropped writes.")
+	})
+}
+
+func TestAsyncWriteSyncerWriteErrors(t *testing.T) {
+	withAsyncWriter(t, nil, func(s *AsyncWriteSyncer, w *gatedWriter) {
+		w.err = errors.New("fail")
+		w.open()
+		s.Write([]byte("foo"))
+		assert.Error(t, s.Sync(), "Expected Sync to report background write errors.")
+		assert.NoError(t, s.Sync(), "Expected write errors to be reported only once.")
+	})
+}
+
+func TestAsyncWriteSyncerBoundedWriteErrors(t *testing.T) {
+	w := &failingWriter{}
+	s := NewAsyncWriteSyncer(w)
+	defer s.Close()
+
+	for i := 0; i < 100; i++ {
+		s.Write([]byte("foo"))
+	}
+	err := s.Sync()
+	require.Error(t, err, "Expected Sync to report background write errors.")
+	assert.Contains(t, err.Error(), "100 writes failed; first error: fail 1; last error: fail 100", "Expected only the first and last errors to be kept.")
+}
+
+// unsyncedWriter is a WriteSyncer that isn't safe for concurrent use.
+type unsyncedWriter struct {
+	writes, syncs int
+}
+
+func (w *unsyncedWriter) Write(bs []byte) (int, error) {
+	w.writes++
+	return len(bs), nil
+}
+
+func (w *unsyncedWriter) Sync() error {
+	w.syncs++
+	return nil
+}
+
+// failingWriter is an unsyncedWriter whose writes fail with numbered errors.
+type failingWriter struct {
+	unsyncedWriter
+}
+
+func (w *failingWriter) Write(bs []byte) (int, error) {
+	w.unsyncedWriter.Write(bs)
+	return 0, fmt.Errorf("fail %d", w.writes)
+}
+
+func TestAsyncWriteSyncerSyncDuringWrites(t *testing.T) {
+	w := &unsyncedWriter{}
+	s := NewAsyncWriteSyncer(w)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s.Write([]byte("foo"))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				s.Sync()
+			}
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, s.Close(), "Unexpected error closing.")
+	assert.Equal(t, 400, w.writes, "Unexpected number of writes.")
+}
+
+func TestAsyncWriteSyncerClose(t *testing.T) {
+	w := newGatedWriter()
+	s := NewAsyncWriteSyncer(w)
+	s.Write([]byte("foo"))
+	w.open()
+
+	require.NoError(t, s.Close(), "Unexpected error closing.")
+	assert.Equal(t, "foo", w.String(), "Expected Close to flush queued writes.")
+	assert.NoError(t, s.Close(), "Expected closing twice to be a no-op.")
+
+	_, err := s.Write([]byte("bar"))
+	assert.Equal(t, errAsyncClosed, err, "Expected writes after Close to fail.")
+}
+
+func TestAsyncWriteSyncerLogger(t *testing.T) {
+	buf := &testBuffer{}
+	s := NewAsyncWriteSyncer(buf)
+	defer s.Close()
+
+	logger := New(NewJSONEncoder(NoTime()), DebugLevel, Output(s))
+	var wg sync.WaitGroup
+	runConcurrently(10, 100, &wg, func() { logger.Info("foo") })
+	wg.Wait()
+	require.NoError(t, s.Sync(), "Unexpected error syncing.")
+	assert.Equal(t, 1000, len(buf.Lines()), "Expected all entries to be written.")
+}
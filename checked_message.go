@@ -92,6 +92,8 @@ func (m *CheckedMessage) Write(fields ...Field) {
 		m.logger.Warn(m.msg, fields...)
 	case ErrorLevel:
 		m.logger.Error(m.msg, fields...)
+	case DPanicLevel:
+		m.logger.DPanic(m.msg, fields...)
 	case PanicLevel:
 		m.logger.Panic(m.msg, fields...)
 	case FatalLevel:
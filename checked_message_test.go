@@ -59,6 +59,19 @@ func TestCheckedMessageUnsafeWrite(t *testing.T) {
 	})
 }
 
+func TestCheckedMessageDPanic(t *testing.T) {
+	withJSONLogger(t, opts(InfoLevel), func(logger Logger, buf *testBuffer) {
+		assert.NotPanics(t, func() { logger.Check(DPanicLevel, "foo").Write() }, "Expected DPanic not to panic in production.")
+		assert.Equal(t, `{"level":"dpanic","msg":"foo"}`, buf.Stripped(), "Unexpected output from DPanic.")
+	})
+	withJSONLogger(t, opts(FatalLevel+1, Development()), func(logger Logger, buf *testBuffer) {
+		cm := logger.Check(DPanicLevel, "foo")
+		require.True(t, cm.OK(), "Expected DPanicLevel to always be OK in development.")
+		assert.Panics(t, func() { cm.Write() }, "Expected DPanic to panic in development.")
+		assert.Empty(t, buf.String(), "Expected disabled levels not to produce output.")
+	})
+}
+
 func TestCheckedMessage_Chain(t *testing.T) {
 	withJSONLogger(t, opts(InfoLevel), func(logger Logger, buf *testBuffer) {
 		loga := logger.With(String("name", "A"))
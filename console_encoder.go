@@ -0,0 +1,347 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	_consoleTimeFormat = _iso8601Format
+	_colorReset        = "\x1b[0m"
+
+	// Columns are padded to these widths, so that messages line up. Longer
+	// names and callers overflow their columns.
+	_consoleLevelWidth  = len("DPANIC")
+	_consoleNameWidth   = 12
+	_consoleCallerWidth = 20
+)
+
+var (
+	consolePool = sync.Pool{New: func() interface{} {
+		return &consoleEncoder{}
+	}}
+
+	// Since checking whether a file is a terminal requires a syscall, cache
+	// the results.
+	_terminals = terminalCache{files: make(map[*os.File]bool)}
+)
+
+type colorMode int
+
+const (
+	colorAuto colorMode = iota
+	colorAlways
+	colorNever
+)
+
+// consoleEncoder is an Encoder implementation that writes human-friendly,
+// optionally colorized output. Fields are encoded with a logfmt encoder.
+type consoleEncoder struct {
+	*logfmtEncoder
+
+	timeFmt   string
+	callerKey string
+	colors    colorMode
+
+	// Values lifted out of the fields and written in their own columns.
+	caller string
+	stack  string
+}
+
+// NewConsoleEncoder creates an encoder whose output is optimized for reading
+// in a terminal during development. Each entry is written as a line of
+// space-separated columns: the timestamp, the level, the logger's name (if
+// any), the caller (if any; see AddCaller), the message, and finally the
+// entry's fields, encoded as logfmt. The level, name, and caller columns are
+// padded to fixed widths, so that messages line up. Stacktraces (see Stack
+// and AddStacks) are written on the following lines, just as they'd appear in
+// a panic.
+//
+// Level names are colorized with ANSI escape codes when the logger's output is
+// a terminal (see Terminal) and the NO_COLOR environment variable isn't set.
+// See ConsoleColors to override the automatic detection.
+//
+// Time and duration fields are rendered with ISO8601TimeEncoder and
+// StringDurationEncoder.
+func NewConsoleEncoder(options ...ConsoleOption) Encoder {
+	enc := consolePool.Get().(*consoleEncoder)
+	enc.logfmtEncoder = newLogfmtFields()
+	enc.timeEnc = ISO8601TimeEncoder()
+	enc.durationEnc = StringDurationEncoder()
+	enc.timeFmt = _consoleTimeFormat
+	enc.callerKey = "caller"
+	enc.colors = colorAuto
+	if os.Getenv("NO_COLOR") != "" {
+		enc.colors = colorNever
+	}
+	enc.caller, enc.stack = "", ""
+	for _, opt := range options {
+		opt.apply(enc)
+	}
+	return enc
+}
+
+func newLogfmtFields() *logfmtEncoder {
+	enc := logfmtPool.Get().(*logfmtEncoder)
+	enc.truncate()
+	return enc
+}
+
+func (enc *consoleEncoder) Free() {
+	enc.logfmtEncoder.Free()
+	enc.logfmtEncoder = nil
+	consolePool.Put(enc)
+}
+
+// AddString adds a string key and value to the encoder's fields. The caller
+// and stacktrace are kept aside, so that they can be written in their own
+// columns.
+func (enc *consoleEncoder) AddString(key, val string) {
+	switch {
+	case key == enc.callerKey && key != "":
+		enc.caller = val
+	case key == "stacktrace":
+		enc.stack = val
+	default:
+		enc.logfmtEncoder.AddString(key, val)
+	}
+}
+
+// Clone copies the current encoder, including any data already encoded.
+func (enc *consoleEncoder) Clone() Encoder {
+	clone := consolePool.Get().(*consoleEncoder)
+	clone.logfmtEncoder = newLogfmtFields()
+	clone.setEncoders(enc.logfmtEncoder)
+	clone.bytes = append(clone.bytes, enc.bytes...)
+	clone.timeFmt = enc.timeFmt
+	clone.callerKey = enc.callerKey
+	clone.colors = enc.colors
+	clone.caller = enc.caller
+	clone.stack = enc.stack
+	return clone
+}
+
+// WriteEntry writes a complete log message to the supplied writer, including
+// the encoder's accumulated fields. It doesn't modify or lock the encoder's
+// underlying byte slice. It's safe to call from multiple goroutines, but it's
+// not safe to call WriteEntry while adding fields.
+func (enc *consoleEncoder) WriteEntry(sink io.Writer, ent Entry) error {
+	if sink == nil {
+		return errNilSink
+	}
+
+	final := newLogfmtFields()
+	if enc.timeFmt != "" {
+		final.bytes = ent.Time.AppendFormat(final.bytes, enc.timeFmt)
+		final.bytes = append(final.bytes, ' ')
+	}
+	enc.addLevel(final, ent.Level, enc.useColor(sink))
+	addColumn(final, ent.LoggerName, _consoleNameWidth)
+	addColumn(final, enc.caller, _consoleCallerWidth)
+	addColumn(final, ent.Message, 0)
+	if len(enc.bytes) > 0 {
+		final.bytes = append(final.bytes, ' ')
+		final.bytes = append(final.bytes, enc.bytes...)
+	}
+	// Drop the padding after the last column.
+	for len(final.bytes) > 0 && final.bytes[len(final.bytes)-1] == ' ' {
+		final.bytes = final.bytes[:len(final.bytes)-1]
+	}
+	final.bytes = append(final.bytes, '\n')
+	if enc.stack != "" {
+		final.bytes = append(final.bytes, strings.TrimRight(enc.stack, "\n")...)
+		final.bytes = append(final.bytes, '\n')
+	}
+
+	expectedBytes := len(final.bytes)
+	n, err := sink.Write(final.bytes)
+	final.Free()
+	if err != nil {
+		return err
+	}
+	if n != expectedBytes {
+		return fmt.Errorf("incomplete write: only wrote %v of %v bytes", n, expectedBytes)
+	}
+	return nil
+}
+
+func (enc *consoleEncoder) addLevel(final *logfmtEncoder, lvl Level, color bool) {
+	name := capitalLevelName(lvl)
+	if color {
+		final.bytes = append(final.bytes, levelColor(lvl)...)
+		final.bytes = append(final.bytes, name...)
+		final.bytes = append(final.bytes, _colorReset...)
+	} else {
+		final.bytes = append(final.bytes, name...)
+	}
+	// Pad outside the escape codes, so that colors don't affect alignment.
+	addPadding(final, _consoleLevelWidth-len(name))
+}
+
+// addColumn adds a space-separated column padded to the given width,
+// skipping empty values.
+func addColumn(final *logfmtEncoder, col string, width int) {
+	if col == "" {
+		return
+	}
+	final.bytes = append(final.bytes, ' ')
+	final.bytes = append(final.bytes, col...)
+	addPadding(final, width-utf8.RuneCountInString(col))
+}
+
+func addPadding(final *logfmtEncoder, n int) {
+	for ; n > 0; n-- {
+		final.bytes = append(final.bytes, ' ')
+	}
+}
+
+func (enc *consoleEncoder) useColor(sink io.Writer) bool {
+	switch enc.colors {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	return isTerminal(sink)
+}
+
+// A Terminal is a writer that can report whether it's connected to an
+// interactive terminal. The console encoder only colorizes output written to
+// terminals. Files are detected automatically, and zap's own WriteSyncer
+// wrappers (e.g., those added by Output and AddSync) forward the question to
+// the writer they wrap; other wrappers can implement Terminal to do the same.
+type Terminal interface {
+	IsTerminal() bool
+}
+
+func isTerminal(w io.Writer) bool {
+	switch t := w.(type) {
+	case Terminal:
+		return t.IsTerminal()
+	case *os.File:
+		return _terminals.isTerminal(t)
+	default:
+		return false
+	}
+}
+
+func capitalLevelName(lvl Level) string {
+	switch lvl {
+	case DebugLevel:
+		return "DEBUG"
+	case InfoLevel:
+		return "INFO"
+	case WarnLevel:
+		return "WARN"
+	case ErrorLevel:
+		return "ERROR"
+	case DPanicLevel:
+		return "DPANIC"
+	case PanicLevel:
+		return "PANIC"
+	case FatalLevel:
+		return "FATAL"
+	default:
+		return strings.ToUpper(lvl.String())
+	}
+}
+
+func levelColor(lvl Level) string {
+	switch lvl {
+	case DebugLevel:
+		return "\x1b[35m" // magenta
+	case InfoLevel:
+		return "\x1b[34m" // blue
+	case WarnLevel:
+		return "\x1b[33m" // yellow
+	default:
+		return "\x1b[31m" // red
+	}
+}
+
+type terminalCache struct {
+	sync.RWMutex
+	files map[*os.File]bool
+}
+
+func (c *terminalCache) isTerminal(f *os.File) bool {
+	c.RLock()
+	is, ok := c.files[f]
+	c.RUnlock()
+	if ok {
+		return is
+	}
+
+	info, err := f.Stat()
+	is = err == nil && info.Mode()&os.ModeCharDevice != 0
+	c.Lock()
+	c.files[f] = is
+	c.Unlock()
+	return is
+}
+
+// A ConsoleOption is used to set options for a console encoder.
+type ConsoleOption interface {
+	apply(*consoleEncoder)
+}
+
+type consoleOptionFunc func(*consoleEncoder)
+
+func (opt consoleOptionFunc) apply(enc *consoleEncoder) {
+	opt(enc)
+}
+
+// ConsoleTimeFormat sets the format for log timestamps, using the same layout
+// strings supported by time.Parse. Passing an empty layout omits timestamps.
+// The default layout is ISO8601 with millisecond precision.
+func ConsoleTimeFormat(layout string) ConsoleOption {
+	return consoleOptionFunc(func(enc *consoleEncoder) {
+		enc.timeFmt = layout
+	})
+}
+
+// ConsoleColors forces level names to be colorized (or not), regardless of
+// the output and the NO_COLOR environment variable.
+func ConsoleColors(enabled bool) ConsoleOption {
+	return consoleOptionFunc(func(enc *consoleEncoder) {
+		if enabled {
+			enc.colors = colorAlways
+		} else {
+			enc.colors = colorNever
+		}
+	})
+}
+
+// ConsoleCallerKey sets the key of the field (added by AddCaller) that's
+// written in the caller column. The default is "caller", which matches
+// AddCaller's default. Passing an empty key disables the column, so that the
+// caller is encoded with the rest of the fields.
+func ConsoleCallerKey(key string) ConsoleOption {
+	return consoleOptionFunc(func(enc *consoleEncoder) {
+		enc.callerKey = key
+	})
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uber-go/zap/spywrite"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withConsoleLogger(t testing.TB, opts []ConsoleOption, f func(Logger, *testBuffer)) {
+	sink := &testBuffer{}
+	enc := NewConsoleEncoder(append([]ConsoleOption{ConsoleTimeFormat("")}, opts...)...)
+	f(New(enc, DebugLevel, Output(sink)), sink)
+}
+
+func TestConsoleWriteEntry(t *testing.T) {
+	enc := NewConsoleEncoder(ConsoleColors(false))
+	entry := Entry{Level: WarnLevel, Message: "hello world", Time: epoch, LoggerName: "foo.bar"}
+	assert.Equal(t, errNilSink, enc.WriteEntry(nil, entry), "Expected an error writing to a nil sink.")
+
+	sink := &testBuffer{}
+	enc.AddString("k", "a b")
+	enc.AddInt("n", 42)
+	enc.AddDuration("d", time.Second)
+	enc.AddTime("t", epoch.UTC())
+	for _, e := range []Encoder{enc, enc.Clone()} {
+		sink.Reset()
+		require.NoError(t, e.WriteEntry(sink, entry), "Unexpected error writing entry.")
+		assert.Equal(
+			t,
+			"1970-01-01T00:00:00.000Z WARN   foo.bar      hello world k=\"a b\" n=42 d=1s t=1970-01-01T00:00:00.000Z",
+			sink.Stripped(),
+			"Unexpected console output.",
+		)
+	}
+}
+
+func TestConsoleWriteEntryFailure(t *testing.T) {
+	enc := NewConsoleEncoder()
+	defer enc.Free()
+	for _, sink := range []io.Writer{spywrite.FailWriter{}, spywrite.ShortWriter{}} {
+		assert.Error(t, enc.WriteEntry(sink, Entry{Message: "hello", Time: epoch}), "Expected an error writing to %T.", sink)
+	}
+}
+
+func TestConsoleLevels(t *testing.T) {
+	withConsoleLogger(t, []ConsoleOption{ConsoleColors(true)}, func(logger Logger, buf *testBuffer) {
+		logger.Debug("debug")
+		logger.Info("info")
+		logger.Warn("warn")
+		logger.Error("error")
+		logger.DPanic("dpanic")
+		assert.Equal(t, []string{
+			"\x1b[35mDEBUG\x1b[0m  debug",
+			"\x1b[34mINFO\x1b[0m   info",
+			"\x1b[33mWARN\x1b[0m   warn",
+			"\x1b[31mERROR\x1b[0m  error",
+			"\x1b[31mDPANIC\x1b[0m dpanic",
+		}, buf.Lines(), "Unexpected colorized levels.")
+	})
+}
+
+func TestConsoleCallerAndStacktrace(t *testing.T) {
+	withConsoleLogger(t, nil, func(logger Logger, buf *testBuffer) {
+		logger = logger.With(String("k", "v"))
+		logger.Info("caller", String("caller", "foo.go:42"))
+		logger.Info("stack", Stack())
+
+		lines := buf.Lines()
+		require.True(t, len(lines) > 3, "Expected a multi-line stacktrace.")
+		assert.Equal(t, "INFO   foo.go:42            caller k=v", lines[0], "Expected caller in its own column.")
+		assert.Equal(t, "INFO   stack k=v", lines[1], "Expected stacktrace to be removed from fields.")
+		assert.True(t, strings.HasPrefix(lines[2], "goroutine "), "Expected stacktrace on the following lines.")
+		assert.Contains(t, buf.String(), "zap.TestConsoleCallerAndStacktrace", "Expected to find the test function in the stacktrace.")
+	})
+
+	// Nested keys and disabled caller columns shouldn't be lifted out.
+	withConsoleLogger(t, []ConsoleOption{ConsoleCallerKey("")}, func(logger Logger, buf *testBuffer) {
+		logger.Info("caller", String("caller", "foo.go:42"), Nest("n", String("stacktrace", "x")))
+		assert.Equal(t, "INFO   caller caller=foo.go:42 n.stacktrace=x", buf.Stripped(), "Unexpected output without a caller column.")
+	})
+}
+
+func TestConsoleAddCaller(t *testing.T) {
+	sink := &testBuffer{}
+	logger := New(NewConsoleEncoder(ConsoleTimeFormat("")), Output(sink), AddCaller())
+	logger.Named("foo").Info("hello")
+	assert.Regexp(t, `^INFO   foo {10}console_encoder_test.go:\d+ +hello$`, sink.Stripped(), "Expected the caller column.")
+}
+
+func TestConsoleColorDetection(t *testing.T) {
+	f, err := ioutil.TempFile("", "zap-console")
+	require.NoError(t, err, "Failed to create temporary file.")
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	enc := NewConsoleEncoder().(*consoleEncoder)
+	assert.False(t, enc.useColor(f), "Expected no colors when writing to a file.")
+	assert.False(t, enc.useColor(newLockedWriteSyncer(f)), "Expected no colors when writing to a locked file.")
+	assert.False(t, enc.useColor(&testBuffer{}), "Expected no colors when writing to a buffer.")
+	assert.True(t, enc.useColor(newLockedWriteSyncer(AddSync(&fakeTerminal{}))), "Expected wrapped Terminals to be detected.")
+
+	assert.True(t, NewConsoleEncoder(ConsoleColors(true)).(*consoleEncoder).useColor(f), "Expected forced colors.")
+
+	os.Setenv("NO_COLOR", "1")
+	defer os.Unsetenv("NO_COLOR")
+	assert.Equal(t, colorNever, NewConsoleEncoder().(*consoleEncoder).colors, "Expected NO_COLOR to disable colors.")
+}
+
+type fakeTerminal struct{ testBuffer }
+
+func (fakeTerminal) IsTerminal() bool { return true }
+
+func TestConsoleAlignment(t *testing.T) {
+	withConsoleLogger(t, nil, func(logger Logger, buf *testBuffer) {
+		logger.Info("one")
+		logger.Named("http").Error("two")
+		logger.Named("rpc.client").DPanic("three")
+		assert.Equal(t, []string{
+			"INFO   one",
+			"ERROR  http         two",
+			"DPANIC rpc.client   three",
+		}, buf.Lines(), "Expected padded level and name columns.")
+	})
+}
+
+func TestConsoleTimeFormat(t *testing.T) {
+	sink := &testBuffer{}
+	enc := NewConsoleEncoder(ConsoleTimeFormat(time.Kitchen), ConsoleColors(false))
+	enc.WriteEntry(sink, Entry{Level: InfoLevel, Message: "hello", Time: epoch})
+	assert.Equal(t, "12:00AM INFO   hello", sink.Stripped(), "Unexpected custom time format.")
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	fieldsKey
+)
+
+var (
+	_extractorsMu sync.Mutex
+	_extractors   atomic.Value // []ContextExtractor
+)
+
+// A ContextExtractor pulls values out of a context.Context and returns them
+// as fields. Extractors run each time a logger retrieved with FromContext
+// writes an entry, so they see values (like trace IDs or spans) added to the
+// context after the logger was retrieved.
+type ContextExtractor func(context.Context) []Field
+
+// RegisterContextExtractor adds an extractor to the global list consulted by
+// loggers retrieved with FromContext. Extractors run in the order they were
+// registered, and they must be safe for concurrent use. Register them while
+// initializing the program, since FromContext only arranges to run extractors
+// if at least one is registered when it's called.
+func RegisterContextExtractor(extract ContextExtractor) {
+	_extractorsMu.Lock()
+	defer _extractorsMu.Unlock()
+	existing := contextExtractors()
+	// Copy, so that concurrent readers never see a partially-updated slice.
+	all := make([]ContextExtractor, 0, len(existing)+1)
+	all = append(all, existing...)
+	all = append(all, extract)
+	_extractors.Store(all)
+}
+
+func contextExtractors() []ContextExtractor {
+	extractors, _ := _extractors.Load().([]ContextExtractor)
+	return extractors
+}
+
+// NewContext returns a copy of the parent context that carries the supplied
+// Logger.
+func NewContext(ctx context.Context, log Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, log)
+}
+
+// ContextWithFields returns a copy of the parent context that carries the
+// supplied fields in addition to any fields already stored in the parent.
+func ContextWithFields(ctx context.Context, fields ...Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+	existing, _ := ctx.Value(fieldsKey).([]Field)
+	// Copy, so that sibling contexts never share a backing array.
+	all := make([]Field, 0, len(existing)+len(fields))
+	all = append(all, existing...)
+	all = append(all, fields...)
+	return context.WithValue(ctx, fieldsKey, all)
+}
+
+// ContextFields returns the fields stored in the context, followed by the
+// current output of the registered ContextExtractors.
+func ContextFields(ctx context.Context) []Field {
+	fields, _ := ctx.Value(fieldsKey).([]Field)
+	extractors := contextExtractors()
+	if len(extractors) == 0 {
+		return fields
+	}
+	all := make([]Field, 0, len(fields)+len(extractors))
+	all = append(all, fields...)
+	for _, extract := range extractors {
+		all = append(all, extract(ctx)...)
+	}
+	return all
+}
+
+// FromContext returns the Logger stored in the context, or the fallback if
+// there isn't one, with the context's stored fields added to its logging
+// context. If any ContextExtractors are registered, the returned logger runs
+// them each time it writes an entry, adding their output before the fields
+// passed at the log site. Since the result is an ordinary Logger, it works
+// with any implementation (including Tee) and with Check.
+func FromContext(ctx context.Context, fallback Logger) Logger {
+	log, ok := ctx.Value(loggerKey).(Logger)
+	if !ok || log == nil {
+		log = fallback
+	}
+	if log == nil {
+		return nil
+	}
+	if fields, _ := ctx.Value(fieldsKey).([]Field); len(fields) > 0 {
+		log = log.With(fields...)
+	}
+	if len(contextExtractors()) > 0 {
+		log = &contextLogger{Logger: log, ctx: ctx}
+	}
+	return log
+}
+
+// contextLogger adds the output of the registered ContextExtractors to each
+// entry.
+type contextLogger struct {
+	Logger
+
+	ctx context.Context
+}
+
+func (l *contextLogger) With(fields ...Field) Logger {
+	return &contextLogger{Logger: l.Logger.With(fields...), ctx: l.ctx}
+}
+
+func (l *contextLogger) Named(name string) Logger {
+	return &contextLogger{Logger: l.Logger.Named(name), ctx: l.ctx}
+}
+
+func (l *contextLogger) IsDevelopment() bool {
+	return IsDevelopment(l.Logger)
+}
+
+// Check returns a CheckedMessage against the contextLogger itself, so that
+// writing it runs the extractors. It checks the level without calling the
+// wrapped logger's Check, since that may have side effects (e.g., a sampler
+// counting the entry) that writing the message would then repeat.
+func (l *contextLogger) Check(lvl Level, msg string) *CheckedMessage {
+	switch lvl {
+	case PanicLevel, FatalLevel:
+		// Like Meta.Check, always panic or exit.
+	case DPanicLevel:
+		if !IsDevelopment(l.Logger) && !l.enabled(lvl) {
+			return nil
+		}
+	default:
+		if !l.enabled(lvl) {
+			return nil
+		}
+	}
+	return NewCheckedMessage(l, lvl, msg)
+}
+
+func (l *contextLogger) Log(lvl Level, msg string, fields ...Field) {
+	if l.enabled(lvl) {
+		l.Logger.Log(lvl, msg, l.fields(fields)...)
+	}
+}
+
+func (l *contextLogger) Debug(msg string, fields ...Field) {
+	if l.enabled(DebugLevel) {
+		l.Logger.Debug(msg, l.fields(fields)...)
+	}
+}
+
+func (l *contextLogger) Info(msg string, fields ...Field) {
+	if l.enabled(InfoLevel) {
+		l.Logger.Info(msg, l.fields(fields)...)
+	}
+}
+
+func (l *contextLogger) Warn(msg string, fields ...Field) {
+	if l.enabled(WarnLevel) {
+		l.Logger.Warn(msg, l.fields(fields)...)
+	}
+}
+
+func (l *contextLogger) Error(msg string, fields ...Field) {
+	if l.enabled(ErrorLevel) {
+		l.Logger.Error(msg, l.fields(fields)...)
+	}
+}
+
+func (l *contextLogger) DPanic(msg string, fields ...Field) {
+	l.Logger.DPanic(msg, l.fields(fields)...)
+}
+
+func (l *contextLogger) Panic(msg string, fields ...Field) {
+	l.Logger.Panic(msg, l.fields(fields)...)
+}
+
+func (l *contextLogger) Fatal(msg string, fields ...Field) {
+	l.Logger.Fatal(msg, l.fields(fields)...)
+}
+
+// enabled reports whether the wrapped logger might write an entry at the
+// given level, so that extractors don't run for disabled levels.
+func (l *contextLogger) enabled(lvl Level) bool {
+	if enab, ok := l.Logger.(LevelEnabler); ok {
+		return enab.Enabled(lvl)
+	}
+	return true
+}
+
+// fields returns the output of the registered extractors, followed by the
+// fields passed at the log site.
+func (l *contextLogger) fields(fields []Field) []Field {
+	extractors := contextExtractors()
+	all := make([]Field, 0, len(extractors)+len(fields))
+	for _, extract := range extractors {
+		all = append(all, extract(l.ctx)...)
+	}
+	return append(all, fields...)
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type traceKey struct{}
+
+// A span's ID is only known once it starts, which may be after a logger is
+// retrieved from the context.
+type span struct{ id string }
+
+func extractTrace(ctx context.Context) []Field {
+	if s, ok := ctx.Value(traceKey{}).(*span); ok && s.id != "" {
+		return []Field{String("trace", s.id)}
+	}
+	return nil
+}
+
+// withExtractors replaces the global extractors for the duration of f. Since
+// they're global, tests that use it must not call t.Parallel.
+func withExtractors(extractors []ContextExtractor, f func()) {
+	existing := contextExtractors()
+	defer _extractors.Store(existing)
+	_extractors.Store([]ContextExtractor(nil))
+	for _, extract := range extractors {
+		RegisterContextExtractor(extract)
+	}
+	f()
+}
+
+func TestContextExtractorsRunAtLogTime(t *testing.T) {
+	withExtractors([]ContextExtractor{extractTrace}, func() {
+		withJSONLogger(t, nil, func(logger Logger, buf *testBuffer) {
+			s := &span{}
+			ctx := context.WithValue(context.Background(), traceKey{}, s)
+			ctx = ContextWithFields(ctx, String("request", "abc"))
+			log := FromContext(ctx, logger)
+
+			log.Info("no trace")
+			s.id = "xyz"
+			log.Info("trace", Int("n", 1))
+			log.With(Int("foo", 42)).Named("child").Info("child")
+			log.Check(InfoLevel, "checked").Write()
+			assert.Equal(t, []string{
+				`{"level":"info","msg":"no trace","request":"abc"}`,
+				`{"level":"info","msg":"trace","request":"abc","trace":"xyz","n":1}`,
+				`{"level":"info","logger":"child","msg":"child","request":"abc","foo":42,"trace":"xyz"}`,
+				`{"level":"info","msg":"checked","request":"abc","trace":"xyz"}`,
+			}, buf.Lines(), "Expected extractors to run each time an entry is written.")
+			assert.Equal(t, []Field{String("request", "abc"), String("trace", "xyz")}, ContextFields(ctx), "Unexpected context fields.")
+		})
+	})
+}
+
+func TestContextExtractorsSkipDisabledLevels(t *testing.T) {
+	calls := 0
+	extract := func(context.Context) []Field {
+		calls++
+		return nil
+	}
+	withExtractors([]ContextExtractor{extract}, func() {
+		withJSONLogger(t, opts(WarnLevel), func(logger Logger, buf *testBuffer) {
+			log := FromContext(context.Background(), logger)
+			log.Info("")
+			assert.Nil(t, log.Check(InfoLevel, ""), "Expected a nil CheckedMessage at disabled levels.")
+			assert.Equal(t, 0, calls, "Expected extractors not to run at disabled levels.")
+			assert.False(t, IsDevelopment(log), "Expected context loggers to report the wrapped logger's mode.")
+		})
+	})
+}
+
+// checkCountingLogger counts calls to the wrapped logger's Check.
+type checkCountingLogger struct {
+	Logger
+	checks int
+}
+
+func (l *checkCountingLogger) Enabled(lvl Level) bool {
+	return l.Logger.(LevelEnabler).Enabled(lvl)
+}
+
+func (l *checkCountingLogger) Check(lvl Level, msg string) *CheckedMessage {
+	l.checks++
+	return l.Logger.Check(lvl, msg)
+}
+
+func TestContextLoggerCheck(t *testing.T) {
+	withExtractors([]ContextExtractor{extractTrace}, func() {
+		withJSONLogger(t, opts(WarnLevel), func(logger Logger, buf *testBuffer) {
+			counting := &checkCountingLogger{Logger: logger}
+			log := FromContext(context.Background(), counting)
+
+			assert.Nil(t, log.Check(InfoLevel, ""), "Expected a nil CheckedMessage at disabled levels.")
+			log.Check(WarnLevel, "warn").Write()
+			assert.True(t, log.Check(PanicLevel, "").OK(), "Expected Panic to always be checked.")
+			assert.True(t, log.Check(FatalLevel, "").OK(), "Expected Fatal to always be checked.")
+			assert.Equal(t, 0, counting.checks, "Expected Check not to call the wrapped logger's Check.")
+			assert.Equal(t, `{"level":"warn","msg":"warn"}`, buf.Stripped(), "Unexpected output from a checked message.")
+		})
+	})
+}
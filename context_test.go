@@ -0,0 +1,93 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/uber-go/zap"
+	"github.com/uber-go/zap/spy"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContextFallback(t *testing.T) {
+	fallback, sink := spy.New(zap.DebugLevel)
+	log := zap.FromContext(context.Background(), fallback)
+	assert.True(t, log == fallback, "Expected fallback logger when context is empty.")
+
+	log.Info("foo")
+	assert.Equal(t, []spy.Log{{Level: zap.InfoLevel, Msg: "foo", Fields: []zap.Field{}}}, sink.Logs())
+}
+
+func TestFromContextStoredLogger(t *testing.T) {
+	stored, storedSink := spy.New(zap.DebugLevel)
+	fallback, fallbackSink := spy.New(zap.DebugLevel)
+
+	ctx := zap.NewContext(context.Background(), stored)
+	ctx = zap.ContextWithFields(ctx, zap.String("request", "abc"))
+	ctx = zap.ContextWithFields(ctx, zap.Int("tenant", 42))
+	zap.FromContext(ctx, fallback).Info("foo", zap.Bool("b", true))
+
+	expected := []spy.Log{{
+		Level: zap.InfoLevel,
+		Msg:   "foo",
+		Fields: []zap.Field{
+			zap.String("request", "abc"),
+			zap.Int("tenant", 42),
+			zap.Bool("b", true),
+		},
+	}}
+	assert.Equal(t, expected, storedSink.Logs(), "Expected context fields on stored logger.")
+	assert.Empty(t, fallbackSink.Logs(), "Expected fallback logger to be unused.")
+}
+
+func TestContextFieldsDontAlias(t *testing.T) {
+	parent := zap.ContextWithFields(context.Background(), zap.Int("a", 1))
+	child1 := zap.ContextWithFields(parent, zap.Int("b", 2))
+	child2 := zap.ContextWithFields(parent, zap.Int("c", 3))
+
+	assert.Equal(t, []zap.Field{zap.Int("a", 1)}, zap.ContextFields(parent))
+	assert.Equal(t, []zap.Field{zap.Int("a", 1), zap.Int("b", 2)}, zap.ContextFields(child1))
+	assert.Equal(t, []zap.Field{zap.Int("a", 1), zap.Int("c", 3)}, zap.ContextFields(child2))
+}
+
+func TestFromContextTeeCheck(t *testing.T) {
+	log1, sink1 := spy.New(zap.DebugLevel)
+	log2, sink2 := spy.New(zap.WarnLevel)
+	ctx := zap.NewContext(context.Background(), zap.Tee(log1, log2))
+	ctx = zap.ContextWithFields(ctx, zap.String("request", "abc"))
+	log := zap.FromContext(ctx, nil)
+
+	assert.Nil(t, log.Check(zap.DebugLevel-1, "disabled"), "Expected nil CheckedMessage at disabled levels.")
+	for _, lvl := range []zap.Level{zap.InfoLevel, zap.WarnLevel} {
+		if cm := log.Check(lvl, "checked"); assert.True(t, cm.OK(), "Expected %v to be enabled.", lvl) {
+			cm.Write(zap.Int("n", 1))
+		}
+	}
+
+	fields := []zap.Field{zap.String("request", "abc"), zap.Int("n", 1)}
+	info := spy.Log{Level: zap.InfoLevel, Msg: "checked", Fields: fields}
+	warn := spy.Log{Level: zap.WarnLevel, Msg: "checked", Fields: fields}
+	assert.Equal(t, []spy.Log{info, warn}, sink1.Logs(), "Unexpected output from first logger.")
+	assert.Equal(t, []spy.Log{warn}, sink2.Logs(), "Unexpected output from second logger.")
+}
@@ -23,19 +23,35 @@ package zap
 import "time"
 
 // An Entry represents a complete log message. The entry's structured context
-// is already serialized, but the log level, time, and message are available
-// for inspection and modification.
+// is already serialized, but the log level, time, message, and logger name are
+// available for inspection and modification.
 //
 // Entries are pooled, so any functions that accept them must be careful not to
 // retain references to them.
 type Entry struct {
-	Level   Level
-	Time    time.Time
-	Message string
-	enc     Encoder
+	Level      Level
+	Time       time.Time
+	LoggerName string
+	Message    string
+	enc        Encoder
+	ctx        *loggerContext
+	callerSkip int
 }
 
 // Fields returns a mutable reference to the entry's accumulated context.
 func (e Entry) Fields() KeyValue {
 	return e.enc
 }
+
+// Context returns the fields added to the logger with With (including those
+// added to its ancestors), oldest first. Unlike Fields, it returns the
+// original Fields rather than their serialized form, so hooks can inspect
+// them without decoding. It doesn't include fields passed to the logging call
+// itself or added by the Fields option. Since only hooks need it, loggers
+// without hooks don't keep their context as Fields, and Context returns nil
+// for their entries (e.g., in an Encoder's WriteEntry).
+//
+// The returned slice is a copy, so modifying it doesn't affect the logger.
+func (e Entry) Context() []Field {
+	return e.ctx.Fields()
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import "fmt"
+
+// _maxErrorDepth limits both the length of a chain of wrapped errors and the
+// nesting of error groups, so that an error that wraps or contains itself
+// can't hang or overflow the stack of the logging goroutine.
+const _maxErrorDepth = 32
+
+// An errorGroup is an error that aggregates other errors, like the package's
+// own multiError.
+type errorGroup interface {
+	Errors() []error
+}
+
+// A causer is an error that wraps another, in the style of
+// github.com/pkg/errors.
+type causer interface {
+	Cause() error
+}
+
+// A wrapper is an error that wraps another, in the style of the standard
+// library's error wrapping.
+type wrapper interface {
+	Unwrap() error
+}
+
+func unwrapError(err error) error {
+	switch e := err.(type) {
+	case causer:
+		return e.Cause()
+	case wrapper:
+		return e.Unwrap()
+	default:
+		return nil
+	}
+}
+
+// encodeError adds err.Error() under the supplied key, along with any
+// additional information the error carries:
+//
+//	keyCauses:  an array of the errors wrapped by err, or of the errors in an
+//	            errorGroup
+//	keyVerbose: the %+v representation of errors that implement fmt.Formatter,
+//	            if it differs from err.Error() (e.g., it includes a stacktrace)
+//	keyFields:  the fields added by errors that implement LogMarshaler
+//
+// Wrapped errors are flattened into a single array. Since the verbose
+// representation of a wrapping error typically includes its causes, chained
+// errors don't repeat it. Chains longer than _maxErrorDepth are truncated, and
+// error groups nested more deeply aren't expanded.
+func encodeError(kv KeyValue, key string, err error, chained bool, depth int) {
+	basic := err.Error()
+	kv.AddString(key, basic)
+
+	if group, ok := err.(errorGroup); ok {
+		if depth < _maxErrorDepth {
+			kv.AddArray(key+"Causes", errorCauses{errs: group.Errors(), depth: depth + 1})
+		}
+	} else if !chained {
+		var chain []error
+		for cause := unwrapError(err); cause != nil && len(chain) < _maxErrorDepth; cause = unwrapError(cause) {
+			chain = append(chain, cause)
+		}
+		if len(chain) > 0 {
+			kv.AddArray(key+"Causes", errorCauses{errs: chain, chained: true, depth: depth + 1})
+		}
+	}
+
+	if f, ok := err.(fmt.Formatter); ok && !chained {
+		if verbose := fmt.Sprintf("%+v", f); verbose != basic {
+			kv.AddString(key+"Verbose", verbose)
+		}
+	}
+
+	if m, ok := err.(LogMarshaler); ok {
+		if err := kv.AddMarshaler(key+"Fields", m); err != nil {
+			kv.AddString(key+"FieldsError", err.Error())
+		}
+	}
+}
+
+type errorCauses struct {
+	errs    []error
+	chained bool
+	depth   int
+}
+
+func (c errorCauses) MarshalLogArray(arr ArrayEncoder) error {
+	for _, err := range c.errs {
+		if err == nil {
+			continue
+		}
+		arr.AppendMarshaler(errorCause{err, c.chained, c.depth})
+	}
+	return nil
+}
+
+type errorCause struct {
+	err     error
+	chained bool
+	depth   int
+}
+
+func (c errorCause) MarshalLog(kv KeyValue) error {
+	encodeError(kv, "error", c.err, c.chained, c.depth)
+	return nil
+}
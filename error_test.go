@@ -0,0 +1,163 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+type causedError struct {
+	msg   string
+	cause error
+}
+
+func (e causedError) Error() string { return e.msg + ": " + e.cause.Error() }
+func (e causedError) Cause() error  { return e.cause }
+
+type unwrappableError struct {
+	msg   string
+	inner error
+}
+
+func (e unwrappableError) Error() string { return e.msg + ": " + e.inner.Error() }
+func (e unwrappableError) Unwrap() error { return e.inner }
+
+// loopError is its own cause.
+type loopError struct{}
+
+func (e loopError) Error() string { return "loop" }
+func (e loopError) Cause() error  { return e }
+
+// loopGroup contains itself.
+type loopGroup struct{}
+
+func (g loopGroup) Error() string   { return "group" }
+func (g loopGroup) Errors() []error { return []error{g} }
+
+type stackedError struct{ msg string }
+
+func (e stackedError) Error() string { return e.msg }
+
+func (e stackedError) Format(s fmt.State, verb rune) {
+	io.WriteString(s, e.msg)
+	if s.Flag('+') {
+		io.WriteString(s, "\nstack")
+	}
+}
+
+type userError struct{ name string }
+
+func (e userError) Error() string { return "bad user" }
+
+func (e userError) MarshalLog(kv KeyValue) error {
+	if e.name == "fail" {
+		return errors.New("fail")
+	}
+	kv.AddString("name", e.name)
+	return nil
+}
+
+func TestNamedErrorField(t *testing.T) {
+	assertFieldJSON(t, `"err":"fail"`, NamedError("err", errors.New("fail")))
+	assertFieldJSON(t, ``, NamedError("err", nil))
+	assertFieldJSON(t, `"err":"fail"`, Any("err", errors.New("fail")))
+	assertCanBeReused(t, NamedError("err", errors.New("fail")))
+}
+
+func TestErrorCauses(t *testing.T) {
+	root := errors.New("root")
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{
+			causedError{"outer", root},
+			`"error":"outer: root","errorCauses":[{"error":"root"}]`,
+		},
+		{
+			unwrappableError{"outer", causedError{"middle", root}},
+			`"error":"outer: middle: root","errorCauses":[{"error":"middle: root"},{"error":"root"}]`,
+		},
+		{
+			multiError{root, causedError{"outer", root}},
+			`"error":"root outer: root ","errorCauses":[{"error":"root"},{"error":"outer: root","errorCauses":[{"error":"root"}]}]`,
+		},
+		{
+			causedError{"outer", multiError{root, root}},
+			`"error":"outer: root root ","errorCauses":[{"error":"root root ","errorCauses":[{"error":"root"},{"error":"root"}]}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		assertFieldJSON(t, tt.expected, Error(tt.err))
+	}
+}
+
+func TestErrorCycles(t *testing.T) {
+	chain := make([]string, _maxErrorDepth)
+	for i := range chain {
+		chain[i] = `{"error":"loop"}`
+	}
+	assertFieldJSON(t,
+		`"error":"loop","errorCauses":[`+strings.Join(chain, ",")+`]`,
+		Error(loopError{}),
+	)
+
+	nested := `"error":"group"`
+	for i := 0; i < _maxErrorDepth; i++ {
+		nested = `"error":"group","errorCauses":[{` + nested + `}]`
+	}
+	assertFieldJSON(t, nested, Error(loopGroup{}))
+}
+
+func TestErrorVerbose(t *testing.T) {
+	assertFieldJSON(t, `"error":"fail","errorVerbose":"fail\nstack"`, Error(stackedError{"fail"}))
+	// Chained errors don't repeat the verbose output.
+	assertFieldJSON(t,
+		`"error":"outer: fail","errorCauses":[{"error":"fail"}]`,
+		Error(causedError{"outer", stackedError{"fail"}}),
+	)
+	// Members of a multi-error are independent, so they include it.
+	assertFieldJSON(t,
+		`"error":"fail ","errorCauses":[{"error":"fail","errorVerbose":"fail\nstack"}]`,
+		Error(multiError{stackedError{"fail"}}),
+	)
+}
+
+func TestErrorFields(t *testing.T) {
+	assertFieldJSON(t, `"error":"bad user","errorFields":{"name":"phil"}`, Error(userError{"phil"}))
+	assertFieldJSON(t, `"error":"bad user","errorFields":{},"errorFieldsError":"fail"`, Error(userError{"fail"}))
+	assertFieldJSON(t,
+		`"error":"outer: bad user","errorCauses":[{"error":"bad user","errorFields":{"name":"phil"}}]`,
+		Error(causedError{"outer", userError{"phil"}}),
+	)
+}
+
+func TestErrorFieldsLogfmt(t *testing.T) {
+	assertLogfmtOutput(t, "multi-error",
+		`error="foo bad user " errorCauses.0.error=foo errorCauses.1.error="bad user" errorCauses.1.errorFields.name=phil`,
+		func(e Encoder) { Error(multiError{errors.New("foo"), userError{"phil"}}).AddTo(e) },
+	)
+}
@@ -27,6 +27,13 @@ import (
 	"time"
 )
 
+// _minTimeInt64 and _maxTimeInt64 bound the times that Time can store as
+// nanoseconds since epoch.
+var (
+	_minTimeInt64 = time.Unix(0, math.MinInt64)
+	_maxTimeInt64 = time.Unix(0, math.MaxInt64)
+)
+
 type fieldType int
 
 const (
@@ -40,9 +47,15 @@ const (
 	uintptrType
 	stringType
 	marshalerType
+	arrayType
 	objectType
 	stringerType
 	errorType
+	timeType
+	durationType
+	levelType
+	lazyType
+	deferredType
 	skipType
 )
 
@@ -123,20 +136,39 @@ func Stringer(key string, val fmt.Stringer) Field {
 	return Field{key: key, fieldType: stringerType, obj: val}
 }
 
-// Time constructs a Field with the given key and value. It represents a
-// time.Time as a floating-point number of seconds since epoch. Conversion to a
-// float64 happens eagerly.
+// Time constructs a Field with the given key and value. The encoder's
+// TimeEncoder controls how the time is rendered; by default, the JSON encoder
+// represents it as a floating-point number of seconds since epoch.
+//
+// Times that can't be represented as nanoseconds since epoch (those before
+// 1678 or after 2262, including the zero time) are stored as-is, at the cost
+// of an allocation.
 func Time(key string, val time.Time) Field {
-	return Float64(key, timeToSeconds(val))
+	if val.Before(_minTimeInt64) || val.After(_maxTimeInt64) {
+		return Field{key: key, fieldType: timeType, obj: val}
+	}
+	return Field{key: key, fieldType: timeType, ival: val.UnixNano(), obj: val.Location()}
 }
 
-// Error constructs a Field that lazily stores err.Error() under the key
-// "error". If passed a nil error, the field is a no-op.
+// Error is shorthand for the common idiom NamedError("error", err).
 func Error(err error) Field {
+	return NamedError("error", err)
+}
+
+// NamedError constructs a Field that lazily stores err.Error() under the
+// supplied key. If passed a nil error, the field is a no-op.
+//
+// Errors that carry more information add it under related keys: wrapped
+// errors (via Cause or Unwrap methods) and the members of multi-errors are
+// added as an array under keyCauses, errors that implement fmt.Formatter add
+// their %+v representation (often including a stacktrace) under keyVerbose,
+// and errors that implement LogMarshaler add their own fields under
+// keyFields.
+func NamedError(key string, err error) Field {
 	if err == nil {
 		return Skip()
 	}
-	return Field{key: "error", fieldType: errorType, obj: err}
+	return Field{key: key, fieldType: errorType, obj: err}
 }
 
 // Stack constructs a Field that stores a stacktrace of the current goroutine
@@ -157,10 +189,11 @@ func Stack() Field {
 	return field
 }
 
-// Duration constructs a Field with the given key and value. It represents
-// durations as an integer number of nanoseconds.
+// Duration constructs a Field with the given key and value. The encoder's
+// DurationEncoder controls how the duration is rendered; by default, the JSON
+// encoder represents it as an integer number of nanoseconds.
 func Duration(key string, val time.Duration) Field {
-	return Int64(key, int64(val))
+	return Field{key: key, fieldType: durationType, ival: int64(val)}
 }
 
 // Marshaler constructs a field with the given key and zap.LogMarshaler. It
@@ -171,6 +204,14 @@ func Marshaler(key string, val LogMarshaler) Field {
 	return Field{key: key, fieldType: marshalerType, obj: val}
 }
 
+// Array constructs a field with the given key and zap.ArrayMarshaler. It
+// provides a type-safe and efficient way to add lists of user-defined types
+// to the logging context. The ArrayMarshaler's MarshalLogArray method is
+// called lazily.
+func Array(key string, val ArrayMarshaler) Field {
+	return Field{key: key, fieldType: arrayType, obj: val}
+}
+
 // Object constructs a field with the given key and an arbitrary object. It uses
 // an encoding-appropriate, reflection-based function to lazily serialize nearly
 // any object into the logging context, but it's relatively slow and
@@ -182,12 +223,110 @@ func Object(key string, val interface{}) Field {
 	return Field{key: key, fieldType: objectType, obj: val}
 }
 
+// Any takes a key and an arbitrary value and chooses the best way to represent
+// them as a field, falling back to a reflection-based approach only if
+// necessary. If the value is itself a Field, Any returns a copy of it under
+// the supplied key.
+func Any(key string, value interface{}) Field {
+	switch val := value.(type) {
+	case Field:
+		val.key = key
+		return val
+	case LogMarshaler:
+		return Marshaler(key, val)
+	case ArrayMarshaler:
+		return Array(key, val)
+	case bool:
+		return Bool(key, val)
+	case []bool:
+		return Bools(key, val)
+	case float64:
+		return Float64(key, val)
+	case []float64:
+		return Float64s(key, val)
+	case float32:
+		return Float64(key, float64(val))
+	case int:
+		return Int(key, val)
+	case []int:
+		return Ints(key, val)
+	case int64:
+		return Int64(key, val)
+	case []int64:
+		return Int64s(key, val)
+	case int32:
+		return Int64(key, int64(val))
+	case int16:
+		return Int64(key, int64(val))
+	case int8:
+		return Int64(key, int64(val))
+	case uint:
+		return Uint(key, val)
+	case uint64:
+		return Uint64(key, val)
+	case uint32:
+		return Uint64(key, uint64(val))
+	case uint16:
+		return Uint64(key, uint64(val))
+	case uint8:
+		return Uint64(key, uint64(val))
+	case uintptr:
+		return Uintptr(key, val)
+	case string:
+		return String(key, val)
+	case []string:
+		return Strings(key, val)
+	case time.Time:
+		return Time(key, val)
+	case []time.Time:
+		return Times(key, val)
+	case time.Duration:
+		return Duration(key, val)
+	case []time.Duration:
+		return Durations(key, val)
+	case error:
+		return NamedError(key, val)
+	case fmt.Stringer:
+		return Stringer(key, val)
+	default:
+		return Object(key, val)
+	}
+}
+
 // Nest takes a key and a variadic number of Fields and creates a nested
 // namespace.
 func Nest(key string, fields ...Field) Field {
 	return Field{key: key, fieldType: marshalerType, obj: multiFields(fields)}
 }
 
+// Lazy constructs a field that calls the supplied function to build the real
+// field only when an encoder actually receives it. Since disabled log levels
+// never reach the encoder, Lazy makes expensive fields (serializing a request,
+// computing a diff) free unless the entry is written. The function may be
+// called more than once (e.g., once per Tee'd logger), so it must be safe for
+// concurrent use.
+func Lazy(f func() Field) Field {
+	return Field{fieldType: lazyType, obj: f}
+}
+
+// Deferred constructs a field that calls the supplied function to add any
+// number of fields directly to the enclosing context, and only when an
+// encoder actually receives it. Unlike Marshaler, the added fields aren't
+// nested under a key. If the function returns an error, its message is added
+// under the key "deferredError". Like Lazy, the function may be called more
+// than once.
+func Deferred(f func(KeyValue) error) Field {
+	return Field{key: "deferred", fieldType: deferredType, obj: f}
+}
+
+// IsLazy reports whether the field was constructed with Lazy or Deferred.
+// Adding such a field to a KeyValue runs the supplied function, so code that
+// inspects fields before deciding whether to log (e.g., a sampling strategy)
+// should skip them.
+func (f Field) IsLazy() bool {
+	return f.fieldType == lazyType || f.fieldType == deferredType
+}
+
 // AddTo exports a field through the KeyValue interface. It's primarily useful
 // to library authors, and shouldn't be necessary in most applications.
 func (f Field) AddTo(kv KeyValue) {
@@ -214,10 +353,26 @@ func (f Field) AddTo(kv KeyValue) {
 		kv.AddString(f.key, f.obj.(fmt.Stringer).String())
 	case marshalerType:
 		err = kv.AddMarshaler(f.key, f.obj.(LogMarshaler))
+	case arrayType:
+		err = kv.AddArray(f.key, f.obj.(ArrayMarshaler))
 	case objectType:
 		err = kv.AddObject(f.key, f.obj)
 	case errorType:
-		kv.AddString(f.key, f.obj.(error).Error())
+		encodeError(kv, f.key, f.obj.(error), false, 0)
+	case timeType:
+		if t, ok := f.obj.(time.Time); ok {
+			kv.AddTime(f.key, t)
+		} else {
+			kv.AddTime(f.key, time.Unix(0, f.ival).In(f.obj.(*time.Location)))
+		}
+	case durationType:
+		kv.AddDuration(f.key, time.Duration(f.ival))
+	case levelType:
+		addLevel(kv, f.key, Level(f.ival))
+	case lazyType:
+		f.obj.(func() Field)().AddTo(kv)
+	case deferredType:
+		err = f.obj.(func(KeyValue) error)(kv)
 	case skipType:
 		break
 	default:
@@ -23,6 +23,7 @@ package zap
 import (
 	"encoding/json"
 	"errors"
+	"math"
 	"net"
 	"strings"
 	"sync"
@@ -154,6 +155,24 @@ func TestTimeField(t *testing.T) {
 	assertCanBeReused(t, Time("foo", time.Unix(0, 0)))
 }
 
+func TestTimeFieldOutOfRange(t *testing.T) {
+	tests := []struct {
+		t        time.Time
+		expected string
+	}{
+		{time.Time{}, `"foo":"0001-01-01T00:00:00Z"`},
+		{time.Date(2400, 1, 1, 0, 0, 0, 0, time.UTC), `"foo":"2400-01-01T00:00:00Z"`},
+		{time.Date(1600, 1, 1, 0, 0, 0, 0, time.UTC), `"foo":"1600-01-01T00:00:00Z"`},
+		{time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), `"foo":"2000-01-01T00:00:00Z"`},
+	}
+	for _, tt := range tests {
+		enc := newJSONEncoder(RFC3339TimeEncoder())
+		Time("foo", tt.t).AddTo(enc)
+		assert.Equal(t, tt.expected, string(enc.bytes), "Unexpected output encoding time %v.", tt.t)
+		enc.Free()
+	}
+}
+
 func TestErrField(t *testing.T) {
 	assertFieldJSON(t, `"error":"fail"`, Error(errors.New("fail")))
 	assertFieldJSON(t, ``, Error(nil))
@@ -188,6 +207,65 @@ func TestStringsField(t *testing.T) {
 	assertCanBeReused(t, Object("foo", []string{"bar 1", "bar 2", "bar 3"}))
 }
 
+func TestArrayFields(t *testing.T) {
+	tests := []struct {
+		expected string
+		field    Field
+	}{
+		{`"foo":[]`, Ints("foo", nil)},
+		{`"foo":[1,2,3]`, Ints("foo", []int{1, 2, 3})},
+		{`"foo":[-1,1]`, Int64s("foo", []int64{-1, 1})},
+		{`"foo":["bar 1","bar 2"]`, Strings("foo", []string{"bar 1", "bar 2"})},
+		{`"foo":[true,false]`, Bools("foo", []bool{true, false})},
+		{`"foo":[1.5,"NaN"]`, Float64s("foo", []float64{1.5, math.NaN()})},
+		{`"foo":[1,1000]`, Durations("foo", []time.Duration{time.Nanosecond, time.Microsecond})},
+		{`"foo":[0,1.5]`, Times("foo", []time.Time{time.Unix(0, 0), time.Unix(1, int64(500*time.Millisecond))})},
+		{`"foo":[{"name":"phil"}]`, Array("foo", ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+			return arr.AppendMarshaler(fakeUser{"phil"})
+		}))},
+		{`"foo":[[1],[2,3]]`, Array("foo", ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+			arr.AppendArray(ints{1})
+			return arr.AppendArray(ints{2, 3})
+		}))},
+	}
+
+	for _, tt := range tests {
+		assertFieldJSON(t, tt.expected, tt.field)
+		assertCanBeReused(t, tt.field)
+	}
+}
+
+func TestArrayFieldError(t *testing.T) {
+	assertFieldJSON(t, `"foo":[{}],"fooError":"fail"`, Array("foo", ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+		return arr.AppendMarshaler(fakeUser{"fail"})
+	})))
+}
+
+func TestAnyField(t *testing.T) {
+	tests := []struct {
+		expected string
+		value    interface{}
+	}{
+		{`"foo":true`, true},
+		{`"foo":1.5`, float32(1.5)},
+		{`"foo":-3`, int32(-3)},
+		{`"foo":3`, uint8(3)},
+		{`"foo":"bar"`, "bar"},
+		{`"foo":["bar"]`, []string{"bar"}},
+		{`"foo":1000`, time.Microsecond},
+		{`"foo":"fail"`, errors.New("fail")},
+		{`"foo":"1.2.3.4"`, net.ParseIP("1.2.3.4")},
+		{`"foo":{"name":"phil"}`, fakeUser{"phil"}},
+		{`"foo":[1,2]`, ints{1, 2}},
+		{`"foo":{"bar":1}`, map[string]int{"bar": 1}},
+		{`"foo":1`, Int("baz", 1)},
+	}
+
+	for _, tt := range tests {
+		assertFieldJSON(t, tt.expected, Any("foo", tt.value))
+	}
+}
+
 func TestObjectField(t *testing.T) {
 	assertFieldJSON(t, `"foo":[5,6]`, Object("foo", []int{5, 6}))
 	assertCanBeReused(t, Object("foo", []int{5, 6}))
@@ -219,6 +297,34 @@ func TestLogMarshalerFunc(t *testing.T) {
 		Marshaler("foo", LogMarshalerFunc(fakeUser{"phil"}.MarshalLog)))
 }
 
+func TestLazyField(t *testing.T) {
+	calls := 0
+	lazy := Lazy(func() Field {
+		calls++
+		return Nest("foo", String("name", "phil"))
+	})
+	assert.Equal(t, 0, calls, "Expected constructing a lazy field not to evaluate it.")
+	assertFieldJSON(t, `"foo":{"name":"phil"}`, lazy)
+	assert.Equal(t, 1, calls, "Expected adding a lazy field to evaluate it once.")
+	assertCanBeReused(t, Lazy(func() Field { return Int("foo", 42) }))
+	assert.True(t, lazy.IsLazy(), "Expected Lazy fields to report that they're lazy.")
+	assert.False(t, Int("foo", 42).IsLazy(), "Expected eager fields not to report that they're lazy.")
+}
+
+func TestDeferredField(t *testing.T) {
+	assertFieldJSON(t, `"name":"phil","age":42`, Deferred(func(kv KeyValue) error {
+		kv.AddString("name", "phil")
+		kv.AddInt("age", 42)
+		return nil
+	}))
+	assertFieldJSON(t, `"name":"phil","deferredError":"fail"`, Deferred(func(kv KeyValue) error {
+		kv.AddString("name", "phil")
+		return errors.New("fail")
+	}))
+	assertCanBeReused(t, Deferred(fakeUser{"phil"}.MarshalLog))
+	assert.True(t, Deferred(fakeUser{"phil"}.MarshalLog).IsLazy(), "Expected Deferred fields to report that they're lazy.")
+}
+
 func TestStackField(t *testing.T) {
 	enc := newJSONEncoder()
 	defer enc.Free()
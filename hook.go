@@ -22,19 +22,25 @@ package zap
 
 import (
 	"errors"
-	"path/filepath"
+	"path"
 	"runtime"
 	"strconv"
+	"strings"
 )
 
 var (
 	errHookNilEntry = errors.New("can't call a hook on a nil *Entry")
 	errCaller       = errors.New("failed to get caller")
-	// Skip Caller, Logger.log, and the leveled Logger method when using
-	// runtime.Caller.
-	_callerSkip = 4
+	// Frames from zap's own (non-test) source files, including its wrapper
+	// packages, are never reported as the caller.
+	_zapDir = func() string {
+		_, file, _, _ := runtime.Caller(0)
+		return path.Dir(file) + "/"
+	}()
 )
 
+const _maxCallerDepth = 64
+
 // A Hook is executed each time the logger writes an Entry. It can modify the
 // entry (including adding context to Entry.Fields()), but must not retain
 // references to the entry or any of its contents. Returned errors are written to
@@ -48,35 +54,119 @@ func (h Hook) apply(m *Meta) {
 	m.Hooks = append(m.Hooks, h)
 }
 
+// A CallerOption configures the field added by AddCaller.
+type CallerOption interface {
+	apply(*callerConfig)
+}
+
+type callerOptionFunc func(*callerConfig)
+
+func (f callerOptionFunc) apply(c *callerConfig) {
+	f(c)
+}
+
+type callerConfig struct {
+	key      string
+	funcKey  string
+	fullPath bool
+}
+
+// CallerKey sets the key used for the caller's file and line number. The
+// default is "caller".
+func CallerKey(key string) CallerOption {
+	return callerOptionFunc(func(c *callerConfig) {
+		c.key = key
+	})
+}
+
+// CallerFunctionKey adds the caller's fully-qualified function name to each
+// entry under the supplied key.
+func CallerFunctionKey(key string) CallerOption {
+	return callerOptionFunc(func(c *callerConfig) {
+		c.funcKey = key
+	})
+}
+
+// FullCallerPath reports the full path of the caller's file, rather than just
+// its base name.
+func FullCallerPath() CallerOption {
+	return callerOptionFunc(func(c *callerConfig) {
+		c.fullPath = true
+	})
+}
+
 // AddCaller configures the Logger to annotate each message with the filename
-// and line number of zap's caller.
-func AddCaller() Option {
+// and line number of zap's caller. The caller is added as a field (by default,
+// "caller"), so the message itself is left untouched.
+//
+// The caller is the first stack frame outside zap and its wrapper packages, so
+// it's reported correctly when logging through CheckedMessage.Write, Tee,
+// Sugar, zwrap.Standardize, or zbark.Barkify. Other wrappers should use the
+// CallerSkip option to skip their own frames.
+func AddCaller(options ...CallerOption) Option {
+	cfg := callerConfig{key: "caller"}
+	for _, opt := range options {
+		opt.apply(&cfg)
+	}
 	return Hook(func(e *Entry) error {
 		if e == nil {
 			return errHookNilEntry
 		}
-		_, filename, line, ok := runtime.Caller(_callerSkip)
+		frame, ok := findCaller(e.callerSkip)
 		if !ok {
 			return errCaller
 		}
 
+		filename := frame.File
+		if !cfg.fullPath {
+			filename = path.Base(filename)
+		}
+
 		// Re-use a buffer from the pool.
 		enc := jsonPool.Get().(*jsonEncoder)
 		enc.truncate()
 		buf := enc.bytes
-		buf = append(buf, filepath.Base(filename)...)
+		buf = append(buf, filename...)
 		buf = append(buf, ':')
-		buf = strconv.AppendInt(buf, int64(line), 10)
-		buf = append(buf, ':', ' ')
-		buf = append(buf, e.Message...)
+		buf = strconv.AppendInt(buf, int64(frame.Line), 10)
 
-		newMsg := string(buf)
+		caller := string(buf)
 		enc.Free()
-		e.Message = newMsg
+
+		kv := e.Fields()
+		kv.AddString(cfg.key, caller)
+		if cfg.funcKey != "" {
+			kv.AddString(cfg.funcKey, frame.Function)
+		}
 		return nil
 	})
 }
 
+// findCaller returns the first stack frame outside zap, skipping an
+// additional number of frames beyond it.
+func findCaller(skip int) (runtime.Frame, bool) {
+	var pcs [_maxCallerDepth]uintptr
+	// Skip runtime.Callers and findCaller itself.
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isZapFrame(frame.File) {
+			if skip == 0 {
+				return frame, true
+			}
+			skip--
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+func isZapFrame(file string) bool {
+	return strings.HasPrefix(file, _zapDir) && !strings.HasSuffix(file, "_test.go")
+}
+
 // AddStacks configures the Logger to record a stack trace for all messages at
 // or above a given level. Keep in mind that this is (relatively speaking) quite
 // expensive.
@@ -22,6 +22,8 @@ package zap
 
 import (
 	"regexp"
+	"runtime"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -33,22 +35,84 @@ func TestHookAddCaller(t *testing.T) {
 	logger := New(NewJSONEncoder(), DebugLevel, Output(buf), AddCaller())
 	logger.Info("Callers.")
 
-	re := regexp.MustCompile(`"msg":"hook_test.go:[\d]+: Callers\."`)
-	assert.Regexp(t, re, buf.Stripped(), "Expected to find package name and file name in output.")
+	re := regexp.MustCompile(`"msg":"Callers\.","caller":"hook_test.go:[\d]+"`)
+	assert.Regexp(t, re, buf.Stripped(), "Expected to find file name and line number in a separate field.")
+}
+
+func TestHookAddCallerOptions(t *testing.T) {
+	buf := &testBuffer{}
+	logger := New(NewJSONEncoder(), DebugLevel, Output(buf), AddCaller(
+		CallerKey("src"),
+		CallerFunctionKey("func"),
+		FullCallerPath(),
+	))
+	logger.Info("Callers.")
+
+	re := regexp.MustCompile(`"src":"/.+/hook_test.go:[\d]+","func":"github.com/uber-go/zap.TestHookAddCallerOptions"`)
+	assert.Regexp(t, re, buf.Stripped(), "Expected to find full path and function name in output.")
+}
+
+// thisLine returns the line number of its caller.
+func thisLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+func TestHookAddCallerPaths(t *testing.T) {
+	buf := &testBuffer{}
+	logger := New(NewJSONEncoder(NoTime()), DebugLevel, Output(buf), AddCaller())
+	tee := Tee(logger, New(NewJSONEncoder(), Output(&testBuffer{})))
+
+	tests := []struct {
+		desc string
+		f    func()
+		line int
+	}{
+		{"leveled method", func() { logger.Info("") }, thisLine()},
+		{"Log", func() { logger.Log(InfoLevel, "") }, thisLine()},
+		{"CheckedMessage", func() { logger.Check(InfoLevel, "").Write() }, thisLine()},
+		{"Tee", func() { tee.Info("") }, thisLine()},
+		{"Tee CheckedMessage", func() { tee.Check(InfoLevel, "").Write() }, thisLine()},
+		{"Sugar", func() { Sugar(logger).Info() }, thisLine()},
+	}
+
+	for _, tt := range tests {
+		buf.Reset()
+		tt.f()
+		assert.Contains(
+			t,
+			buf.String(),
+			`"caller":"hook_test.go:`+strconv.Itoa(tt.line)+`"`,
+			"Expected to report the call site when logging via %s.", tt.desc,
+		)
+	}
+}
+
+type callerWrapper struct{ Logger }
+
+func (w callerWrapper) Info(msg string) {
+	w.Logger.Info(msg)
+}
+
+func TestHookCallerSkip(t *testing.T) {
+	buf := &testBuffer{}
+	log := New(NewJSONEncoder(), DebugLevel, Output(buf), AddCaller(), CallerSkip(1))
+	assert.Equal(t, 1, log.Named("foo").(*logger).CallerSkip, "Expected named loggers to keep CallerSkip.")
+
+	callerWrapper{log}.Info("Callers.")
+	line := thisLine() - 1
+	assert.Contains(t, buf.String(), `"caller":"hook_test.go:`+strconv.Itoa(line)+`"`, "Expected CallerSkip to skip the wrapper.")
 }
 
 func TestHookAddCallerFail(t *testing.T) {
 	buf := &testBuffer{}
 	errBuf := &testBuffer{}
 
-	originalSkip := _callerSkip
-	_callerSkip = 1e3
-	defer func() { _callerSkip = originalSkip }()
-
-	logger := New(NewJSONEncoder(), DebugLevel, Output(buf), ErrorOutput(errBuf), AddCaller())
+	logger := New(NewJSONEncoder(), DebugLevel, Output(buf), ErrorOutput(errBuf), AddCaller(), CallerSkip(1e3))
 	logger.Info("Failure.")
 	assert.Regexp(t, `hook error: failed to get caller`, errBuf.String(), "Didn't find expected failure message.")
 	assert.Contains(t, buf.String(), `"msg":"Failure."`, "Expected original message to survive failures in runtime.Caller.")
+	assert.NotContains(t, buf.String(), `"caller"`, "Unexpected caller field after failure.")
 }
 
 func TestHookAddStacks(t *testing.T) {
@@ -69,6 +133,21 @@ func TestHookAddStacks(t *testing.T) {
 	assert.NotContains(t, buf.String(), "Unexpected stacktrace at Debug level.")
 }
 
+func TestHookSeesLoggerName(t *testing.T) {
+	buf := &testBuffer{}
+	var names []string
+	hook := Hook(func(e *Entry) error {
+		names = append(names, e.LoggerName)
+		e.LoggerName = "renamed"
+		return nil
+	})
+	logger := New(NewJSONEncoder(NoTime()), Output(buf), hook)
+
+	logger.Named("foo").Named("bar").Info("Named.")
+	assert.Equal(t, []string{"foo.bar"}, names, "Expected hook to see the logger's name.")
+	assert.Equal(t, `{"level":"info","logger":"renamed","msg":"Named."}`, buf.Stripped(), "Expected hooks to be able to rename entries.")
+}
+
 func TestHooksNilEntry(t *testing.T) {
 	tests := []struct {
 		name string
@@ -74,3 +74,73 @@ func (lvl AtomicLevel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 }
+
+// ServeHTTP supports inspecting and changing the registered logging levels
+// with HTTP requests. It extends the protocol used by AtomicLevel.ServeHTTP
+// with a logger name.
+//
+// GET requests with a name query parameter (e.g., "?name=rpc.client") return
+// a JSON description of the level used by loggers with that name:
+//   {"name":"rpc.client","level":"info"}
+// GET requests without a name parameter list all the registered levels,
+// including the root level under the empty name:
+//   {"levels":[{"name":"","level":"info"},{"name":"rpc","level":"debug"}]}
+// PUT requests change the level for a name (or the root level, if the name is
+// omitted) and expect a payload like:
+//   {"name":"rpc","level":"debug"}
+func (r *LevelRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	type errorResponse struct {
+		Error string `json:"error"`
+	}
+	type payload struct {
+		Name  string `json:"name"`
+		Level *Level `json:"level"`
+	}
+	type listPayload struct {
+		Levels []payload `json:"levels"`
+	}
+
+	enc := json.NewEncoder(w)
+
+	switch req.Method {
+
+	case "GET":
+		if names, ok := req.URL.Query()["name"]; ok && len(names) > 0 {
+			current := r.Level(names[0])
+			enc.Encode(payload{Name: names[0], Level: &current})
+			return
+		}
+		var list listPayload
+		for _, name := range r.names() {
+			current := r.Level(name)
+			list.Levels = append(list.Levels, payload{Name: name, Level: &current})
+		}
+		enc.Encode(list)
+
+	case "PUT":
+		var p payload
+
+		if errmess := func() string {
+			if err := json.NewDecoder(req.Body).Decode(&p); err != nil {
+				return fmt.Sprintf("Request body must be well-formed JSON: %v", err)
+			}
+			if p.Level == nil {
+				return "Must specify a logging level."
+			}
+			return ""
+		}(); errmess != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			enc.Encode(errorResponse{Error: errmess})
+			return
+		}
+
+		r.SetLevel(p.Name, *p.Level)
+		enc.Encode(p)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		enc.Encode(errorResponse{
+			Error: "Only GET and PUT are supported.",
+		})
+	}
+}
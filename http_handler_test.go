@@ -70,10 +70,14 @@ func assertJSONError(t testing.TB, body string) {
 }
 
 func makeRequest(t testing.TB, method string, handler http.Handler, reader io.Reader) (int, string) {
+	return makeRequestPath(t, method, handler, "", reader)
+}
+
+func makeRequestPath(t testing.TB, method string, handler http.Handler, path string, reader io.Reader) (int, string) {
 	ts := httptest.NewServer(handler)
 	defer ts.Close()
 
-	req, err := http.NewRequest(method, ts.URL, reader)
+	req, err := http.NewRequest(method, ts.URL+path, reader)
 	require.NoError(t, err, "Error constructing %s request.", method)
 
 	res, err := http.DefaultClient.Do(req)
@@ -129,3 +133,65 @@ func TestHTTPHandlerMethodNotAllowed(t *testing.T) {
 	assertCodeMethodNotAllowed(t, code)
 	assertJSONError(t, body)
 }
+
+func newRegistryHandler() *LevelRegistry {
+	r := NewLevelRegistry(DynamicLevel())
+	r.SetLevel("rpc", DebugLevel)
+	return r
+}
+
+func TestRegistryHTTPHandlerGetLevel(t *testing.T) {
+	r := newRegistryHandler()
+
+	code, body := makeRequestPath(t, "GET", r, "/?name=rpc.client", nil)
+	assertCodeOK(t, code)
+	assert.Equal(t, `{"name":"rpc.client","level":"debug"}`+"\n", body, "Unexpected response body.")
+
+	code, body = makeRequestPath(t, "GET", r, "/?name=", nil)
+	assertCodeOK(t, code)
+	assert.Equal(t, `{"name":"","level":"info"}`+"\n", body, "Unexpected response body.")
+}
+
+func TestRegistryHTTPHandlerListLevels(t *testing.T) {
+	r := newRegistryHandler()
+	code, body := makeRequest(t, "GET", r, nil)
+	assertCodeOK(t, code)
+	assert.Equal(
+		t,
+		`{"levels":[{"name":"","level":"info"},{"name":"rpc","level":"debug"}]}`+"\n",
+		body,
+		"Unexpected response body.",
+	)
+}
+
+func TestRegistryHTTPHandlerPutLevel(t *testing.T) {
+	r := newRegistryHandler()
+
+	code, body := makeRequest(t, "PUT", r, strings.NewReader(`{"name":"rpc.client","level":"warn"}`))
+	assertCodeOK(t, code)
+	assert.Equal(t, `{"name":"rpc.client","level":"warn"}`+"\n", body, "Unexpected response body.")
+	assert.Equal(t, WarnLevel, r.Level("rpc.client.retry"), "Expected PUT to change the level.")
+
+	code, _ = makeRequest(t, "PUT", r, strings.NewReader(`{"level":"error"}`))
+	assertCodeOK(t, code)
+	assert.Equal(t, ErrorLevel, r.Level("http"), "Expected PUT without a name to change the root level.")
+}
+
+func TestRegistryHTTPHandlerErrors(t *testing.T) {
+	tests := []struct {
+		method string
+		body   string
+		code   int
+	}{
+		{"PUT", `{"name":"rpc","level":"unrecognized-level"}`, http.StatusBadRequest},
+		{"PUT", `{`, http.StatusBadRequest},
+		{"PUT", `{"name":"rpc"}`, http.StatusBadRequest},
+		{"POST", `{`, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		code, body := makeRequest(t, tt.method, newRegistryHandler(), strings.NewReader(tt.body))
+		assert.Equal(t, tt.code, code, "Unexpected response status code.")
+		assertJSONError(t, body)
+	}
+}
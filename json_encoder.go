@@ -45,8 +45,9 @@ var (
 
 	// Default formatters for JSON encoders.
 	defaultMessageF = MessageKey("msg")
-	defaultTimeF    = EpochFormatter("ts")
-	defaultLevelF   = LevelString("level")
+	defaultTimeF    = TimeKey("ts")
+	defaultLevelF   = LevelKey("level")
+	defaultNameF    = NameKey("logger")
 
 	jsonPool = sync.Pool{New: func() interface{} {
 		return &jsonEncoder{
@@ -62,13 +63,22 @@ type jsonEncoder struct {
 	messageF MessageFormatter
 	timeF    TimeFormatter
 	levelF   LevelFormatter
+	nameF    NameFormatter
+
+	timeEnc     TimeEncoder
+	durationEnc DurationEncoder
+	levelEnc    LevelEncoder
 }
 
 // NewJSONEncoder creates a fast, low-allocation JSON encoder. By default, JSON
 // encoders put the log message under the "msg" key, the timestamp (as
-// floating-point seconds since epoch) under the "ts" key, and the log level
-// under the "level" key. The encoder appropriately escapes all field keys and
-// values.
+// floating-point seconds since epoch) under the "ts" key, the log level
+// under the "level" key, and the logger's name (if any) under the "logger"
+// key. The encoder appropriately escapes all field keys and values.
+//
+// Times, durations, and levels are rendered with the encoder's TimeEncoder,
+// DurationEncoder, and LevelEncoder, which default to EpochTimeEncoder,
+// NanosDurationEncoder, and LowercaseLevelEncoder.
 //
 // Note that the encoder doesn't deduplicate keys, so it's possible to produce a
 // message like
@@ -84,6 +94,10 @@ func NewJSONEncoder(options ...JSONOption) Encoder {
 	enc.messageF = defaultMessageF
 	enc.timeF = defaultTimeF
 	enc.levelF = defaultLevelF
+	enc.nameF = defaultNameF
+	enc.timeEnc = EpochTimeEncoder()
+	enc.durationEnc = NanosDurationEncoder()
+	enc.levelEnc = LowercaseLevelEncoder()
 	for _, opt := range options {
 		opt.apply(enc)
 	}
@@ -147,16 +161,21 @@ func (enc *jsonEncoder) AddUintptr(key string, val uintptr) {
 // large exponents).
 func (enc *jsonEncoder) AddFloat64(key string, val float64) {
 	enc.addKey(key)
-	switch {
-	case math.IsNaN(val):
-		enc.bytes = append(enc.bytes, `"NaN"`...)
-	case math.IsInf(val, 1):
-		enc.bytes = append(enc.bytes, `"+Inf"`...)
-	case math.IsInf(val, -1):
-		enc.bytes = append(enc.bytes, `"-Inf"`...)
-	default:
-		enc.bytes = strconv.AppendFloat(enc.bytes, val, 'f', -1, 64)
-	}
+	enc.appendFloat64(val)
+}
+
+// AddTime adds a string key and time.Time value to the encoder's fields,
+// using the encoder's TimeEncoder. The key is JSON-escaped.
+func (enc *jsonEncoder) AddTime(key string, val time.Time) {
+	enc.addKey(key)
+	enc.timeEnc(val, enc)
+}
+
+// AddDuration adds a string key and time.Duration value to the encoder's
+// fields, using the encoder's DurationEncoder. The key is JSON-escaped.
+func (enc *jsonEncoder) AddDuration(key string, val time.Duration) {
+	enc.addKey(key)
+	enc.durationEnc(val, enc)
 }
 
 // AddMarshaler adds a LogMarshaler to the encoder's fields.
@@ -168,6 +187,12 @@ func (enc *jsonEncoder) AddMarshaler(key string, obj LogMarshaler) error {
 	return err
 }
 
+// AddArray adds an ArrayMarshaler to the encoder's fields.
+func (enc *jsonEncoder) AddArray(key string, arr ArrayMarshaler) error {
+	enc.addKey(key)
+	return enc.appendArray(arr)
+}
+
 // AddObject uses reflection to add an arbitrary object to the logging context.
 func (enc *jsonEncoder) AddObject(key string, obj interface{}) error {
 	marshaled, err := json.Marshal(obj)
@@ -179,6 +204,78 @@ func (enc *jsonEncoder) AddObject(key string, obj interface{}) error {
 	return nil
 }
 
+// AppendString adds a JSON-escaped string to the current array.
+func (enc *jsonEncoder) AppendString(val string) {
+	enc.addElementSeparator()
+	enc.bytes = append(enc.bytes, '"')
+	enc.safeAddString(val)
+	enc.bytes = append(enc.bytes, '"')
+}
+
+// AppendBool adds a boolean to the current array.
+func (enc *jsonEncoder) AppendBool(val bool) {
+	enc.addElementSeparator()
+	enc.bytes = strconv.AppendBool(enc.bytes, val)
+}
+
+// AppendInt adds an integer to the current array.
+func (enc *jsonEncoder) AppendInt(val int) {
+	enc.AppendInt64(int64(val))
+}
+
+// AppendInt64 adds an int64 to the current array.
+func (enc *jsonEncoder) AppendInt64(val int64) {
+	enc.addElementSeparator()
+	enc.bytes = strconv.AppendInt(enc.bytes, val, 10)
+}
+
+// AppendUint adds an unsigned integer to the current array.
+func (enc *jsonEncoder) AppendUint(val uint) {
+	enc.AppendUint64(uint64(val))
+}
+
+// AppendUint64 adds a uint64 to the current array.
+func (enc *jsonEncoder) AppendUint64(val uint64) {
+	enc.addElementSeparator()
+	enc.bytes = strconv.AppendUint(enc.bytes, val, 10)
+}
+
+// AppendFloat64 adds a float64 to the current array, using the same encoding
+// as AddFloat64.
+func (enc *jsonEncoder) AppendFloat64(val float64) {
+	enc.addElementSeparator()
+	enc.appendFloat64(val)
+}
+
+// AppendTime adds a time.Time to the current array, using the encoder's
+// TimeEncoder.
+func (enc *jsonEncoder) AppendTime(val time.Time) {
+	// The TimeEncoder appends the value, adding the separator itself.
+	enc.timeEnc(val, enc)
+}
+
+// AppendDuration adds a time.Duration to the current array, using the
+// encoder's DurationEncoder.
+func (enc *jsonEncoder) AppendDuration(val time.Duration) {
+	enc.durationEnc(val, enc)
+}
+
+// AppendArray adds a nested array to the current array.
+func (enc *jsonEncoder) AppendArray(arr ArrayMarshaler) error {
+	enc.addElementSeparator()
+	return enc.appendArray(arr)
+}
+
+// AppendMarshaler adds a LogMarshaler to the current array as a nested
+// object.
+func (enc *jsonEncoder) AppendMarshaler(obj LogMarshaler) error {
+	enc.addElementSeparator()
+	enc.bytes = append(enc.bytes, '{')
+	err := obj.MarshalLog(enc)
+	enc.bytes = append(enc.bytes, '}')
+	return err
+}
+
 // Clone copies the current encoder, including any data already encoded.
 func (enc *jsonEncoder) Clone() Encoder {
 	clone := jsonPool.Get().(*jsonEncoder)
@@ -187,6 +284,8 @@ func (enc *jsonEncoder) Clone() Encoder {
 	clone.messageF = enc.messageF
 	clone.timeF = enc.timeF
 	clone.levelF = enc.levelF
+	clone.nameF = enc.nameF
+	clone.setEncoders(enc)
 	return clone
 }
 
@@ -194,17 +293,19 @@ func (enc *jsonEncoder) Clone() Encoder {
 // the encoder's accumulated fields. It doesn't modify or lock the encoder's
 // underlying byte slice. It's safe to call from multiple goroutines, but it's
 // not safe to call WriteEntry while adding fields.
-func (enc *jsonEncoder) WriteEntry(sink io.Writer, msg string, lvl Level, t time.Time) error {
+func (enc *jsonEncoder) WriteEntry(sink io.Writer, ent Entry) error {
 	if sink == nil {
 		return errNilSink
 	}
 
 	final := jsonPool.Get().(*jsonEncoder)
 	final.truncate()
+	final.setEncoders(enc)
 	final.bytes = append(final.bytes, '{')
-	enc.levelF(lvl).AddTo(final)
-	enc.timeF(t).AddTo(final)
-	enc.messageF(msg).AddTo(final)
+	enc.levelF(ent.Level).AddTo(final)
+	enc.timeF(ent.Time).AddTo(final)
+	enc.nameF(ent.LoggerName).AddTo(final)
+	enc.messageF(ent.Message).AddTo(final)
 	if len(enc.bytes) > 0 {
 		if len(final.bytes) > 1 {
 			// All the formatters may have been no-ops.
@@ -230,17 +331,63 @@ func (enc *jsonEncoder) truncate() {
 	enc.bytes = enc.bytes[:0]
 }
 
+// setEncoders copies another encoder's TimeEncoder, DurationEncoder, and
+// LevelEncoder.
+func (enc *jsonEncoder) setEncoders(from *jsonEncoder) {
+	enc.timeEnc = from.timeEnc
+	enc.durationEnc = from.durationEnc
+	enc.levelEnc = from.levelEnc
+}
+
+// addLevel implements levelAdder, so that LevelKey uses the encoder's
+// LevelEncoder.
+func (enc *jsonEncoder) addLevel(key string, lvl Level) {
+	enc.addKey(key)
+	enc.levelEnc(lvl, enc)
+}
+
 func (enc *jsonEncoder) addKey(key string) {
-	last := len(enc.bytes) - 1
-	// At some point, we'll also want to support arrays.
-	if last >= 0 && enc.bytes[last] != '{' {
-		enc.bytes = append(enc.bytes, ',')
-	}
+	enc.addElementSeparator()
 	enc.bytes = append(enc.bytes, '"')
 	enc.safeAddString(key)
 	enc.bytes = append(enc.bytes, '"', ':')
 }
 
+// addElementSeparator adds a comma unless we're at the start of the buffer,
+// an object, or an array, or just after a key.
+func (enc *jsonEncoder) addElementSeparator() {
+	last := len(enc.bytes) - 1
+	if last < 0 {
+		return
+	}
+	switch enc.bytes[last] {
+	case '{', '[', ':':
+		return
+	default:
+		enc.bytes = append(enc.bytes, ',')
+	}
+}
+
+func (enc *jsonEncoder) appendArray(arr ArrayMarshaler) error {
+	enc.bytes = append(enc.bytes, '[')
+	err := arr.MarshalLogArray(enc)
+	enc.bytes = append(enc.bytes, ']')
+	return err
+}
+
+func (enc *jsonEncoder) appendFloat64(val float64) {
+	switch {
+	case math.IsNaN(val):
+		enc.bytes = append(enc.bytes, `"NaN"`...)
+	case math.IsInf(val, 1):
+		enc.bytes = append(enc.bytes, `"+Inf"`...)
+	case math.IsInf(val, -1):
+		enc.bytes = append(enc.bytes, `"-Inf"`...)
+	default:
+		enc.bytes = strconv.AppendFloat(enc.bytes, val, 'f', -1, 64)
+	}
+}
+
 // safeAddString JSON-escapes a string and appends it to the internal buffer.
 // Unlike the standard library's escaping function, it doesn't attempt to
 // protect the user from browser vulnerabilities or JSONP-related problems.
@@ -51,7 +51,7 @@ func BenchmarkZapJSONInts(b *testing.B) {
 		for pb.Next() {
 			enc := NewJSONEncoder()
 			enc.AddObject("ints", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
-			enc.WriteEntry(ioutil.Discard, "fake", DebugLevel, ts)
+			enc.WriteEntry(ioutil.Discard, Entry{Message: "fake", Level: DebugLevel, Time: ts})
 			enc.Free()
 		}
 	})
@@ -63,7 +63,7 @@ func BenchmarkZapJSONStrings(b *testing.B) {
 		for pb.Next() {
 			enc := NewJSONEncoder()
 			enc.AddObject("strings", []string{"bar 1", "bar 2", "bar 3", "bar 4", "bar 5", "bar 6", "bar 7", "bar 8", "bar 9", "bar 10"})
-			enc.WriteEntry(ioutil.Discard, "fake", DebugLevel, ts)
+			enc.WriteEntry(ioutil.Discard, Entry{Message: "fake", Level: DebugLevel, Time: ts})
 			enc.Free()
 		}
 	})
@@ -83,7 +83,7 @@ func BenchmarkZapJSON(b *testing.B) {
 			enc.AddString("string3", "🤔")
 			enc.AddString("string4", "🙊")
 			enc.AddBool("bool", true)
-			enc.WriteEntry(ioutil.Discard, "fake", DebugLevel, ts)
+			enc.WriteEntry(ioutil.Discard, Entry{Message: "fake", Level: DebugLevel, Time: ts})
 			enc.Free()
 		}
 	})
@@ -140,6 +140,30 @@ func TestJSONEncoderFields(t *testing.T) {
 		{"marshaler", `"k":{}`, func(e Encoder) {
 			assert.Error(t, e.AddMarshaler("k", loggable{false}), "Expected an error calling MarshalLog.")
 		}},
+		{"array", `"k":[1,2,3]`, func(e Encoder) {
+			assert.NoError(t, e.AddArray("k", ints{1, 2, 3}), "Unexpected error adding an array.")
+		}},
+		{"array", `"k\\":["a\\",true,-1,1,2.5]`, func(e Encoder) {
+			assert.NoError(t, e.AddArray(`k\`, ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+				arr.AppendString(`a\`)
+				arr.AppendBool(true)
+				arr.AppendInt64(-1)
+				arr.AppendUint(1)
+				arr.AppendFloat64(2.5)
+				return nil
+			})), "Unexpected error adding an array.")
+		}},
+		{"array", `"k":[[],{"loggable":"yes"}]`, func(e Encoder) {
+			assert.NoError(t, e.AddArray("k", ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+				arr.AppendArray(ints{})
+				return arr.AppendMarshaler(loggable{true})
+			})), "Unexpected error adding an array.")
+		}},
+		{"array", `"k":[{}]`, func(e Encoder) {
+			assert.Error(t, e.AddArray("k", ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+				return arr.AppendMarshaler(loggable{false})
+			})), "Expected an error adding an array.")
+		}},
 		{"ints", `"k":[1,2,3]`, func(e Encoder) { e.AddObject("k", []int{1, 2, 3}) }},
 		{"strings", `"k":["bar 1","bar 2","bar 3"]`,
 			func(e Encoder) {
@@ -165,17 +189,12 @@ func TestJSONWriteEntry(t *testing.T) {
 	entry := &Entry{Level: InfoLevel, Message: `hello\`, Time: time.Unix(0, 0)}
 	enc := NewJSONEncoder()
 
-	assert.Equal(t, errNilSink, enc.WriteEntry(
-		nil,
-		entry.Message,
-		entry.Level,
-		entry.Time,
-	), "Expected an error writing to a nil sink.")
+	assert.Equal(t, errNilSink, enc.WriteEntry(nil, *entry), "Expected an error writing to a nil sink.")
 
 	// Messages should be escaped.
 	sink := &testBuffer{}
 	enc.AddString("foo", "bar")
-	err := enc.WriteEntry(sink, entry.Message, entry.Level, entry.Time)
+	err := enc.WriteEntry(sink, *entry)
 	assert.NoError(t, err, "WriteEntry returned an unexpected error.")
 	assert.Equal(
 		t,
@@ -186,7 +205,7 @@ func TestJSONWriteEntry(t *testing.T) {
 	// We should be able to re-use the encoder, preserving the accumulated
 	// fields.
 	sink.Reset()
-	err = enc.WriteEntry(sink, entry.Message, entry.Level, time.Unix(100, 0))
+	err = enc.WriteEntry(sink, Entry{Message: entry.Message, Level: entry.Level, Time: time.Unix(100, 0)})
 	assert.NoError(t, err, "WriteEntry returned an unexpected error.")
 	assert.Equal(
 		t,
@@ -200,7 +219,7 @@ func TestJSONWriteEntryLargeTimestamps(t *testing.T) {
 	sink := &testBuffer{}
 	enc := NewJSONEncoder()
 	future := time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC)
-	require.NoError(t, enc.WriteEntry(sink, "fake msg", DebugLevel, future))
+	require.NoError(t, enc.WriteEntry(sink, Entry{Message: "fake msg", Level: DebugLevel, Time: future}))
 	assert.Contains(
 		t,
 		sink.Stripped(),
@@ -232,7 +251,7 @@ func TestJSONWriteEntryFailure(t *testing.T) {
 			{spywrite.ShortWriter{}, "Expected an error on partial writes to sink."},
 		}
 		for _, tt := range tests {
-			err := enc.WriteEntry(tt.sink, "hello", InfoLevel, time.Unix(0, 0))
+			err := enc.WriteEntry(tt.sink, Entry{Message: "hello", Level: InfoLevel, Time: time.Unix(0, 0)})
 			assert.Error(t, err, tt.msg)
 		}
 	})
@@ -288,7 +307,7 @@ func TestJSONOptions(t *testing.T) {
 
 	for _, enc := range []Encoder{root, root.Clone()} {
 		buf := &bytes.Buffer{}
-		enc.WriteEntry(buf, "fake msg", DebugLevel, epoch)
+		enc.WriteEntry(buf, Entry{Message: "fake msg", Level: DebugLevel, Time: epoch})
 		assert.Equal(
 			t,
 			`{"the-level":"debug","the-timestamp":"1970-01-01T00:00:00Z","the-message":"fake msg"}`+"\n",
@@ -297,3 +316,25 @@ func TestJSONOptions(t *testing.T) {
 		)
 	}
 }
+
+func TestJSONValueEncoderOptions(t *testing.T) {
+	root := NewJSONEncoder(
+		NoTime(),
+		RFC3339NanoTimeEncoder(),
+		StringDurationEncoder(),
+		CapitalLevelEncoder(),
+	)
+	Time("start", time.Unix(0, 1).UTC()).AddTo(root)
+	Durations("waits", []time.Duration{time.Millisecond, time.Second}).AddTo(root)
+
+	for _, enc := range []Encoder{root, root.Clone()} {
+		buf := &bytes.Buffer{}
+		enc.WriteEntry(buf, Entry{Message: "fake msg", Level: WarnLevel, Time: epoch})
+		assert.Equal(
+			t,
+			`{"level":"WARN","msg":"fake msg","start":"1970-01-01T00:00:00.000000001Z","waits":["1ms","1s"]}`+"\n",
+			buf.String(),
+			"Unexpected log output with non-default value encoders.",
+		)
+	}
+}
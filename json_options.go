@@ -23,13 +23,14 @@ package zap
 import "time"
 
 // JSONOption is used to set options for a JSON encoder. MessageFormatters,
-// TimeFormatters, and LevelFormatters all implement the JSONOption interface.
+// TimeFormatters, LevelFormatters, NameFormatters, TimeEncoders,
+// DurationEncoders, and LevelEncoders all implement the JSONOption interface.
 type JSONOption interface {
 	apply(*jsonEncoder)
 }
 
 // A MessageFormatter defines how to convert a log message into a Field.
-// MessageFormatters implement the JSONOption interface.
+// MessageFormatters implement the JSONOption and LogfmtOption interfaces.
 type MessageFormatter func(string) Field
 
 func (mf MessageFormatter) apply(enc *jsonEncoder) {
@@ -44,21 +45,29 @@ func MessageKey(key string) MessageFormatter {
 }
 
 // A TimeFormatter defines how to convert the time of a log entry into a Field.
-// TimeFormatters implement the JSONOption interface.
+// TimeFormatters implement the JSONOption and LogfmtOption interfaces.
 type TimeFormatter func(time.Time) Field
 
 func (tf TimeFormatter) apply(enc *jsonEncoder) {
 	enc.timeF = tf
 }
 
-// EpochFormatter uses the Time field (floating-point seconds since epoch) to
-// encode the entry time under the provided key.
-func EpochFormatter(key string) TimeFormatter {
+// TimeKey encodes the entry time under the provided key, using the encoder's
+// TimeEncoder.
+func TimeKey(key string) TimeFormatter {
 	return TimeFormatter(func(t time.Time) Field {
 		return Time(key, t)
 	})
 }
 
+// EpochFormatter encodes the entry time as floating-point seconds since epoch
+// under the provided key, regardless of the encoder's TimeEncoder.
+func EpochFormatter(key string) TimeFormatter {
+	return TimeFormatter(func(t time.Time) Field {
+		return Float64(key, timeToSeconds(t))
+	})
+}
+
 // RFC3339Formatter encodes the entry time as an RFC3339-formatted string under
 // the provided key.
 func RFC3339Formatter(key string) TimeFormatter {
@@ -76,17 +85,46 @@ func NoTime() TimeFormatter {
 }
 
 // A LevelFormatter defines how to convert an entry's logging level into a
-// Field. LevelFormatters implement the JSONOption interface.
+// Field. LevelFormatters implement the JSONOption and LogfmtOption
+// interfaces.
 type LevelFormatter func(Level) Field
 
 func (lf LevelFormatter) apply(enc *jsonEncoder) {
 	enc.levelF = lf
 }
 
+// LevelKey encodes the entry's level under the provided key, using the
+// encoder's LevelEncoder.
+func LevelKey(key string) LevelFormatter {
+	return LevelFormatter(func(l Level) Field {
+		return Field{key: key, fieldType: levelType, ival: int64(l)}
+	})
+}
+
 // LevelString encodes the entry's level under the provided key. It uses the
-// level's String method to serialize it.
+// level's String method to serialize it, regardless of the encoder's
+// LevelEncoder.
 func LevelString(key string) LevelFormatter {
 	return LevelFormatter(func(l Level) Field {
 		return String(key, l.String())
 	})
 }
+
+// A NameFormatter defines how to convert a logger's name into a Field.
+// NameFormatters implement the JSONOption and LogfmtOption interfaces.
+type NameFormatter func(string) Field
+
+func (nf NameFormatter) apply(enc *jsonEncoder) {
+	enc.nameF = nf
+}
+
+// NameKey encodes the name of the logger under the provided key. Entries from
+// unnamed loggers omit the key altogether.
+func NameKey(key string) NameFormatter {
+	return NameFormatter(func(name string) Field {
+		if name == "" {
+			return Skip()
+		}
+		return String(key, name)
+	})
+}
@@ -49,10 +49,11 @@ func TestTimeFormatters(t *testing.T) {
 		formatter TimeFormatter
 		expected  Field
 	}{
+		{"TimeKey", TimeKey("the-time"), Time("the-time", epoch)},
 		{"EpochFormatter", EpochFormatter("the-time"), Float64("the-time", 0)},
 		{"RFC3339", RFC3339Formatter("ts"), String("ts", "1970-01-01T00:00:00Z")},
 		{"NoTime", NoTime(), Skip()},
-		{"Default", defaultTimeF, Float64("ts", 0)},
+		{"Default", defaultTimeF, Time("ts", epoch)},
 	}
 
 	for _, tt := range tests {
@@ -67,11 +68,29 @@ func TestLevelFormatters(t *testing.T) {
 		formatter LevelFormatter
 		expected  Field
 	}{
+		{"LevelKey", LevelKey("the-level"), Field{key: "the-level", fieldType: levelType, ival: int64(lvl)}},
 		{"LevelString", LevelString("the-level"), String("the-level", "info")},
-		{"Default", defaultLevelF, String("level", "info")},
+		{"Default", defaultLevelF, Field{key: "level", fieldType: levelType, ival: int64(lvl)}},
 	}
 
 	for _, tt := range tests {
 		assert.Equal(t, tt.expected, tt.formatter(lvl), "Unexpected output from LevelFormatter %s.", tt.name)
 	}
 }
+
+func TestNameFormatters(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatter NameFormatter
+		input     string
+		expected  Field
+	}{
+		{"NameKey", NameKey("the-logger"), "foo.bar", String("the-logger", "foo.bar")},
+		{"NameKey unnamed", NameKey("the-logger"), "", Skip()},
+		{"Default", defaultNameF, "foo", String("logger", "foo")},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tt.formatter(tt.input), "Unexpected output from NameFormatter %s.", tt.name)
+	}
+}
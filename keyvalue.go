@@ -20,6 +20,8 @@
 
 package zap
 
+import "time"
+
 // KeyValue is an encoding-agnostic interface to add structured data to the
 // logging context. Like maps, KeyValues aren't safe for concurrent use (though
 // typical use shouldn't require locks).
@@ -33,9 +35,31 @@ type KeyValue interface {
 	AddUint(key string, value uint)
 	AddUint64(key string, value uint64)
 	AddUintptr(key string, value uintptr)
+	AddTime(key string, value time.Time)
+	AddDuration(key string, value time.Duration)
+	AddArray(key string, marshaler ArrayMarshaler) error
 	AddMarshaler(key string, marshaler LogMarshaler) error
 	// AddObject uses reflection to serialize arbitrary objects, so it's slow and
 	// allocation-heavy. Consider implementing the LogMarshaler interface instead.
 	AddObject(key string, value interface{}) error
 	AddString(key, value string)
 }
+
+// ArrayEncoder is an encoding-agnostic interface to add a sequence of
+// unkeyed values to the logging context. It's the array counterpart of
+// KeyValue, and like KeyValue, it isn't safe for concurrent use.
+//
+// See ArrayMarshaler for an example.
+type ArrayEncoder interface {
+	AppendBool(value bool)
+	AppendFloat64(value float64)
+	AppendInt(value int)
+	AppendInt64(value int64)
+	AppendUint(value uint)
+	AppendUint64(value uint64)
+	AppendString(value string)
+	AppendTime(value time.Time)
+	AppendDuration(value time.Duration)
+	AppendArray(marshaler ArrayMarshaler) error
+	AppendMarshaler(marshaler LogMarshaler) error
+}
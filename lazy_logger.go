@@ -0,0 +1,142 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import "sync"
+
+// LazyWith creates a child logger like log.With(fields...), but defers the
+// call until the child is first used to write an entry. Child loggers that
+// are created on every request but rarely log (or only log at disabled
+// levels) never pay to encode their context.
+//
+// If the parent implements LevelEnabler (as loggers built by New do), calls
+// at disabled levels don't trigger encoding. DPanic, Panic, and Fatal always
+// do, since they may terminate the program even when disabled.
+func LazyWith(log Logger, fields ...Field) Logger {
+	return &lazyLogger{parent: log, fields: fields}
+}
+
+type lazyLogger struct {
+	parent Logger
+	fields []Field
+
+	once  sync.Once
+	child Logger
+}
+
+func (l *lazyLogger) logger() Logger {
+	l.once.Do(func() {
+		parent := l.parent
+		if lazy, ok := parent.(*lazyLogger); ok {
+			// Calling With on a lazy parent would just defer again.
+			parent = lazy.logger()
+		}
+		l.child = parent.With(l.fields...)
+	})
+	return l.child
+}
+
+// Enabled reports whether the parent logger is enabled at the given level,
+// which lets nested lazy loggers skip encoding too.
+func (l *lazyLogger) Enabled(lvl Level) bool {
+	if enab, ok := l.parent.(LevelEnabler); ok {
+		return enab.Enabled(lvl)
+	}
+	return true
+}
+
+func (l *lazyLogger) With(fields ...Field) Logger {
+	return LazyWith(l, fields...)
+}
+
+func (l *lazyLogger) Named(name string) Logger {
+	if name == "" {
+		return l
+	}
+	return LazyWith(l.parent.Named(name), l.fields...)
+}
+
+func (l *lazyLogger) Check(lvl Level, msg string) *CheckedMessage {
+	switch lvl {
+	case PanicLevel, FatalLevel:
+		break
+	case DPanicLevel:
+		// Like Meta.Check, DPanic is always checked in development.
+		if !l.IsDevelopment() && !l.Enabled(lvl) {
+			return nil
+		}
+	default:
+		if !l.Enabled(lvl) {
+			return nil
+		}
+	}
+	return l.logger().Check(lvl, msg)
+}
+
+func (l *lazyLogger) Log(lvl Level, msg string, fields ...Field) {
+	if l.Enabled(lvl) {
+		l.logger().Log(lvl, msg, fields...)
+	}
+}
+
+func (l *lazyLogger) Debug(msg string, fields ...Field) {
+	if l.Enabled(DebugLevel) {
+		l.logger().Debug(msg, fields...)
+	}
+}
+
+func (l *lazyLogger) Info(msg string, fields ...Field) {
+	if l.Enabled(InfoLevel) {
+		l.logger().Info(msg, fields...)
+	}
+}
+
+func (l *lazyLogger) Warn(msg string, fields ...Field) {
+	if l.Enabled(WarnLevel) {
+		l.logger().Warn(msg, fields...)
+	}
+}
+
+func (l *lazyLogger) Error(msg string, fields ...Field) {
+	if l.Enabled(ErrorLevel) {
+		l.logger().Error(msg, fields...)
+	}
+}
+
+func (l *lazyLogger) DPanic(msg string, fields ...Field) {
+	l.logger().DPanic(msg, fields...)
+}
+
+func (l *lazyLogger) Panic(msg string, fields ...Field) {
+	l.logger().Panic(msg, fields...)
+}
+
+func (l *lazyLogger) Fatal(msg string, fields ...Field) {
+	l.logger().Fatal(msg, fields...)
+}
+
+func (l *lazyLogger) IsDevelopment() bool {
+	return IsDevelopment(l.parent)
+}
+
+func (l *lazyLogger) Sync() error {
+	return l.parent.Sync()
+}
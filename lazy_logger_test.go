@@ -0,0 +1,114 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func countingField(calls *int) Field {
+	return Lazy(func() Field {
+		*calls++
+		return Int("foo", 42)
+	})
+}
+
+func TestLazyWith(t *testing.T) {
+	withJSONLogger(t, opts(InfoLevel), func(logger Logger, buf *testBuffer) {
+		calls := 0
+		child := LazyWith(logger, countingField(&calls))
+		assert.Equal(t, 0, calls, "Expected LazyWith not to encode context eagerly.")
+
+		child.Debug("")
+		assert.Nil(t, child.Check(DebugLevel, ""), "Expected a nil CheckedMessage at disabled levels.")
+		assert.Equal(t, 0, calls, "Expected disabled levels not to encode context.")
+
+		child.Info("")
+		child.Warn("")
+		child.Check(ErrorLevel, "").Write()
+		assert.Equal(t, 1, calls, "Expected context to be encoded exactly once.")
+		assert.Equal(t, []string{
+			`{"level":"info","msg":"","foo":42}`,
+			`{"level":"warn","msg":"","foo":42}`,
+			`{"level":"error","msg":"","foo":42}`,
+		}, buf.Lines(), "Unexpected output from lazy child logger.")
+	})
+}
+
+func TestLazyWithChildren(t *testing.T) {
+	withJSONLogger(t, nil, func(logger Logger, buf *testBuffer) {
+		calls := 0
+		child := LazyWith(logger.Named("rpc"), countingField(&calls))
+		grandchild := child.With(String("bar", "baz")).Named("client")
+		assert.True(t, child == child.Named(""), "Expected empty names to be no-ops.")
+		assert.Equal(t, 0, calls, "Expected child loggers not to encode context eagerly.")
+
+		grandchild.Info("")
+		child.Log(InfoLevel, "")
+		assert.Equal(t, []string{
+			`{"level":"info","logger":"rpc.client","msg":"","foo":42,"bar":"baz"}`,
+			`{"level":"info","logger":"rpc","msg":"","foo":42}`,
+		}, buf.Lines(), "Unexpected output from children of a lazy logger.")
+	})
+}
+
+func TestLazyWithTee(t *testing.T) {
+	withJSONLogger(t, nil, func(logger Logger, buf *testBuffer) {
+		tee := LazyWith(Tee(logger, logger.Named("copy")), Int("foo", 42))
+		tee.Error("")
+		assert.Equal(t, []string{
+			`{"level":"error","msg":"","foo":42}`,
+			`{"level":"error","logger":"copy","msg":"","foo":42}`,
+		}, buf.Lines(), "Unexpected output from a lazy Tee.")
+	})
+}
+
+func TestLazyWithPanicAndFatal(t *testing.T) {
+	withJSONLogger(t, opts(FatalLevel+1, Development()), func(logger Logger, buf *testBuffer) {
+		child := LazyWith(logger, Int("foo", 42))
+		assert.True(t, IsDevelopment(child), "Expected lazy loggers to report the parent's mode.")
+		assert.NotNil(t, child.Check(PanicLevel, ""), "Expected Check to be OK at PanicLevel.")
+		assert.NotNil(t, child.Check(DPanicLevel, ""), "Expected Check to be OK at DPanicLevel in development.")
+		assert.Panics(t, func() { child.Panic("") }, "Expected Panic to panic.")
+		assert.Panics(t, func() { child.DPanic("") }, "Expected DPanic to panic in development.")
+
+		stub := stubExit()
+		defer stub.Unstub()
+		child.Fatal("")
+		stub.AssertStatus(t, 1)
+		assert.Empty(t, buf.Lines(), "Expected disabled levels not to produce output.")
+	})
+}
+
+func TestLazyWithCheckDPanic(t *testing.T) {
+	withJSONLogger(t, opts(FatalLevel+1), func(logger Logger, buf *testBuffer) {
+		child := LazyWith(logger, Int("foo", 42))
+		assert.Nil(t, child.Check(DPanicLevel, ""), "Expected Check to skip disabled DPanics outside development.")
+	})
+}
+
+func TestLazyWithSync(t *testing.T) {
+	withJSONLogger(t, nil, func(logger Logger, _ *testBuffer) {
+		assert.NoError(t, LazyWith(logger).Sync(), "Unexpected error syncing a lazy logger.")
+	})
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	uatomic "github.com/uber-go/atomic"
+)
+
+// A LevelRegistry maps logger names to AtomicLevels, allowing applications to
+// change the logging level of individual components at runtime. Each named
+// logger uses the level registered for the most specific matching prefix of
+// its name: a logger named "rpc.client.retry" uses the level registered for
+// "rpc.client.retry" if there is one, then the level for "rpc.client", then
+// the level for "rpc". Loggers whose names don't match any registered prefix
+// use the registry's root level.
+//
+// Prefixes match whole name segments, so the level for "rpc" applies to
+// "rpc.client" but not to "rpcx".
+//
+// Like AtomicLevel, a *LevelRegistry is a valid Option. Loggers configured
+// with a registry (and all their descendants, whether created with With or
+// Named) use the registry's levels. Each logger resolves its AtomicLevel once
+// and only looks it up again after names are registered or unset, so checking
+// the level on the logging path doesn't take any locks.
+type LevelRegistry struct {
+	mu     sync.RWMutex
+	root   AtomicLevel
+	levels map[string]AtomicLevel
+	// generation is incremented each time a name is registered or unset,
+	// invalidating the AtomicLevels resolved by loggers.
+	generation uatomic.Uint64
+}
+
+// NewLevelRegistry creates a LevelRegistry that falls back to the supplied
+// AtomicLevel for loggers that don't match any registered name.
+func NewLevelRegistry(root AtomicLevel) *LevelRegistry {
+	return &LevelRegistry{
+		root:   root,
+		levels: make(map[string]AtomicLevel),
+	}
+}
+
+// apply implements the Option interface.
+func (r *LevelRegistry) apply(m *Meta) {
+	m.LevelEnabler = r.scoped(m.Name)
+}
+
+// Enabled reports whether the given level is enabled at the root level.
+func (r *LevelRegistry) Enabled(lvl Level) bool {
+	return r.root.Enabled(lvl)
+}
+
+// AtomicLevel returns the AtomicLevel used by loggers with the given name,
+// which may belong to one of the name's prefixes or to the root. Callers that
+// want to change the level of a name that isn't yet registered should use
+// SetLevel instead.
+func (r *LevelRegistry) AtomicLevel(name string) AtomicLevel {
+	r.mu.RLock()
+	lvl := r.lookup(name)
+	r.mu.RUnlock()
+	return lvl
+}
+
+// Level returns the minimum enabled level for loggers with the given name.
+func (r *LevelRegistry) Level(name string) Level {
+	return r.AtomicLevel(name).Level()
+}
+
+// SetLevel sets the level for the given name and all its descendants that
+// don't have a more specific level of their own. Setting the level for the
+// empty name changes the root level.
+func (r *LevelRegistry) SetLevel(name string, lvl Level) {
+	if name == "" {
+		r.root.SetLevel(lvl)
+		return
+	}
+
+	r.mu.Lock()
+	if existing, ok := r.levels[name]; ok {
+		existing.SetLevel(lvl)
+	} else {
+		atomicLvl := DynamicLevel()
+		atomicLvl.SetLevel(lvl)
+		r.levels[name] = atomicLvl
+		r.generation.Inc()
+	}
+	r.mu.Unlock()
+}
+
+// Unset removes the level registered for the given name, so loggers with that
+// name fall back to the level of the next most specific prefix. The root
+// level can't be removed.
+func (r *LevelRegistry) Unset(name string) {
+	r.mu.Lock()
+	if _, ok := r.levels[name]; ok {
+		delete(r.levels, name)
+		r.generation.Inc()
+	}
+	r.mu.Unlock()
+}
+
+// Levels returns a snapshot of all the explicitly registered levels, keyed by
+// name. The root level is included under the empty name.
+func (r *LevelRegistry) Levels() map[string]Level {
+	r.mu.RLock()
+	levels := make(map[string]Level, len(r.levels)+1)
+	for name, lvl := range r.levels {
+		levels[name] = lvl.Level()
+	}
+	r.mu.RUnlock()
+	levels[""] = r.root.Level()
+	return levels
+}
+
+// names returns the registered names, including the root, in sorted order.
+func (r *LevelRegistry) names() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.levels)+1)
+	names = append(names, "")
+	for name := range r.levels {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// lookup finds the most specific AtomicLevel for a name. It must be called
+// with the read lock held.
+func (r *LevelRegistry) lookup(name string) AtomicLevel {
+	for name != "" {
+		if lvl, ok := r.levels[name]; ok {
+			return lvl
+		}
+		idx := strings.LastIndexByte(name, '.')
+		if idx < 0 {
+			break
+		}
+		name = name[:idx]
+	}
+	return r.root
+}
+
+func (r *LevelRegistry) scoped(name string) LevelEnabler {
+	return &registryLevel{registry: r, name: name}
+}
+
+// A nameScopedEnabler is a LevelEnabler whose decisions depend on the name of
+// the logger using it. Meta.Named re-scopes these enablers for child loggers.
+type nameScopedEnabler interface {
+	LevelEnabler
+
+	scoped(name string) LevelEnabler
+}
+
+// registryLevel is a LevelEnabler that consults a LevelRegistry on behalf of
+// a single logger name.
+type registryLevel struct {
+	registry *LevelRegistry
+	name     string
+	resolved atomic.Value // resolvedLevel
+}
+
+// resolvedLevel caches the AtomicLevel for a name, along with the registry
+// generation it was looked up in.
+type resolvedLevel struct {
+	generation uint64
+	level      AtomicLevel
+}
+
+func (rl *registryLevel) Enabled(lvl Level) bool {
+	return rl.level().Enabled(lvl)
+}
+
+func (rl *registryLevel) level() AtomicLevel {
+	gen := rl.registry.generation.Load()
+	if cached, ok := rl.resolved.Load().(resolvedLevel); ok && cached.generation == gen {
+		return cached.level
+	}
+	// Read the generation under the lock, so that a concurrent change can't
+	// be cached under a newer generation than the lookup reflects.
+	rl.registry.mu.RLock()
+	resolved := resolvedLevel{
+		generation: rl.registry.generation.Load(),
+		level:      rl.registry.lookup(rl.name),
+	}
+	rl.registry.mu.RUnlock()
+	rl.resolved.Store(resolved)
+	return resolved.level
+}
+
+func (rl *registryLevel) scoped(name string) LevelEnabler {
+	return rl.registry.scoped(name)
+}
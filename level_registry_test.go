@@ -0,0 +1,105 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelRegistryLookup(t *testing.T) {
+	r := NewLevelRegistry(DynamicLevel())
+	r.SetLevel("rpc", WarnLevel)
+	r.SetLevel("rpc.client", DebugLevel)
+
+	tests := []struct {
+		name     string
+		expected Level
+	}{
+		{"", InfoLevel},
+		{"http", InfoLevel},
+		{"rpc", WarnLevel},
+		{"rpcx", InfoLevel},
+		{"rpc.server", WarnLevel},
+		{"rpc.client", DebugLevel},
+		{"rpc.client.retry", DebugLevel},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, r.Level(tt.name), "Unexpected level for name %q.", tt.name)
+	}
+
+	r.Unset("rpc.client")
+	assert.Equal(t, WarnLevel, r.Level("rpc.client.retry"), "Expected to fall back to parent after Unset.")
+
+	r.SetLevel("", ErrorLevel)
+	assert.Equal(t, ErrorLevel, r.Level("http"), "Expected setting the empty name to change the root level.")
+	assert.True(t, r.Enabled(ErrorLevel), "Expected registry to enable levels at or above the root.")
+	assert.False(t, r.Enabled(WarnLevel), "Expected registry to disable levels below the root.")
+
+	assert.Equal(t, map[string]Level{"": ErrorLevel, "rpc": WarnLevel}, r.Levels(), "Unexpected registered levels.")
+}
+
+func TestLevelRegistryLogger(t *testing.T) {
+	r := NewLevelRegistry(DynamicLevel())
+	withJSONLogger(t, opts(r), func(logger Logger, buf *testBuffer) {
+		client := logger.Named("rpc").With(Int("foo", 42)).Named("client")
+		server := logger.Named("rpc").Named("server")
+
+		client.Debug("")
+		server.Debug("")
+		logger.Debug("")
+		assert.Empty(t, buf.String(), "Expected all loggers to use the root level.")
+
+		// Levels registered after the loggers were created should still apply.
+		r.SetLevel("rpc.client", DebugLevel)
+		client.Debug("")
+		server.Debug("")
+		logger.Debug("")
+		assert.Equal(t, []string{
+			`{"level":"debug","logger":"rpc.client","msg":"","foo":42}`,
+		}, buf.Lines(), "Expected only the client logger to log at debug.")
+
+		buf.Reset()
+		r.SetLevel("rpc", ErrorLevel)
+		client.Info("")
+		server.Info("")
+		logger.Info("")
+		assert.Equal(t, []string{
+			`{"level":"info","logger":"rpc.client","msg":"","foo":42}`,
+			`{"level":"info","msg":""}`,
+		}, buf.Lines(), "Expected the most specific level to win.")
+	})
+}
+
+func TestLevelRegistryResolvedLevels(t *testing.T) {
+	r := NewLevelRegistry(DynamicLevel())
+	enab := r.scoped("rpc.client")
+	assert.False(t, enab.Enabled(DebugLevel), "Expected the root level to apply.")
+	assert.Equal(t, 0.0, testing.AllocsPerRun(100, func() { enab.Enabled(DebugLevel) }), "Expected resolved levels not to allocate.")
+
+	r.SetLevel("rpc", DebugLevel)
+	assert.True(t, enab.Enabled(DebugLevel), "Expected registering a prefix to invalidate resolved levels.")
+	r.SetLevel("rpc", ErrorLevel)
+	assert.False(t, enab.Enabled(WarnLevel), "Expected changes to a resolved level to apply immediately.")
+	r.Unset("rpc")
+	assert.True(t, enab.Enabled(InfoLevel), "Expected Unset to invalidate resolved levels.")
+}
@@ -0,0 +1,546 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	// Default formatters for logfmt encoders.
+	defaultLogfmtTimeF = RFC3339Formatter("ts")
+
+	logfmtPool = sync.Pool{New: func() interface{} {
+		return &logfmtEncoder{
+			// Pre-allocate a reasonably-sized buffer for each encoder.
+			bytes: make([]byte, 0, _initialBufSize),
+		}
+	}}
+)
+
+// LogfmtOption is used to set options for a logfmt encoder. MessageFormatters,
+// TimeFormatters, LevelFormatters, NameFormatters, TimeEncoders,
+// DurationEncoders, and LevelEncoders all implement the LogfmtOption
+// interface.
+type LogfmtOption interface {
+	applyLogfmt(*logfmtEncoder)
+}
+
+func (mf MessageFormatter) applyLogfmt(enc *logfmtEncoder) {
+	enc.messageF = mf
+}
+
+func (tf TimeFormatter) applyLogfmt(enc *logfmtEncoder) {
+	enc.timeF = tf
+}
+
+func (lf LevelFormatter) applyLogfmt(enc *logfmtEncoder) {
+	enc.levelF = lf
+}
+
+func (nf NameFormatter) applyLogfmt(enc *logfmtEncoder) {
+	enc.nameF = nf
+}
+
+func (te TimeEncoder) applyLogfmt(enc *logfmtEncoder) {
+	enc.timeEnc = te
+}
+
+func (de DurationEncoder) applyLogfmt(enc *logfmtEncoder) {
+	enc.durationEnc = de
+}
+
+func (le LevelEncoder) applyLogfmt(enc *logfmtEncoder) {
+	enc.levelEnc = le
+}
+
+// logfmtEncoder is an Encoder implementation that writes logfmt.
+type logfmtEncoder struct {
+	bytes    []byte
+	messageF MessageFormatter
+	timeF    TimeFormatter
+	levelF   LevelFormatter
+	nameF    NameFormatter
+
+	timeEnc     TimeEncoder
+	durationEnc DurationEncoder
+	levelEnc    LevelEncoder
+
+	// When encoding nested objects and arrays, keys are prefixed with the
+	// parent's key. Array elements use their index as a key.
+	prefix string
+	index  int
+
+	// keyed is set while a TimeEncoder, DurationEncoder, or LevelEncoder
+	// appends the value for a key that's already been written.
+	keyed bool
+}
+
+// NewLogfmtEncoder creates a logfmt encoder, which writes each entry as a
+// single line of space-separated key=value pairs. By default, logfmt encoders
+// put the log level under the "level" key, the timestamp (as an RFC3339
+// string) under the "ts" key, the logger's name (if any) under the "logger"
+// key, and the log message under the "msg" key.
+//
+// Values are quoted if they're empty or contain spaces, equals signs, quotes,
+// backslashes, or non-printable characters; within quotes, special characters
+// are escaped as they would be in JSON. Unsafe characters in keys are replaced
+// with underscores. Since logfmt has no notion of nesting, LogMarshalers and
+// arrays are flattened: a LogMarshaler under the key "user" that adds a "name"
+// is encoded as user.name=value, and the elements of an array under the key
+// "ids" are encoded as ids.0=value, ids.1=value, and so on. Objects added with
+// AddObject are serialized to JSON and encoded as a single value.
+//
+// Like the JSON encoder, logfmt encoders default to EpochTimeEncoder,
+// NanosDurationEncoder, and LowercaseLevelEncoder.
+func NewLogfmtEncoder(options ...LogfmtOption) Encoder {
+	enc := logfmtPool.Get().(*logfmtEncoder)
+	enc.truncate()
+
+	enc.messageF = defaultMessageF
+	enc.timeF = defaultLogfmtTimeF
+	enc.levelF = defaultLevelF
+	enc.nameF = defaultNameF
+	enc.timeEnc = EpochTimeEncoder()
+	enc.durationEnc = NanosDurationEncoder()
+	enc.levelEnc = LowercaseLevelEncoder()
+	for _, opt := range options {
+		opt.applyLogfmt(enc)
+	}
+
+	return enc
+}
+
+func (enc *logfmtEncoder) Free() {
+	logfmtPool.Put(enc)
+}
+
+// AddString adds a string key and value to the encoder's fields, quoting the
+// value if necessary.
+func (enc *logfmtEncoder) AddString(key, val string) {
+	enc.addKey(key)
+	enc.safeAddString(val)
+}
+
+// AddBool adds a string key and a boolean value to the encoder's fields.
+func (enc *logfmtEncoder) AddBool(key string, val bool) {
+	enc.addKey(key)
+	enc.bytes = strconv.AppendBool(enc.bytes, val)
+}
+
+// AddInt adds a string key and integer value to the encoder's fields.
+func (enc *logfmtEncoder) AddInt(key string, val int) {
+	enc.AddInt64(key, int64(val))
+}
+
+// AddInt64 adds a string key and int64 value to the encoder's fields.
+func (enc *logfmtEncoder) AddInt64(key string, val int64) {
+	enc.addKey(key)
+	enc.bytes = strconv.AppendInt(enc.bytes, val, 10)
+}
+
+// AddUint adds a string key and integer value to the encoder's fields.
+func (enc *logfmtEncoder) AddUint(key string, val uint) {
+	enc.AddUint64(key, uint64(val))
+}
+
+// AddUint64 adds a string key and integer value to the encoder's fields.
+func (enc *logfmtEncoder) AddUint64(key string, val uint64) {
+	enc.addKey(key)
+	enc.bytes = strconv.AppendUint(enc.bytes, val, 10)
+}
+
+// AddUintptr adds a string key and uintptr value to the encoder's fields.
+func (enc *logfmtEncoder) AddUintptr(key string, val uintptr) {
+	enc.AddUint64(key, uint64(val))
+}
+
+// AddFloat64 adds a string key and float64 value to the encoder's fields,
+// using the same representation as the JSON encoder.
+func (enc *logfmtEncoder) AddFloat64(key string, val float64) {
+	enc.addKey(key)
+	enc.appendFloat64(val)
+}
+
+// AddTime adds a string key and time.Time value to the encoder's fields,
+// using the encoder's TimeEncoder.
+func (enc *logfmtEncoder) AddTime(key string, val time.Time) {
+	enc.addKey(key)
+	enc.keyed = true
+	enc.timeEnc(val, enc)
+	enc.keyed = false
+}
+
+// AddDuration adds a string key and time.Duration value to the encoder's
+// fields, using the encoder's DurationEncoder.
+func (enc *logfmtEncoder) AddDuration(key string, val time.Duration) {
+	enc.addKey(key)
+	enc.keyed = true
+	enc.durationEnc(val, enc)
+	enc.keyed = false
+}
+
+// AddMarshaler adds a LogMarshaler to the encoder's fields, prefixing each of
+// its keys with the supplied key.
+func (enc *logfmtEncoder) AddMarshaler(key string, obj LogMarshaler) error {
+	return enc.nested(enc.fullKey(key), obj)
+}
+
+// AddArray adds an ArrayMarshaler to the encoder's fields, using each
+// element's index as a key.
+func (enc *logfmtEncoder) AddArray(key string, arr ArrayMarshaler) error {
+	return enc.nestedArray(enc.fullKey(key), arr)
+}
+
+// AddObject uses reflection to serialize an arbitrary object to JSON, then
+// adds it to the encoder's fields as a single value.
+func (enc *logfmtEncoder) AddObject(key string, obj interface{}) error {
+	marshaled, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	enc.addKey(key)
+	enc.safeAddString(string(marshaled))
+	return nil
+}
+
+// AppendString adds a string to the current array.
+func (enc *logfmtEncoder) AppendString(val string) {
+	enc.addIndex()
+	enc.safeAddString(val)
+}
+
+// AppendBool adds a boolean to the current array.
+func (enc *logfmtEncoder) AppendBool(val bool) {
+	enc.addIndex()
+	enc.bytes = strconv.AppendBool(enc.bytes, val)
+}
+
+// AppendInt adds an integer to the current array.
+func (enc *logfmtEncoder) AppendInt(val int) {
+	enc.AppendInt64(int64(val))
+}
+
+// AppendInt64 adds an int64 to the current array.
+func (enc *logfmtEncoder) AppendInt64(val int64) {
+	enc.addIndex()
+	enc.bytes = strconv.AppendInt(enc.bytes, val, 10)
+}
+
+// AppendUint adds an unsigned integer to the current array.
+func (enc *logfmtEncoder) AppendUint(val uint) {
+	enc.AppendUint64(uint64(val))
+}
+
+// AppendUint64 adds a uint64 to the current array.
+func (enc *logfmtEncoder) AppendUint64(val uint64) {
+	enc.addIndex()
+	enc.bytes = strconv.AppendUint(enc.bytes, val, 10)
+}
+
+// AppendFloat64 adds a float64 to the current array.
+func (enc *logfmtEncoder) AppendFloat64(val float64) {
+	enc.addIndex()
+	enc.appendFloat64(val)
+}
+
+// AppendTime adds a time.Time to the current array, using the encoder's
+// TimeEncoder.
+func (enc *logfmtEncoder) AppendTime(val time.Time) {
+	enc.timeEnc(val, enc)
+}
+
+// AppendDuration adds a time.Duration to the current array, using the
+// encoder's DurationEncoder.
+func (enc *logfmtEncoder) AppendDuration(val time.Duration) {
+	enc.durationEnc(val, enc)
+}
+
+// AppendArray adds a nested array to the current array.
+func (enc *logfmtEncoder) AppendArray(arr ArrayMarshaler) error {
+	return enc.nestedArray(enc.nextIndex(), arr)
+}
+
+// AppendMarshaler adds a LogMarshaler to the current array.
+func (enc *logfmtEncoder) AppendMarshaler(obj LogMarshaler) error {
+	return enc.nested(enc.nextIndex(), obj)
+}
+
+// Clone copies the current encoder, including any data already encoded.
+func (enc *logfmtEncoder) Clone() Encoder {
+	clone := logfmtPool.Get().(*logfmtEncoder)
+	clone.truncate()
+	clone.bytes = append(clone.bytes, enc.bytes...)
+	clone.messageF = enc.messageF
+	clone.timeF = enc.timeF
+	clone.levelF = enc.levelF
+	clone.nameF = enc.nameF
+	clone.setEncoders(enc)
+	return clone
+}
+
+// WriteEntry writes a complete log message to the supplied writer, including
+// the encoder's accumulated fields. It doesn't modify or lock the encoder's
+// underlying byte slice. It's safe to call from multiple goroutines, but it's
+// not safe to call WriteEntry while adding fields.
+func (enc *logfmtEncoder) WriteEntry(sink io.Writer, ent Entry) error {
+	if sink == nil {
+		return errNilSink
+	}
+
+	final := logfmtPool.Get().(*logfmtEncoder)
+	final.truncate()
+	final.setEncoders(enc)
+	enc.levelF(ent.Level).AddTo(final)
+	enc.timeF(ent.Time).AddTo(final)
+	enc.nameF(ent.LoggerName).AddTo(final)
+	enc.messageF(ent.Message).AddTo(final)
+	if len(enc.bytes) > 0 {
+		final.addSeparator()
+		final.bytes = append(final.bytes, enc.bytes...)
+	}
+	final.bytes = append(final.bytes, '\n')
+
+	expectedBytes := len(final.bytes)
+	n, err := sink.Write(final.bytes)
+	final.Free()
+	if err != nil {
+		return err
+	}
+	if n != expectedBytes {
+		return fmt.Errorf("incomplete write: only wrote %v of %v bytes", n, expectedBytes)
+	}
+	return nil
+}
+
+func (enc *logfmtEncoder) truncate() {
+	enc.bytes = enc.bytes[:0]
+	enc.prefix = ""
+	enc.index = 0
+	enc.keyed = false
+}
+
+// setEncoders copies another encoder's TimeEncoder, DurationEncoder, and
+// LevelEncoder.
+func (enc *logfmtEncoder) setEncoders(from *logfmtEncoder) {
+	enc.timeEnc = from.timeEnc
+	enc.durationEnc = from.durationEnc
+	enc.levelEnc = from.levelEnc
+}
+
+// addLevel implements levelAdder, so that LevelKey uses the encoder's
+// LevelEncoder.
+func (enc *logfmtEncoder) addLevel(key string, lvl Level) {
+	enc.addKey(key)
+	enc.keyed = true
+	enc.levelEnc(lvl, enc)
+	enc.keyed = false
+}
+
+func (enc *logfmtEncoder) addSeparator() {
+	if len(enc.bytes) > 0 {
+		enc.bytes = append(enc.bytes, ' ')
+	}
+}
+
+func (enc *logfmtEncoder) addKey(key string) {
+	enc.addSeparator()
+	if enc.prefix != "" {
+		enc.safeAddKey(enc.prefix)
+		enc.bytes = append(enc.bytes, '.')
+	}
+	enc.safeAddKey(key)
+	enc.bytes = append(enc.bytes, '=')
+}
+
+func (enc *logfmtEncoder) addIndex() {
+	if enc.keyed {
+		// The key was already written by AddTime, AddDuration, or addLevel.
+		enc.keyed = false
+		return
+	}
+	enc.addSeparator()
+	enc.safeAddKey(enc.prefix)
+	enc.bytes = append(enc.bytes, '.')
+	enc.bytes = strconv.AppendInt(enc.bytes, int64(enc.index), 10)
+	enc.bytes = append(enc.bytes, '=')
+	enc.index++
+}
+
+// fullKey returns the supplied key, prefixed with the current object's key.
+func (enc *logfmtEncoder) fullKey(key string) string {
+	if enc.prefix == "" {
+		return key
+	}
+	return enc.prefix + "." + key
+}
+
+// nextIndex returns the full key for the next element of the current array.
+func (enc *logfmtEncoder) nextIndex() string {
+	key := enc.prefix + "." + strconv.Itoa(enc.index)
+	enc.index++
+	return key
+}
+
+func (enc *logfmtEncoder) nested(prefix string, obj LogMarshaler) error {
+	oldPrefix, oldIndex := enc.prefix, enc.index
+	enc.prefix = prefix
+	err := obj.MarshalLog(enc)
+	enc.prefix, enc.index = oldPrefix, oldIndex
+	return err
+}
+
+func (enc *logfmtEncoder) nestedArray(prefix string, arr ArrayMarshaler) error {
+	oldPrefix, oldIndex := enc.prefix, enc.index
+	enc.prefix, enc.index = prefix, 0
+	err := arr.MarshalLogArray(enc)
+	enc.prefix, enc.index = oldPrefix, oldIndex
+	return err
+}
+
+func (enc *logfmtEncoder) appendFloat64(val float64) {
+	switch {
+	case math.IsNaN(val):
+		enc.bytes = append(enc.bytes, "NaN"...)
+	case math.IsInf(val, 1):
+		enc.bytes = append(enc.bytes, "+Inf"...)
+	case math.IsInf(val, -1):
+		enc.bytes = append(enc.bytes, "-Inf"...)
+	default:
+		enc.bytes = strconv.AppendFloat(enc.bytes, val, 'f', -1, 64)
+	}
+}
+
+// safeAddKey appends a key to the internal buffer, replacing any characters
+// that aren't allowed in logfmt keys with underscores.
+func (enc *logfmtEncoder) safeAddKey(key string) {
+	if key == "" {
+		enc.bytes = append(enc.bytes, '_')
+		return
+	}
+	for i := 0; i < len(key); {
+		if b := key[i]; b < utf8.RuneSelf {
+			i++
+			if b <= ' ' || b == '=' || b == '"' || b == 0x7f {
+				b = '_'
+			}
+			enc.bytes = append(enc.bytes, b)
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(key[i:])
+		if (c == utf8.RuneError && size == 1) || unicode.IsSpace(c) || !unicode.IsPrint(c) {
+			enc.bytes = append(enc.bytes, '_')
+		} else {
+			enc.bytes = append(enc.bytes, key[i:i+size]...)
+		}
+		i += size
+	}
+}
+
+// safeAddString appends a value to the internal buffer, quoting and escaping
+// it if necessary.
+func (enc *logfmtEncoder) safeAddString(s string) {
+	if !needsLogfmtQuotes(s) {
+		enc.bytes = append(enc.bytes, s...)
+		return
+	}
+	enc.bytes = append(enc.bytes, '"')
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			i++
+			if 0x20 <= b && b != '\\' && b != '"' && b != 0x7f {
+				enc.bytes = append(enc.bytes, b)
+				continue
+			}
+			switch b {
+			case '\\', '"':
+				enc.bytes = append(enc.bytes, '\\', b)
+			case '\n':
+				enc.bytes = append(enc.bytes, '\\', 'n')
+			case '\r':
+				enc.bytes = append(enc.bytes, '\\', 'r')
+			case '\t':
+				enc.bytes = append(enc.bytes, '\\', 't')
+			default:
+				// Encode bytes < 0x20 and DEL, except for the escape sequences
+				// above.
+				enc.addEscapedRune(rune(b))
+			}
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case c == utf8.RuneError && size == 1:
+			enc.bytes = append(enc.bytes, `\ufffd`...)
+		case !unicode.IsPrint(c):
+			// Non-printing runes (e.g., U+0085 and U+2028) can break lines or
+			// hide content, so they're escaped rather than copied.
+			enc.addEscapedRune(c)
+		default:
+			enc.bytes = append(enc.bytes, s[i:i+size]...)
+		}
+		i += size
+	}
+	enc.bytes = append(enc.bytes, '"')
+}
+
+// addEscapedRune appends a \uXXXX escape for the rune, using a UTF-16
+// surrogate pair for runes outside the Basic Multilingual Plane.
+func (enc *logfmtEncoder) addEscapedRune(c rune) {
+	if c > 0xFFFF {
+		r1, r2 := utf16.EncodeRune(c)
+		enc.addEscapedRune(r1)
+		enc.addEscapedRune(r2)
+		return
+	}
+	enc.bytes = append(enc.bytes, '\\', 'u',
+		_hex[c>>12&0xF], _hex[c>>8&0xF], _hex[c>>4&0xF], _hex[c&0xF])
+}
+
+func needsLogfmtQuotes(s string) bool {
+	if s == "" {
+		return true
+	}
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b <= ' ' || b == '=' || b == '"' || b == '\\' || b == 0x7f {
+				return true
+			}
+			i++
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if (c == utf8.RuneError && size == 1) || unicode.IsSpace(c) || !unicode.IsPrint(c) {
+			return true
+		}
+		i += size
+	}
+	return false
+}
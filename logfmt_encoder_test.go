@@ -0,0 +1,257 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/uber-go/zap/spywrite"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newLogfmtEncoder(opts ...LogfmtOption) *logfmtEncoder {
+	return NewLogfmtEncoder(opts...).(*logfmtEncoder)
+}
+
+func withLogfmtEncoder(f func(*logfmtEncoder)) {
+	enc := newLogfmtEncoder()
+	f(enc)
+	enc.Free()
+}
+
+type nestedLoggable struct{}
+
+func (nestedLoggable) MarshalLog(kv KeyValue) error {
+	kv.AddString("name", "jane doe")
+	kv.AddMarshaler("inner", loggable{true})
+	return kv.AddArray("ids", ints{1, 2})
+}
+
+func assertLogfmtOutput(t testing.TB, desc string, expected string, f func(Encoder)) {
+	withLogfmtEncoder(func(enc *logfmtEncoder) {
+		f(enc)
+		assert.Equal(t, expected, string(enc.bytes), "Unexpected encoder output after adding a %s.", desc)
+	})
+	withLogfmtEncoder(func(enc *logfmtEncoder) {
+		enc.AddString("foo", "bar")
+		f(enc)
+		expectedPrefix := "foo=bar"
+		if expected != "" {
+			// If we expect output, it should be space-separated from the
+			// previous field.
+			expectedPrefix += " "
+		}
+		assert.Equal(t, expectedPrefix+expected, string(enc.bytes), "Unexpected encoder output after adding a %s as a second field.", desc)
+	})
+}
+
+func TestLogfmtEncoderFields(t *testing.T) {
+	tests := []struct {
+		desc     string
+		expected string
+		f        func(Encoder)
+	}{
+		{"string", `k=v`, func(e Encoder) { e.AddString("k", "v") }},
+		{"string", `k=""`, func(e Encoder) { e.AddString("k", "") }},
+		{"string", `k="a b"`, func(e Encoder) { e.AddString("k", "a b") }},
+		{"string", `k="a=b"`, func(e Encoder) { e.AddString("k", "a=b") }},
+		{"string", `k="line\nbreak level=error"`, func(e Encoder) { e.AddString("k", "line\nbreak level=error") }},
+		{"string", `k="\"quoted\\"`, func(e Encoder) { e.AddString("k", `"quoted\`) }},
+		{"string", `k_x=v`, func(e Encoder) { e.AddString("k x", "v") }},
+		{"string", `k_x__=v`, func(e Encoder) { e.AddString(`k=x"`+"\n", "v") }},
+		{"string", `_=v`, func(e Encoder) { e.AddString("", "v") }},
+		{"bool", `k=true`, func(e Encoder) { e.AddBool("k", true) }},
+		{"int", `k=42`, func(e Encoder) { e.AddInt("k", 42) }},
+		{"int64", fmt.Sprintf(`k=%d`, math.MinInt64), func(e Encoder) { e.AddInt64("k", math.MinInt64) }},
+		{"uint", `k=42`, func(e Encoder) { e.AddUint("k", 42) }},
+		{"uint64", fmt.Sprintf(`k=%d`, uint64(math.MaxUint64)), func(e Encoder) { e.AddUint64("k", math.MaxUint64) }},
+		{"uintptr", `k=42`, func(e Encoder) { e.AddUintptr("k", 42) }},
+		{"float64", `k=10000000000`, func(e Encoder) { e.AddFloat64("k", 1e10) }},
+		{"float64", `k=NaN`, func(e Encoder) { e.AddFloat64("k", math.NaN()) }},
+		{"float64", `k=+Inf`, func(e Encoder) { e.AddFloat64("k", math.Inf(1)) }},
+		{"float64", `k=-Inf`, func(e Encoder) { e.AddFloat64("k", math.Inf(-1)) }},
+		{"time", `k=1.5`, func(e Encoder) { e.AddTime("k", time.Unix(1, int64(500*time.Millisecond))) }},
+		{"duration", `k=1000000`, func(e Encoder) { e.AddDuration("k", time.Millisecond) }},
+		{"marshaler", `k.loggable=yes`, func(e Encoder) {
+			assert.NoError(t, e.AddMarshaler("k", loggable{true}), "Unexpected error calling MarshalLog.")
+		}},
+		{"marshaler", `k.name="jane doe" k.inner.loggable=yes k.ids.0=1 k.ids.1=2`, func(e Encoder) {
+			assert.NoError(t, e.AddMarshaler("k", nestedLoggable{}), "Unexpected error calling MarshalLog.")
+		}},
+		{"marshaler", "", func(e Encoder) {
+			assert.Error(t, e.AddMarshaler("k", loggable{false}), "Expected an error calling MarshalLog.")
+		}},
+		{"array", `k.0=1 k.1=2 k.2=3`, func(e Encoder) {
+			assert.NoError(t, e.AddArray("k", ints{1, 2, 3}), "Unexpected error adding an array.")
+		}},
+		{"array", `k.0="a b" k.1=true k.2=-1 k.3=1 k.4=2.5`, func(e Encoder) {
+			assert.NoError(t, e.AddArray("k", ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+				arr.AppendString("a b")
+				arr.AppendBool(true)
+				arr.AppendInt64(-1)
+				arr.AppendUint(1)
+				arr.AppendFloat64(2.5)
+				return nil
+			})), "Unexpected error adding an array.")
+		}},
+		{"array", `k.0.0=1 k.1.loggable=yes k.2=x`, func(e Encoder) {
+			assert.NoError(t, e.AddArray("k", ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+				arr.AppendArray(ints{1})
+				arr.AppendMarshaler(loggable{true})
+				arr.AppendString("x")
+				return nil
+			})), "Unexpected error adding an array.")
+		}},
+		{"array", "", func(e Encoder) {
+			assert.NoError(t, e.AddArray("k", ints{}), "Unexpected error adding an empty array.")
+		}},
+		{"arbitrary object", `k="{\"loggable\":\"yes\"}"`, func(e Encoder) {
+			assert.NoError(t, e.AddObject("k", map[string]string{"loggable": "yes"}), "Unexpected error JSON-serializing a map.")
+		}},
+		{"arbitrary object", `k=[1,2,3]`, func(e Encoder) {
+			assert.NoError(t, e.AddObject("k", []int{1, 2, 3}), "Unexpected error JSON-serializing a slice.")
+		}},
+		{"arbitrary object", "", func(e Encoder) {
+			assert.Error(t, e.AddObject("k", noJSON{}), "Unexpected success JSON-serializing a noJSON.")
+		}},
+	}
+
+	for _, tt := range tests {
+		assertLogfmtOutput(t, tt.desc, tt.expected, tt.f)
+	}
+}
+
+func TestLogfmtValueEncoders(t *testing.T) {
+	enc := newLogfmtEncoder(NoTime(), RFC3339TimeEncoder(), StringDurationEncoder(), SyslogLevelEncoder())
+	Time("t", epoch.UTC()).AddTo(enc)
+	Durations("d", []time.Duration{time.Second, time.Minute}).AddTo(enc)
+	assert.Equal(t, "t=1970-01-01T00:00:00Z d.0=1s d.1=1m0s", string(enc.bytes), "Unexpected fields with non-default value encoders.")
+
+	for _, e := range []Encoder{enc, enc.Clone()} {
+		buf := &bytes.Buffer{}
+		e.WriteEntry(buf, Entry{Level: ErrorLevel, Message: "hi", Time: epoch})
+		assert.Equal(t, "level=3 msg=hi t=1970-01-01T00:00:00Z d.0=1s d.1=1m0s\n", buf.String(), "Unexpected entry with non-default value encoders.")
+	}
+}
+
+func TestLogfmtEscaping(t *testing.T) {
+	cases := map[string]string{
+		`foo`:              `foo`,
+		`☃`:                `☃`,
+		"":                 `""`,
+		`"`:                `"\""`,
+		`\`:                `"\\"`,
+		"\t":               `"\t"`,
+		"\r\n":             `"\r\n"`,
+		"\x7f":             `"\u007f"`,
+		"a\u0085b":         `"a\u0085b"`,
+		"a\u2028b":         `"a\u2028b"`,
+		"a\u200bb":         `"a\u200bb"`,
+		"\U000e0001":       `"\udb40\udc01"`,
+		string(byte(0x07)): `"\u0007"`,
+		"foo\u00a0bar":     `"foo\u00a0bar"`,
+		"\xed\xa0\x80":     `"\ufffd\ufffd\ufffd"`,
+	}
+	enc := newLogfmtEncoder()
+	for input, output := range cases {
+		enc.truncate()
+		enc.safeAddString(input)
+		assert.Equal(t, output, string(enc.bytes), "Unexpected escaping for %q.", input)
+	}
+}
+
+func TestLogfmtWriteEntry(t *testing.T) {
+	entry := Entry{Level: InfoLevel, Message: "hello world", Time: epoch, LoggerName: "foo.bar"}
+	enc := NewLogfmtEncoder()
+
+	assert.Equal(t, errNilSink, enc.WriteEntry(nil, entry), "Expected an error writing to a nil sink.")
+
+	sink := &testBuffer{}
+	assert.NoError(t, enc.WriteEntry(sink, entry), "WriteEntry returned an unexpected error.")
+	assert.Equal(t, `level=info ts=1970-01-01T00:00:00Z logger=foo.bar msg="hello world"`, sink.Stripped())
+
+	// We should be able to re-use the encoder, preserving the accumulated
+	// fields.
+	enc.AddString("foo", "bar")
+	for _, e := range []Encoder{enc, enc.Clone()} {
+		sink.Reset()
+		assert.NoError(t, e.WriteEntry(sink, Entry{Level: WarnLevel, Message: "hi", Time: epoch}), "WriteEntry returned an unexpected error.")
+		assert.Equal(t, `level=warn ts=1970-01-01T00:00:00Z msg=hi foo=bar`, sink.Stripped())
+	}
+}
+
+func TestLogfmtWriteEntryFailure(t *testing.T) {
+	withLogfmtEncoder(func(enc *logfmtEncoder) {
+		tests := []struct {
+			sink io.Writer
+			msg  string
+		}{
+			{spywrite.FailWriter{}, "Expected an error when writing to sink fails."},
+			{spywrite.ShortWriter{}, "Expected an error on partial writes to sink."},
+		}
+		for _, tt := range tests {
+			err := enc.WriteEntry(tt.sink, Entry{Message: "hello", Level: InfoLevel, Time: time.Unix(0, 0)})
+			assert.Error(t, err, tt.msg)
+		}
+	})
+}
+
+func TestLogfmtOptions(t *testing.T) {
+	root := NewLogfmtEncoder(
+		MessageKey("the-message"),
+		LevelString("the-level"),
+		EpochFormatter("the-timestamp"),
+		NameKey("the-name"),
+	)
+
+	for _, enc := range []Encoder{root, root.Clone()} {
+		buf := &bytes.Buffer{}
+		enc.WriteEntry(buf, Entry{Message: "fake msg", Level: DebugLevel, Time: epoch, LoggerName: "foo"})
+		assert.Equal(
+			t,
+			`the-level=debug the-timestamp=0 the-name=foo the-message="fake msg"`+"\n",
+			buf.String(),
+			"Unexpected log output with non-default encoder options.",
+		)
+	}
+
+	buf := &bytes.Buffer{}
+	NewLogfmtEncoder(NoTime()).WriteEntry(buf, Entry{Message: "msg", Level: InfoLevel, Time: epoch})
+	assert.Equal(t, "level=info msg=msg\n", buf.String(), "Expected NoTime to omit timestamps.")
+}
+
+func TestLogfmtLogger(t *testing.T) {
+	buf := &testBuffer{}
+	logger := New(NewLogfmtEncoder(NoTime()), Output(buf), Fields(String("service", "api")))
+	logger.Info("user logged in", String("user", "jane doe\nlevel=error"), Object("meta", map[string]int{"n": 1}))
+	assert.Equal(
+		t,
+		`level=info msg="user logged in" service=api user="jane doe\nlevel=error" meta="{\"n\":1}"`,
+		buf.Stripped(),
+		"Expected values to be escaped so that they can't inject fields or lines.",
+	)
+}
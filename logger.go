@@ -34,6 +34,11 @@ type Logger interface {
 	// Create a child logger, and optionally add some context to that logger.
 	With(...Field) Logger
 
+	// Named creates a child logger whose name is the parent's name with the
+	// supplied segment appended, joined by a period (e.g., "rpc.client").
+	// Encoders typically add the name to each entry.
+	Named(string) Logger
+
 	// Check returns a CheckedMessage if logging a message at the specified level
 	// is enabled. It's a completely optional optimization; in high-performance
 	// applications, Check can help avoid allocating a slice to hold fields.
@@ -56,9 +61,34 @@ type Logger interface {
 	DPanic(string, ...Field)
 	Panic(string, ...Field)
 	Fatal(string, ...Field)
+
+	// Sync flushes any buffered log entries. Applications should take care to
+	// call Sync before exiting.
+	Sync() error
+}
+
+// IsDevelopment reports whether the supplied Logger is in development mode
+// (see the Development option), in which calls to DPanic panic after logging.
+//
+// Loggers that embed a Meta report its Development field. Wrappers can report
+// their own mode, typically that of the loggers they wrap, by implementing an
+// IsDevelopment() bool method. Loggers that do neither are assumed to be in
+// production mode.
+func IsDevelopment(log Logger) bool {
+	if d, ok := log.(developmentReporter); ok {
+		return d.IsDevelopment()
+	}
+	return false
 }
 
-type logger struct{ Meta }
+type developmentReporter interface {
+	IsDevelopment() bool
+}
+
+type logger struct {
+	Meta
+	ctx *loggerContext
+}
 
 // New constructs a logger that uses the provided encoder. By default, the
 // logger will write Info logs or higher to standard out. Any errors during logging
@@ -77,9 +107,30 @@ func (log *logger) With(fields ...Field) Logger {
 		Meta: log.Meta.Clone(),
 	}
 	addFields(clone.Encoder, fields)
+	if len(log.Hooks) > 0 {
+		clone.ctx = &loggerContext{parent: log.ctx, fields: copyFields(fields)}
+	}
 	return clone
 }
 
+// withContext is With for Tee'd loggers, which share both the supplied fields
+// and, if they share an encoder, the serialized context. It fills in the
+// supplied logger, so that a Tee can allocate its children together.
+func (log *logger) withContext(clone *logger, ctx *contextEncoder) {
+	clone.Meta = log.Meta
+	clone.Encoder = ctx.encode(log.Encoder)
+	if len(log.Hooks) > 0 {
+		clone.ctx = &loggerContext{parent: log.ctx, fields: ctx.retain()}
+	}
+}
+
+func (log *logger) Named(name string) Logger {
+	return &logger{
+		Meta: log.Meta.Named(name),
+		ctx:  log.ctx,
+	}
+}
+
 func (log *logger) Check(lvl Level, msg string) *CheckedMessage {
 	return log.Meta.Check(log, lvl, msg)
 }
@@ -121,13 +172,17 @@ func (log *logger) Fatal(msg string, fields ...Field) {
 	_exit(1)
 }
 
+func (log *logger) Sync() error {
+	return log.Output.Sync()
+}
+
 func (log *logger) log(lvl Level, msg string, fields []Field) {
 	if !log.Meta.Enabled(lvl) {
 		return
 	}
 
 	t := time.Now().UTC()
-	if err := log.Encode(log.Output, t, lvl, msg, fields); err != nil {
+	if err := log.encode(log.ctx, log.Output, t, lvl, msg, fields); err != nil {
 		log.InternalError("encoder", err)
 	}
 
@@ -208,3 +208,55 @@ func Benchmark100Fields(b *testing.B) {
 		logger.With(first...).Info("Child loggers with lots of context.", second...)
 	}
 }
+
+func withDeepContext(log zap.Logger) zap.Logger {
+	for i := 0; i < 10; i++ {
+		log = log.With(zap.Int("depth", i), zap.String("layer", "middleware"))
+	}
+	return log
+}
+
+func BenchmarkDeepWith(b *testing.B) {
+	b.ReportAllocs()
+	withBenchedLogger(b, func(log zap.Logger) {
+		withDeepContext(log).Info("Ten layers of context.")
+	})
+}
+
+func BenchmarkDeepWithDisabled(b *testing.B) {
+	logger := zap.New(zap.NewJSONEncoder(), zap.InfoLevel, zap.DiscardOutput)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			withDeepContext(logger).Debug("Ten layers of context, at a disabled level.")
+		}
+	})
+}
+
+func benchmarkTeeDeepWith(b *testing.B, logger zap.Logger) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			withDeepContext(logger).Info("Ten layers of context, written twice.")
+		}
+	})
+}
+
+func BenchmarkTeeDeepWith(b *testing.B) {
+	benchmarkTeeDeepWith(b, zap.Tee(
+		zap.New(zap.NewJSONEncoder(), zap.DiscardOutput),
+		zap.New(zap.NewTextEncoder(), zap.DiscardOutput),
+	))
+}
+
+// BenchmarkTeeDeepWithSharedEncoder writes the same format to two outputs, so
+// the sub-loggers can share their serialized context.
+func BenchmarkTeeDeepWithSharedEncoder(b *testing.B) {
+	enc := zap.NewJSONEncoder()
+	benchmarkTeeDeepWith(b, zap.Tee(
+		zap.New(enc, zap.DiscardOutput),
+		zap.New(enc, zap.DiscardOutput),
+	))
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import "reflect"
+
+// A loggerContext is the context a logger accumulates with With, kept as the
+// original Fields so that hooks can inspect it with Entry.Context. The
+// context is also serialized in the logger's encoder, which is all that
+// writing an entry needs, so loggers without hooks don't keep the Fields at
+// all: retaining them would cost a copy on every call to With.
+//
+// Each call to With adds a link to the chain, and links are immutable once
+// created, so children share their ancestors' fields rather than copying
+// them.
+type loggerContext struct {
+	parent *loggerContext
+	fields []Field
+}
+
+// Fields returns a copy of the context's fields, oldest first.
+func (c *loggerContext) Fields() []Field {
+	n := 0
+	for l := c; l != nil; l = l.parent {
+		n += len(l.fields)
+	}
+	if n == 0 {
+		return nil
+	}
+	fields := make([]Field, n)
+	for l := c; l != nil; l = l.parent {
+		n -= len(l.fields)
+		copy(fields[n:], l.fields)
+	}
+	return fields
+}
+
+// _maxSharedEncoders is the number of distinct encoders a contextEncoder
+// remembers. Tees rarely have more sub-loggers than this, and a fixed array
+// lets the contextEncoder live on the stack.
+const _maxSharedEncoders = 4
+
+// A contextEncoder adds the fields passed to a single call to With to each
+// sub-logger of a Tee. It remembers the serialized result for each encoder
+// it's seen, so when sub-loggers share an encoder (e.g., they write the same
+// format to different outputs), the context is serialized once and the
+// children share the result, too.
+type contextEncoder struct {
+	fields   []Field
+	retained bool
+	seen     [_maxSharedEncoders]encodedContext
+	nSeen    int
+}
+
+type encodedContext struct {
+	base, encoded Encoder
+}
+
+func (c *contextEncoder) encode(base Encoder) Encoder {
+	// Encoders are usually pointers, but comparing other types may panic.
+	cacheable := reflect.TypeOf(base).Comparable()
+	if cacheable {
+		for _, s := range c.seen[:c.nSeen] {
+			if s.base == base {
+				return s.encoded
+			}
+		}
+	}
+	enc := base.Clone()
+	addFields(enc, c.fields)
+	if cacheable && c.nSeen < len(c.seen) {
+		c.seen[c.nSeen] = encodedContext{base, enc}
+		c.nSeen++
+	}
+	return enc
+}
+
+// retain returns the fields for sub-loggers that keep them, copying them on
+// first use, since the caller may re-use the slice passed to With.
+func (c *contextEncoder) retain() []Field {
+	if !c.retained {
+		c.fields = copyFields(c.fields)
+		c.retained = true
+	}
+	return c.fields
+}
+
+// copyFields copies the fields passed to With, since the caller may re-use
+// the slice.
+func copyFields(fields []Field) []Field {
+	if len(fields) == 0 {
+		return nil
+	}
+	return append(make([]Field, 0, len(fields)), fields...)
+}
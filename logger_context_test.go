@@ -0,0 +1,160 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerDeepWith(t *testing.T) {
+	withJSONLogger(t, opts(Fields(Int("root", 0))), func(logger Logger, buf *testBuffer) {
+		first := logger.With(Int("one", 1))
+		second := first.With(Int("two", 2)).Named("child")
+		third := second.With().With(Int("three", 3))
+
+		third.Info("")
+		second.Info("")
+		first.Info("")
+		third.With(Int("four", 4)).Info("")
+		assert.Equal(t, []string{
+			`{"level":"info","logger":"child","msg":"","root":0,"one":1,"two":2,"three":3}`,
+			`{"level":"info","logger":"child","msg":"","root":0,"one":1,"two":2}`,
+			`{"level":"info","msg":"","root":0,"one":1}`,
+			`{"level":"info","logger":"child","msg":"","root":0,"one":1,"two":2,"three":3,"four":4}`,
+		}, buf.Lines(), "Unexpected output from a chain of child loggers.")
+	})
+}
+
+func TestLoggerWithCopiesFields(t *testing.T) {
+	withJSONLogger(t, nil, func(logger Logger, buf *testBuffer) {
+		fields := []Field{Int("foo", 1)}
+		child := logger.With(fields...)
+		fields[0] = Int("foo", 2)
+		child.Info("")
+		assert.Equal(t, `{"level":"info","msg":"","foo":1}`, buf.Stripped(), "Expected With to copy the supplied fields.")
+	})
+}
+
+type mutableStringer struct{ s string }
+
+func (m *mutableStringer) String() string { return m.s }
+
+func TestLoggerWithSerializesEagerly(t *testing.T) {
+	withJSONLogger(t, nil, func(logger Logger, buf *testBuffer) {
+		val := &mutableStringer{"before"}
+		child := logger.With(Stringer("foo", val))
+		val.s = "after"
+		child.Info("")
+		assert.Equal(t, `{"level":"info","msg":"","foo":"before"}`, buf.Stripped(), "Expected With to serialize context immediately.")
+	})
+}
+
+func TestEntryContext(t *testing.T) {
+	var context []Field
+	hook := Hook(func(e *Entry) error {
+		context = e.Context()
+		return nil
+	})
+	withJSONLogger(t, opts(hook), func(logger Logger, _ *testBuffer) {
+		logger.Info("")
+		assert.Nil(t, context, "Expected no context on the root logger.")
+
+		logger.With(Int("foo", 1)).Named("child").With(String("bar", "baz")).Info("", Bool("site", true))
+		assert.Equal(t, []Field{Int("foo", 1), String("bar", "baz")}, context, "Unexpected context passed to hooks.")
+
+		context[0] = Int("foo", 2)
+		logger.With(Int("foo", 1)).Info("")
+		assert.Equal(t, []Field{Int("foo", 1)}, context, "Expected Entry.Context to return a copy.")
+	})
+}
+
+func TestLoggerContextRequiresHooks(t *testing.T) {
+	child := New(NewTextEncoder()).With(Int("foo", 1))
+	assert.Nil(t, child.(*logger).ctx, "Expected loggers without hooks not to keep their context as Fields.")
+	tee := Tee(New(NewTextEncoder()), New(NewTextEncoder())).With(Int("foo", 1)).(multiLogger)
+	for _, log := range tee {
+		assert.Nil(t, log.(*logger).ctx, "Expected Tee'd loggers without hooks not to keep their context as Fields.")
+	}
+}
+
+func TestTeeSharesContext(t *testing.T) {
+	// Loggers only keep their context as Fields if they have hooks.
+	hook := Hook(func(*Entry) error { return nil })
+	json := New(newJSONEncoder(NoTime()), Output(&testBuffer{}), hook)
+	text := New(NewTextEncoder(), Output(&testBuffer{}), hook)
+	tee := Tee(json, Tee(text, json)).With(Int("foo", 1)).(multiLogger)
+
+	first := tee[0].(*logger).ctx.fields
+	nested := tee[1].(multiLogger)
+	require.Len(t, first, 1, "Unexpected context on the JSON logger.")
+	for _, log := range nested {
+		assert.True(t, &first[0] == &log.(*logger).ctx.fields[0], "Expected Tee'd loggers to share their context.")
+	}
+}
+
+func TestTeeSharesSerializedContext(t *testing.T) {
+	enc := newJSONEncoder(NoTime())
+	first, second := &testBuffer{}, &testBuffer{}
+	text := New(NewTextEncoder(), Output(&testBuffer{}))
+	tee := Tee(New(enc, Output(first)), Tee(New(enc, Output(second)), text)).With(Int("foo", 1)).(multiLogger)
+
+	shared := tee[0].(*logger).Encoder
+	nested := tee[1].(multiLogger)
+	assert.True(t, shared == nested[0].(*logger).Encoder, "Expected loggers with the same encoder to share serialized context.")
+	assert.False(t, shared == nested[1].(*logger).Encoder, "Expected loggers with different encoders to serialize context separately.")
+
+	tee.Info("")
+	assert.Equal(t, `{"level":"info","msg":"","foo":1}`, first.Stripped(), "Unexpected output from the first JSON logger.")
+	assert.Equal(t, `{"level":"info","msg":"","foo":1}`, second.Stripped(), "Unexpected output from the second JSON logger.")
+}
+
+func TestLoggerContextConcurrentEncoding(t *testing.T) {
+	withJSONLogger(t, nil, func(logger Logger, buf *testBuffer) {
+		parent := logger.With(Int("foo", 1))
+		children := []Logger{parent.With(Int("bar", 2)), parent.With(Int("baz", 3))}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			for _, log := range append(children, parent) {
+				wg.Add(1)
+				go func(log Logger) {
+					defer wg.Done()
+					log.Info("")
+				}(log)
+			}
+		}
+		wg.Wait()
+
+		counts := make(map[string]int)
+		for _, line := range buf.Lines() {
+			counts[line]++
+		}
+		assert.Equal(t, map[string]int{
+			`{"level":"info","msg":"","foo":1}`:         10,
+			`{"level":"info","msg":"","foo":1,"bar":2}`: 10,
+			`{"level":"info","msg":"","foo":1,"baz":3}`: 10,
+		}, counts, "Unexpected output when serializing context concurrently.")
+	})
+}
@@ -21,6 +21,7 @@
 package zap
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -177,6 +178,22 @@ func TestJSONLoggerWith(t *testing.T) {
 	})
 }
 
+func TestJSONLoggerNamed(t *testing.T) {
+	withJSONLogger(t, nil, func(logger Logger, buf *testBuffer) {
+		rpc := logger.Named("rpc")
+		rpc.Info("")
+		rpc.Named("client").With(Int("foo", 42)).Named("retry").Info("")
+		rpc.Named("").Info("")
+		logger.Info("")
+		assert.Equal(t, []string{
+			`{"level":"info","logger":"rpc","msg":""}`,
+			`{"level":"info","logger":"rpc.client.retry","msg":"","foo":42}`,
+			`{"level":"info","logger":"rpc","msg":""}`,
+			`{"level":"info","msg":""}`,
+		}, buf.Lines(), "Unexpected output from named loggers.")
+	})
+}
+
 func TestJSONLoggerLog(t *testing.T) {
 	withJSONLogger(t, nil, func(logger Logger, buf *testBuffer) {
 		logger.Log(DebugLevel, "foo")
@@ -345,6 +362,14 @@ func TestJSONLoggerDPanic(t *testing.T) {
 	})
 }
 
+func TestIsDevelopment(t *testing.T) {
+	assert.False(t, IsDevelopment(New(NullEncoder())), "Expected loggers to default to production.")
+	assert.True(t, IsDevelopment(New(NullEncoder(), Development())), "Expected Development option to be reported.")
+
+	type opaque struct{ Logger }
+	assert.False(t, IsDevelopment(opaque{}), "Expected loggers without a Meta to be in production.")
+}
+
 func TestJSONLoggerNoOpsDisabledLevels(t *testing.T) {
 	withJSONLogger(t, opts(WarnLevel), func(logger Logger, buf *testBuffer) {
 		logger.Info("silence!")
@@ -379,6 +404,17 @@ func TestJSONLoggerSyncsOutput(t *testing.T) {
 	assert.True(t, sink.Called(), "Expected logging at panic level to Sync underlying WriteSyncer.")
 }
 
+func TestJSONLoggerSync(t *testing.T) {
+	sink := &spywrite.WriteSyncer{Writer: ioutil.Discard}
+	logger := New(newJSONEncoder(), DebugLevel, Output(sink))
+
+	assert.NoError(t, logger.Sync(), "Unexpected error syncing logger.")
+	assert.True(t, sink.Called(), "Expected Sync to sync the underlying WriteSyncer.")
+
+	sink.SetError(errors.New("fail"))
+	assert.Error(t, logger.With(Int("foo", 42)).Sync(), "Expected Sync to return errors from the output.")
+}
+
 func TestLoggerConcurrent(t *testing.T) {
 	withJSONLogger(t, nil, func(logger Logger, buf *testBuffer) {
 		child := logger.With(String("foo", "bar"))
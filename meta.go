@@ -43,6 +43,8 @@ type Meta struct {
 	LevelEnabler
 
 	Development bool
+	Name        string
+	CallerSkip  int
 	Encoder     Encoder
 	Hooks       []Hook
 	Output      WriteSyncer
@@ -72,6 +74,35 @@ func (m Meta) Clone() Meta {
 	return m
 }
 
+// Named creates a copy of the meta struct and appends the supplied segment to
+// its Name. Segments are joined with periods, so naming a child of the "rpc"
+// logger "client" yields "rpc.client". Empty segments are ignored.
+//
+// Since naming a logger doesn't add any context, the copy shares the
+// original's encoder. If the Meta's LevelEnabler depends on the logger's name
+// (e.g., it's backed by a LevelRegistry), the copy's enabler uses the new
+// name.
+func (m Meta) Named(name string) Meta {
+	switch {
+	case name == "":
+		return m
+	case m.Name == "":
+		m.Name = name
+	default:
+		m.Name = m.Name + "." + name
+	}
+	if enab, ok := m.LevelEnabler.(nameScopedEnabler); ok {
+		m.LevelEnabler = enab.scoped(m.Name)
+	}
+	return m
+}
+
+// IsDevelopment reports whether the Development flag is set. It lets
+// IsDevelopment work with any Logger that embeds a Meta.
+func (m Meta) IsDevelopment() bool {
+	return m.Development
+}
+
 // Check returns a CheckedMessage logging the given message is Enabled, nil
 // otherwise.
 func (m Meta) Check(log Logger, lvl Level, msg string) *CheckedMessage {
@@ -80,6 +111,11 @@ func (m Meta) Check(log Logger, lvl Level, msg string) *CheckedMessage {
 		// Panic and Fatal should always cause a panic/exit, even if the level
 		// is disabled.
 		break
+	case DPanicLevel:
+		// Likewise for DPanic in development.
+		if !m.Development && !m.Enabled(lvl) {
+			return nil
+		}
 	default:
 		if !m.Enabled(lvl) {
 			return nil
@@ -99,23 +135,30 @@ func (m Meta) InternalError(cause string, err error) {
 // Encode runs any Hook functions and then writes an encoded log entry to the
 // given io.Writer, returning any error.
 func (m Meta) Encode(w io.Writer, t time.Time, lvl Level, msg string, fields []Field) error {
+	return m.encode(nil, w, t, lvl, msg, fields)
+}
+
+// encode is Encode for loggers that also keep their context as Fields, which
+// hooks can retrieve with Entry.Context.
+func (m Meta) encode(ctx *loggerContext, w io.Writer, t time.Time, lvl Level, msg string, fields []Field) error {
 	enc := m.Encoder.Clone()
 	addFields(enc, fields)
-	if len(m.Hooks) >= 0 {
-		entry := _entryPool.Get().(*Entry)
-		entry.Level = lvl
-		entry.Message = msg
-		entry.Time = t
-		entry.enc = enc
-		for _, hook := range m.Hooks {
-			if err := hook(entry); err != nil {
-				m.InternalError("hook", err)
-			}
+	entry := _entryPool.Get().(*Entry)
+	entry.Level = lvl
+	entry.Message = msg
+	entry.Time = t
+	entry.LoggerName = m.Name
+	entry.enc = enc
+	entry.ctx = ctx
+	entry.callerSkip = m.CallerSkip
+	for _, hook := range m.Hooks {
+		if err := hook(entry); err != nil {
+			m.InternalError("hook", err)
 		}
-		msg, enc = entry.Message, entry.enc
-		_entryPool.Put(entry)
 	}
-	err := enc.WriteEntry(w, msg, lvl, t)
+	final := *entry
+	_entryPool.Put(entry)
+	err := enc.WriteEntry(w, final)
 	enc.Free()
 	return err
 }
@@ -0,0 +1,397 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/uber-go/atomic"
+)
+
+const (
+	_defaultDialTimeout  = 5 * time.Second
+	_defaultWriteTimeout = 5 * time.Second
+	_defaultMinBackoff   = 100 * time.Millisecond
+	_defaultMaxBackoff   = 30 * time.Second
+	_defaultBufferLimit  = 1 << 20 // 1 MiB
+)
+
+var errNetworkClosed = errors.New("can't write to a closed NetworkWriteSyncer")
+
+// A NetworkOption configures a NetworkWriteSyncer.
+type NetworkOption interface {
+	apply(*NetworkWriteSyncer)
+}
+
+type networkOptionFunc func(*NetworkWriteSyncer)
+
+func (f networkOptionFunc) apply(s *NetworkWriteSyncer) {
+	f(s)
+}
+
+// DialTimeout limits how long each connection attempt may take. The default
+// is five seconds.
+func DialTimeout(timeout time.Duration) NetworkOption {
+	return networkOptionFunc(func(s *NetworkWriteSyncer) {
+		s.dialTimeout = timeout
+	})
+}
+
+// WriteTimeout limits how long each write to the collector may block. A
+// collector that stalls for longer is treated as disconnected, so that a slow
+// collector can't block logging. The default is five seconds.
+func WriteTimeout(timeout time.Duration) NetworkOption {
+	return networkOptionFunc(func(s *NetworkWriteSyncer) {
+		s.writeTimeout = timeout
+	})
+}
+
+// ReconnectBackoff sets the delay before the first reconnection attempt and
+// the limit on the delay between later attempts, which doubles after every
+// failure. The defaults are 100 milliseconds and 30 seconds.
+func ReconnectBackoff(initial, max time.Duration) NetworkOption {
+	return networkOptionFunc(func(s *NetworkWriteSyncer) {
+		s.minBackoff = initial
+		s.maxBackoff = max
+	})
+}
+
+// BufferLimit caps the number of bytes held in memory while disconnected.
+// Writes that don't fit are spilled to a file (see SpillFile) or, if there's
+// no spill file, dropped. The default is one mebibyte.
+func BufferLimit(bytes int) NetworkOption {
+	return networkOptionFunc(func(s *NetworkWriteSyncer) {
+		s.bufferLimit = bytes
+	})
+}
+
+// SpillFile appends writes that don't fit in the in-memory buffer to the file
+// at the given path, which is created if necessary. Spilled writes aren't
+// replayed after reconnecting, but they aren't lost either.
+func SpillFile(path string) NetworkOption {
+	return networkOptionFunc(func(s *NetworkWriteSyncer) {
+		s.spillPath = path
+	})
+}
+
+// StateOutput sets the destination for reports about connection state
+// changes (disconnecting, reconnecting, and dropping or spilling writes).
+// It's usually the logger's ErrorOutput. The default is standard error.
+func StateOutput(ws WriteSyncer) NetworkOption {
+	return networkOptionFunc(func(s *NetworkWriteSyncer) {
+		s.stateOut = ws
+	})
+}
+
+// A NetworkWriteSyncer is a WriteSyncer that streams logs to a collector over
+// TCP or a unix socket. When the connection drops, it buffers writes in memory
+// and reconnects in the background, backing off exponentially between
+// attempts; once reconnected, it sends the buffered writes before any new
+// ones. Rather than reporting every failed write, it reports each change in
+// connection state once (see StateOutput).
+//
+// Writes never fail because the collector is unavailable: they're sent,
+// buffered, spilled to a file, or dropped. Use Dropped to monitor the last
+// case. NetworkWriteSyncers are safe for concurrent use. Call Close to stop
+// reconnecting and release the connection.
+type NetworkWriteSyncer struct {
+	network      string
+	addr         string
+	dialTimeout  time.Duration
+	writeTimeout time.Duration
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	bufferLimit  int
+	spillPath    string
+	stateOut     WriteSyncer
+	dropped      *atomic.Uint64
+
+	sync.Mutex
+	conn          net.Conn
+	buffered      [][]byte
+	bufferedBytes int
+	overflowed    bool // whether we've reported overflowing the buffer
+	spill         *os.File
+	reconnecting  bool
+	closed        bool
+	done          chan struct{}
+	wg            sync.WaitGroup
+}
+
+// NewNetworkWriteSyncer creates a NetworkWriteSyncer for the given address.
+// The network must be "tcp", "tcp4", "tcp6", or "unix"; see net.Dial for the
+// address format. If the collector isn't reachable yet, writes are buffered
+// until it is.
+func NewNetworkWriteSyncer(network, addr string, options ...NetworkOption) (*NetworkWriteSyncer, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6", "unix":
+	default:
+		return nil, fmt.Errorf("unsupported network %q", network)
+	}
+
+	s := &NetworkWriteSyncer{
+		network:      network,
+		addr:         addr,
+		dialTimeout:  _defaultDialTimeout,
+		writeTimeout: _defaultWriteTimeout,
+		minBackoff:   _defaultMinBackoff,
+		maxBackoff:   _defaultMaxBackoff,
+		bufferLimit:  _defaultBufferLimit,
+		stateOut:     newLockedWriteSyncer(os.Stderr),
+		dropped:      atomic.NewUint64(0),
+		done:         make(chan struct{}),
+	}
+	for _, opt := range options {
+		opt.apply(s)
+	}
+
+	conn, err := net.DialTimeout(network, addr, s.dialTimeout)
+	s.Lock()
+	defer s.Unlock()
+	if err != nil {
+		s.report("can't connect, buffering writes: %v", err)
+		s.startReconnecting()
+	} else {
+		s.conn = conn
+	}
+	return s, nil
+}
+
+// Write sends the supplied bytes to the collector, or buffers them if it's
+// unreachable.
+func (s *NetworkWriteSyncer) Write(bs []byte) (int, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return 0, errNetworkClosed
+	}
+	if s.conn != nil {
+		_, err := s.write(s.conn, bs)
+		if err == nil {
+			return len(bs), nil
+		}
+		// Buffer the whole write, even if part of it made it out: the
+		// collector frames entries by line, so resending only the tail on a
+		// new connection would deliver a corrupt fragment.
+		s.conn.Close()
+		s.conn = nil
+		s.report("disconnected, buffering writes: %v", err)
+		s.startReconnecting()
+	}
+	s.buffer(bs)
+	return len(bs), nil
+}
+
+// Sync syncs the spill file, if any. Writes to the network aren't buffered by
+// the NetworkWriteSyncer while it's connected, so there's nothing else to
+// flush. Sync doesn't try to send writes buffered while disconnected, and it
+// makes no promise that they'll ever be delivered: they're sent by the
+// background reconnection, if it succeeds before Close.
+func (s *NetworkWriteSyncer) Sync() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.spill == nil {
+		return nil
+	}
+	return s.spill.Sync()
+}
+
+// Dropped returns the number of writes discarded because the collector was
+// unreachable and the buffer was full.
+func (s *NetworkWriteSyncer) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close stops reconnecting and closes the connection and spill file. Writes
+// that are still buffered are spilled if possible and dropped otherwise.
+// Subsequent writes return an error.
+func (s *NetworkWriteSyncer) Close() error {
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	s.Unlock()
+
+	// Wait for any reconnection attempt to notice that we're closed.
+	s.wg.Wait()
+
+	s.Lock()
+	defer s.Unlock()
+
+	var errs multiError
+	if s.conn != nil {
+		if err := s.conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		s.conn = nil
+	}
+	if len(s.buffered) > 0 {
+		buffered := s.buffered
+		s.buffered, s.bufferedBytes = nil, 0
+		for _, bs := range buffered {
+			s.overflow(bs)
+		}
+	}
+	if s.spill != nil {
+		if err := s.spill.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		s.spill = nil
+	}
+	return errs.asError()
+}
+
+// buffer holds a copy of the supplied bytes until we reconnect, spilling or
+// dropping them if the buffer is full. It must be called with the lock held.
+func (s *NetworkWriteSyncer) buffer(bs []byte) {
+	if s.bufferedBytes+len(bs) > s.bufferLimit {
+		s.overflow(bs)
+		return
+	}
+	// Encoders re-use their buffers, so we must copy.
+	buf := make([]byte, len(bs))
+	copy(buf, bs)
+	s.buffered = append(s.buffered, buf)
+	s.bufferedBytes += len(buf)
+}
+
+// overflow spills or drops writes that can't be buffered. It must be called
+// with the lock held.
+func (s *NetworkWriteSyncer) overflow(bs []byte) {
+	if s.spillPath == "" {
+		s.dropped.Inc()
+		if !s.overflowed {
+			s.overflowed = true
+			s.report("buffer full, dropping writes")
+		}
+		return
+	}
+	if s.spill == nil {
+		f, err := os.OpenFile(s.spillPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			s.dropped.Inc()
+			s.report("can't open spill file, dropping writes: %v", err)
+			return
+		}
+		s.spill = f
+	}
+	if !s.overflowed {
+		s.overflowed = true
+		s.report("buffer full, spilling writes to %s", s.spillPath)
+	}
+	if _, err := s.spill.Write(bs); err != nil {
+		s.dropped.Inc()
+		s.report("can't write to spill file, dropping writes: %v", err)
+	}
+}
+
+// startReconnecting starts a background goroutine that reconnects to the
+// collector. It must be called with the lock held.
+func (s *NetworkWriteSyncer) startReconnecting() {
+	if s.reconnecting || s.closed {
+		return
+	}
+	s.reconnecting = true
+	s.wg.Add(1)
+	go s.reconnect()
+}
+
+func (s *NetworkWriteSyncer) reconnect() {
+	defer s.wg.Done()
+
+	backoff := s.minBackoff
+	for attempts := 1; ; attempts++ {
+		timer := time.NewTimer(backoff)
+		select {
+		case <-s.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if conn, err := net.DialTimeout(s.network, s.addr, s.dialTimeout); err == nil {
+			if s.resume(conn, attempts) {
+				return
+			}
+			conn.Close()
+		}
+
+		backoff *= 2
+		if backoff > s.maxBackoff {
+			backoff = s.maxBackoff
+		}
+	}
+}
+
+// resume sends any buffered writes over a fresh connection, then starts
+// using it for new writes. It reports whether the reconnection attempt is
+// over, either because it succeeded or because we're closed.
+func (s *NetworkWriteSyncer) resume(conn net.Conn, attempts int) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		conn.Close()
+		return true
+	}
+	sent := 0
+	for _, bs := range s.buffered {
+		if _, err := s.write(conn, bs); err != nil {
+			// Keep partially-sent writes whole, as in Write.
+			s.buffered = s.buffered[sent:]
+			return false
+		}
+		s.bufferedBytes -= len(bs)
+		sent++
+	}
+	s.buffered, s.bufferedBytes = nil, 0
+	s.conn = conn
+	s.reconnecting = false
+	s.overflowed = false
+	s.report("reconnected after %d attempt(s), sent %d buffered write(s)", attempts, sent)
+	return true
+}
+
+// write sends the supplied bytes over the connection, giving up once the
+// write timeout expires. It returns the number of bytes sent.
+func (s *NetworkWriteSyncer) write(conn net.Conn, bs []byte) (int, error) {
+	if err := conn.SetWriteDeadline(time.Now().Add(s.writeTimeout)); err != nil {
+		return 0, err
+	}
+	return conn.Write(bs)
+}
+
+// report writes a connection state change to the state output. It must be
+// called with the lock held.
+func (s *NetworkWriteSyncer) report(format string, args ...interface{}) {
+	fmt.Fprintf(s.stateOut, "%v network writer %s://%s: %s\n", time.Now().UTC(), s.network, s.addr, fmt.Sprintf(format, args...))
+	s.stateOut.Sync()
+}
@@ -0,0 +1,285 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func networkTestOptions(state *testBuffer, opts ...NetworkOption) []NetworkOption {
+	return append([]NetworkOption{
+		ReconnectBackoff(time.Millisecond, 10*time.Millisecond),
+		DialTimeout(time.Second),
+		StateOutput(newLockedWriteSyncer(state)),
+	}, opts...)
+}
+
+// acceptLines accepts a single connection and reads the given number of lines
+// from it.
+func acceptLines(t testing.TB, ln net.Listener, n int) <-chan []string {
+	lines := make(chan []string, 1)
+	go func() {
+		defer close(lines)
+		conn, err := ln.Accept()
+		if !assert.NoError(t, err, "Unexpected error accepting connection.") {
+			return
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		r := bufio.NewReader(conn)
+		var got []string
+		for i := 0; i < n; i++ {
+			line, err := r.ReadString('\n')
+			if !assert.NoError(t, err, "Unexpected error reading line.") {
+				return
+			}
+			got = append(got, line)
+		}
+		lines <- got
+	}()
+	return lines
+}
+
+func TestNetworkWriteSyncer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "Failed to listen on TCP.")
+	defer ln.Close()
+
+	state := &testBuffer{}
+	lines := acceptLines(t, ln, 2)
+	ws, err := NewNetworkWriteSyncer("tcp", ln.Addr().String(), networkTestOptions(state)...)
+	require.NoError(t, err, "Unexpected error creating NetworkWriteSyncer.")
+	defer ws.Close()
+
+	logger := New(newJSONEncoder(NoTime()), Output(ws))
+	logger.Info("one")
+	logger.Info("two")
+	assert.NoError(t, logger.Sync(), "Unexpected error syncing.")
+	assert.Equal(t, []string{
+		`{"level":"info","msg":"one"}` + "\n",
+		`{"level":"info","msg":"two"}` + "\n",
+	}, <-lines, "Unexpected lines received by collector.")
+	assert.Empty(t, state.String(), "Expected no state changes.")
+}
+
+func TestNetworkWriteSyncerReconnects(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "Failed to listen on TCP.")
+	defer ln.Close()
+
+	state := &testBuffer{}
+	first := acceptLines(t, ln, 1)
+	ws, err := NewNetworkWriteSyncer("tcp", ln.Addr().String(), networkTestOptions(state)...)
+	require.NoError(t, err, "Unexpected error creating NetworkWriteSyncer.")
+	defer ws.Close()
+
+	ws.Write([]byte("before\n"))
+	assert.Equal(t, []string{"before\n"}, <-first, "Unexpected lines on first connection.")
+
+	// Break the connection, so that the next write fails.
+	ws.Lock()
+	ws.conn.Close()
+	ws.Unlock()
+
+	second := acceptLines(t, ln, 2)
+	_, err = ws.Write([]byte("during\n"))
+	assert.NoError(t, err, "Expected writes to succeed while disconnected.")
+	assert.Equal(t, []string{"during\n", "after\n"}, waitForWrite(t, ws, second, "after\n"), "Expected buffered writes to be sent first after reconnecting.")
+
+	assert.Contains(t, state.String(), "disconnected, buffering writes", "Expected to report disconnecting.")
+	assert.Contains(t, state.String(), "reconnected after 1 attempt(s), sent 1 buffered write(s)", "Expected to report reconnecting.")
+	assert.Equal(t, uint64(0), ws.Dropped(), "Expected no dropped writes.")
+}
+
+// waitForWrite writes a line after the NetworkWriteSyncer reconnects, then
+// returns the lines received on the new connection.
+func waitForWrite(t testing.TB, ws *NetworkWriteSyncer, lines <-chan []string, line string) []string {
+	for i := 0; i < 500; i++ {
+		ws.Lock()
+		connected := ws.conn != nil
+		ws.Unlock()
+		if connected {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	ws.Write([]byte(line))
+	return <-lines
+}
+
+func TestNetworkWriteSyncerPartialWrite(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "Failed to listen on TCP.")
+	defer ln.Close()
+
+	state := &testBuffer{}
+	first := acceptLines(t, ln, 1)
+	ws, err := NewNetworkWriteSyncer("tcp", ln.Addr().String(), networkTestOptions(state)...)
+	require.NoError(t, err, "Unexpected error creating NetworkWriteSyncer.")
+	defer ws.Close()
+
+	ws.Write([]byte("before\n"))
+	assert.Equal(t, []string{"before\n"}, <-first, "Unexpected lines on first connection.")
+
+	// Swap in a connection that fails after sending the first few bytes.
+	ws.Lock()
+	ws.conn.Close()
+	ws.conn = &shortConn{max: 3}
+	ws.Unlock()
+
+	second := acceptLines(t, ln, 2)
+	n, err := ws.Write([]byte("during\n"))
+	assert.NoError(t, err, "Expected writes to succeed while disconnected.")
+	assert.Equal(t, 7, n, "Expected partial writes to report the full length.")
+	assert.Equal(t, []string{"during\n", "after\n"}, waitForWrite(t, ws, second, "after\n"), "Expected partially-sent writes to be resent whole.")
+}
+
+func TestNetworkWriteSyncerStalledCollector(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "Failed to listen on TCP.")
+	defer ln.Close()
+
+	// Accept connections but never read from them. Each one stays open until
+	// the next is accepted, so the writer stalls instead of seeing a reset.
+	accepted := make(chan struct{})
+	go func() {
+		defer close(accepted)
+		var prev net.Conn
+		for {
+			conn, err := ln.Accept()
+			if prev != nil {
+				prev.Close()
+			}
+			if err != nil {
+				return
+			}
+			prev = conn
+		}
+	}()
+	defer func() {
+		ln.Close()
+		<-accepted
+	}()
+
+	state := &testBuffer{}
+	ws, err := NewNetworkWriteSyncer("tcp", ln.Addr().String(), networkTestOptions(state, WriteTimeout(10*time.Millisecond))...)
+	require.NoError(t, err, "Unexpected error creating NetworkWriteSyncer.")
+	defer ws.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ws.Write(make([]byte, 8<<20))
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Write blocked on a stalled collector.")
+	}
+	assert.Contains(t, state.String(), "disconnected, buffering writes", "Expected a stalled collector to be treated as disconnected.")
+}
+
+func TestNetworkWriteSyncerInitiallyUnreachable(t *testing.T) {
+	withSocketDir(t, func(dir string) {
+		path := filepath.Join(dir, "collector")
+		state := &testBuffer{}
+		ws, err := NewNetworkWriteSyncer("unix", path, networkTestOptions(state)...)
+		require.NoError(t, err, "Expected to tolerate an unreachable collector.")
+		defer ws.Close()
+
+		ws.Write([]byte("buffered\n"))
+		assert.Contains(t, state.String(), "can't connect, buffering writes", "Expected to report the failed connection.")
+
+		ln, err := net.Listen("unix", path)
+		require.NoError(t, err, "Failed to listen on a unix socket.")
+		defer ln.Close()
+
+		lines := acceptLines(t, ln, 2)
+		assert.Equal(t, []string{"buffered\n", "live\n"}, waitForWrite(t, ws, lines, "live\n"), "Unexpected lines after connecting.")
+	})
+}
+
+func TestNetworkWriteSyncerDrops(t *testing.T) {
+	withSocketDir(t, func(dir string) {
+		state := &testBuffer{}
+		ws, err := NewNetworkWriteSyncer("unix", filepath.Join(dir, "collector"), networkTestOptions(state, BufferLimit(10))...)
+		require.NoError(t, err, "Unexpected error creating NetworkWriteSyncer.")
+
+		for i := 0; i < 3; i++ {
+			n, err := ws.Write([]byte("hello\n"))
+			assert.NoError(t, err, "Expected dropped writes to succeed.")
+			assert.Equal(t, 6, n, "Expected dropped writes to report the full length.")
+		}
+		assert.Equal(t, uint64(2), ws.Dropped(), "Expected writes beyond the buffer limit to be dropped.")
+		assert.Equal(t, 1, strings.Count(state.String(), "dropping writes"), "Expected to report dropping writes only once.")
+
+		assert.NoError(t, ws.Close(), "Unexpected error closing.")
+		assert.Equal(t, uint64(3), ws.Dropped(), "Expected buffered writes to be dropped on Close.")
+		assert.NoError(t, ws.Close(), "Expected closing twice to be a no-op.")
+		_, err = ws.Write([]byte("closed\n"))
+		assert.Equal(t, errNetworkClosed, err, "Expected an error writing after Close.")
+	})
+}
+
+func TestNetworkWriteSyncerSpills(t *testing.T) {
+	withSocketDir(t, func(dir string) {
+		state := &testBuffer{}
+		spill := filepath.Join(dir, "spill.log")
+		ws, err := NewNetworkWriteSyncer("unix", filepath.Join(dir, "collector"), networkTestOptions(state, BufferLimit(2), SpillFile(spill))...)
+		require.NoError(t, err, "Unexpected error creating NetworkWriteSyncer.")
+
+		for _, s := range []string{"a\n", "b\n", "c\n"} {
+			ws.Write([]byte(s))
+		}
+		assert.NoError(t, ws.Sync(), "Unexpected error syncing spill file.")
+		assert.NoError(t, ws.Close(), "Unexpected error closing.")
+
+		contents, err := ioutil.ReadFile(spill)
+		require.NoError(t, err, "Failed to read spill file.")
+		assert.Equal(t, "b\nc\na\n", string(contents), "Expected overflowing and then buffered writes to be spilled.")
+		assert.Equal(t, uint64(0), ws.Dropped(), "Expected no dropped writes.")
+		assert.Contains(t, state.String(), "spilling writes to "+spill, "Expected to report spilling.")
+	})
+}
+
+func TestNetworkWriteSyncerErrors(t *testing.T) {
+	_, err := NewNetworkWriteSyncer("udp", "127.0.0.1:0")
+	assert.Error(t, err, "Expected an error using an unsupported network.")
+
+	withSocketDir(t, func(dir string) {
+		state := &testBuffer{}
+		ws, err := NewNetworkWriteSyncer("unix", filepath.Join(dir, "collector"), networkTestOptions(state, BufferLimit(0), SpillFile(dir))...)
+		require.NoError(t, err, "Unexpected error creating NetworkWriteSyncer.")
+		ws.Write([]byte("foo\n"))
+		assert.Equal(t, uint64(1), ws.Dropped(), "Expected a write to be dropped if the spill file can't be opened.")
+		assert.Contains(t, state.String(), "can't open spill file", "Expected to report failing to open the spill file.")
+		assert.NoError(t, ws.Close(), "Unexpected error closing.")
+	})
+}
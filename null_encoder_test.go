@@ -54,6 +54,9 @@ func TestNullEncoderFields(t *testing.T) {
 		{"marshaler", func(e Encoder) {
 			assert.NoError(t, e.AddMarshaler("k", loggable{true}), "Unexpected error calling MarshalLog.")
 		}},
+		{"array", func(e Encoder) {
+			assert.NoError(t, e.AddArray("k", ints{1, 2}), "Unexpected error adding an array.")
+		}},
 		{"arbitrary object", func(e Encoder) {
 			assert.NoError(t, e.AddObject("k", map[string]string{"": ""}), "Unexpected error.")
 		}},
@@ -66,18 +69,13 @@ func TestNullWriteEntry(t *testing.T) {
 	entry := &Entry{Level: InfoLevel, Message: `ohai`, Time: time.Unix(0, 0)}
 	enc := NullEncoder()
 
-	assert.Equal(t, errNilSink, enc.WriteEntry(
-		nil,
-		entry.Message,
-		entry.Level,
-		entry.Time,
-	), "Expected an error writing to a nil sink.")
+	assert.Equal(t, errNilSink, enc.WriteEntry(nil, *entry), "Expected an error writing to a nil sink.")
 
 	// Messages should be thrown away.
 	sink := &bytes.Buffer{}
 	enc.AddString("foo", "bar")
 	assert.Len(t, sink.Bytes(), 0)
-	err := enc.WriteEntry(sink, entry.Message, entry.Level, entry.Time)
+	err := enc.WriteEntry(sink, *entry)
 	assert.NoError(t, err, "WriteEntry returned an unexpected error.")
 	assert.Len(
 		t,
@@ -0,0 +1,241 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package observer
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uber-go/zap"
+	"github.com/uber-go/zap/zwrap"
+)
+
+// A LoggedEntry is an encoding-agnostic representation of a log message.
+// Context holds the logger's accumulated context, any fields added at the log
+// site, and any fields added by hooks. Nested objects are decoded into
+// map[string]interface{} and arrays into []interface{}; other values keep
+// their Go types (e.g., zap.Int fields decode to an int).
+type LoggedEntry struct {
+	Level      zap.Level
+	Time       time.Time
+	LoggerName string
+	Message    string
+	// Caller is the value added by zap.AddCaller under its default key, if
+	// any. It's also available in Context.
+	Caller  string
+	Context map[string]interface{}
+}
+
+// ObservedLogs is a concurrency-safe, ordered collection of observed entries.
+type ObservedLogs struct {
+	mu   sync.RWMutex
+	logs []LoggedEntry
+}
+
+// New constructs a Logger that records each entry it writes to the returned
+// ObservedLogs. Options are handled just as they are by zap.New, except that
+// output-related options have no effect. By default, the logger is enabled at
+// InfoLevel.
+func New(options ...zap.Option) (zap.Logger, *ObservedLogs) {
+	logs := &ObservedLogs{}
+	opts := make([]zap.Option, 0, len(options)+1)
+	opts = append(opts, zap.DiscardOutput)
+	opts = append(opts, options...)
+	return zap.New(&observingEncoder{zwrap.KeyValueMap{}, logs}, opts...), logs
+}
+
+// Len returns the number of entries observed so far.
+func (o *ObservedLogs) Len() int {
+	o.mu.RLock()
+	n := len(o.logs)
+	o.mu.RUnlock()
+	return n
+}
+
+// All returns a copy of all the observed entries.
+func (o *ObservedLogs) All() []LoggedEntry {
+	o.mu.RLock()
+	ret := make([]LoggedEntry, len(o.logs))
+	copy(ret, o.logs)
+	o.mu.RUnlock()
+	return ret
+}
+
+// AllUntimed returns a copy of all the observed entries with their timestamps
+// zeroed, which makes them easy to compare to expectations.
+func (o *ObservedLogs) AllUntimed() []LoggedEntry {
+	ret := o.All()
+	for i := range ret {
+		ret[i].Time = time.Time{}
+	}
+	return ret
+}
+
+// TakeAll returns all the observed entries and resets the collection.
+func (o *ObservedLogs) TakeAll() []LoggedEntry {
+	o.mu.Lock()
+	ret := o.logs
+	o.logs = nil
+	o.mu.Unlock()
+	return ret
+}
+
+// Filter returns a copy of the observed entries for which the supplied
+// function returns true.
+func (o *ObservedLogs) Filter(keep func(LoggedEntry) bool) *ObservedLogs {
+	filtered := &ObservedLogs{}
+	for _, entry := range o.All() {
+		if keep(entry) {
+			filtered.logs = append(filtered.logs, entry)
+		}
+	}
+	return filtered
+}
+
+// FilterLevel filters the entries to those logged at the supplied level.
+func (o *ObservedLogs) FilterLevel(lvl zap.Level) *ObservedLogs {
+	return o.Filter(func(e LoggedEntry) bool {
+		return e.Level == lvl
+	})
+}
+
+// FilterMessage filters the entries to those with the supplied message.
+func (o *ObservedLogs) FilterMessage(msg string) *ObservedLogs {
+	return o.Filter(func(e LoggedEntry) bool {
+		return e.Message == msg
+	})
+}
+
+// FilterMessageSnippet filters the entries to those whose message contains
+// the supplied substring.
+func (o *ObservedLogs) FilterMessageSnippet(snippet string) *ObservedLogs {
+	return o.Filter(func(e LoggedEntry) bool {
+		return strings.Contains(e.Message, snippet)
+	})
+}
+
+// FilterField filters the entries to those whose context contains the
+// supplied field with an identical value.
+func (o *ObservedLogs) FilterField(field zap.Field) *ObservedLogs {
+	kv := zwrap.KeyValueMap{}
+	field.AddTo(kv)
+	want := decode(kv).(map[string]interface{})
+	return o.Filter(func(e LoggedEntry) bool {
+		for k, v := range want {
+			if got, ok := e.Context[k]; !ok || !reflect.DeepEqual(got, v) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// A TestingT is the subset of testing.TB used by the assertion helpers.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// AssertCount reports a test failure unless exactly n entries were observed.
+func (o *ObservedLogs) AssertCount(t TestingT, n int) bool {
+	if got := o.Len(); got != n {
+		t.Errorf("Expected %d logged entries, got %d: %+v", n, got, o.All())
+		return false
+	}
+	return true
+}
+
+// AssertEntries reports a test failure unless the observed entries, with
+// their timestamps zeroed, match the expected entries.
+func (o *ObservedLogs) AssertEntries(t TestingT, expected ...LoggedEntry) bool {
+	got := o.AllUntimed()
+	if len(got) == 0 && len(expected) == 0 {
+		return true
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected logged entries:\nexpected: %+v\n     got: %+v", expected, got)
+		return false
+	}
+	return true
+}
+
+func (o *ObservedLogs) add(entry LoggedEntry) {
+	o.mu.Lock()
+	o.logs = append(o.logs, entry)
+	o.mu.Unlock()
+}
+
+// observingEncoder collects context into a map and records entries instead of
+// serializing them.
+type observingEncoder struct {
+	zwrap.KeyValueMap
+
+	logs *ObservedLogs
+}
+
+func (enc *observingEncoder) Clone() zap.Encoder {
+	// Nested maps and slices are never modified once added, so a shallow copy
+	// is enough.
+	kv := make(zwrap.KeyValueMap, len(enc.KeyValueMap))
+	for k, v := range enc.KeyValueMap {
+		kv[k] = v
+	}
+	return &observingEncoder{kv, enc.logs}
+}
+
+func (enc *observingEncoder) Free() {}
+
+func (enc *observingEncoder) WriteEntry(_ io.Writer, ent zap.Entry) error {
+	ctx := decode(enc.KeyValueMap).(map[string]interface{})
+	caller, _ := ctx["caller"].(string)
+	enc.logs.add(LoggedEntry{
+		Level:      ent.Level,
+		Time:       ent.Time,
+		LoggerName: ent.LoggerName,
+		Message:    ent.Message,
+		Caller:     caller,
+		Context:    ctx,
+	})
+	return nil
+}
+
+// decode converts the nested zwrap.KeyValueMaps built by the encoder into
+// plain maps.
+func decode(v interface{}) interface{} {
+	switch v := v.(type) {
+	case zwrap.KeyValueMap:
+		m := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			m[k] = decode(elem)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, elem := range v {
+			s[i] = decode(elem)
+		}
+		return s
+	default:
+		return v
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package observer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uber-go/zap"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeT struct{ failures []string }
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func TestObserverRecordsEntries(t *testing.T) {
+	logger, logs := New(zap.DebugLevel, zap.Fields(zap.Int("foo", 42)))
+	logger.With(zap.String("bar", "baz")).Named("rpc").Debug("first",
+		zap.Nest("user", zap.String("name", "phil")),
+		zap.Ints("ints", []int{1, 2}),
+		zap.Error(errors.New("fail")),
+	)
+	logger.Warn("second")
+
+	all := logs.All()
+	require.Len(t, all, 2, "Expected two entries.")
+	for _, e := range all {
+		assert.WithinDuration(t, time.Now(), e.Time, time.Minute, "Expected entries to be timestamped.")
+	}
+
+	logs.AssertEntries(t,
+		LoggedEntry{
+			Level:      zap.DebugLevel,
+			LoggerName: "rpc",
+			Message:    "first",
+			Context: map[string]interface{}{
+				"foo":   42,
+				"bar":   "baz",
+				"user":  map[string]interface{}{"name": "phil"},
+				"ints":  []interface{}{1, 2},
+				"error": "fail",
+			},
+		},
+		LoggedEntry{
+			Level:   zap.WarnLevel,
+			Message: "second",
+			Context: map[string]interface{}{"foo": 42},
+		},
+	)
+}
+
+func TestObserverHonorsMeta(t *testing.T) {
+	logger, logs := New(zap.AddCaller(), zap.AddStacks(zap.ErrorLevel), zap.Development())
+	logger.Debug("disabled")
+	logger.Info("info")
+	assert.Panics(t, func() { logger.DPanic("dpanic") }, "Expected DPanic to panic in development.")
+
+	all := logs.All()
+	require.Len(t, all, 2, "Expected disabled levels to be ignored.")
+	assert.True(t, strings.HasPrefix(all[0].Caller, "observer_test.go:"), "Unexpected caller %q.", all[0].Caller)
+	assert.Equal(t, all[0].Caller, all[0].Context["caller"], "Expected caller in context too.")
+	assert.NotContains(t, all[0].Context, "stacktrace", "Expected no stacktrace below ErrorLevel.")
+	assert.Contains(t, all[1].Context, "stacktrace", "Expected AddStacks hook to add a stacktrace.")
+}
+
+func TestObserverFilters(t *testing.T) {
+	logger, logs := New(zap.DebugLevel)
+	logger.Debug("debug", zap.Int("n", 1))
+	logger.Info("info", zap.Int("n", 2), zap.String("s", "foo"))
+	logger.Info("more info", zap.Int("n", 3))
+	logger.Check(zap.ErrorLevel, "error").Write(zap.Int("n", 1))
+
+	assert.Equal(t, 2, logs.FilterLevel(zap.InfoLevel).Len(), "Unexpected count filtering by level.")
+	assert.Equal(t, 1, logs.FilterMessage("info").Len(), "Unexpected count filtering by message.")
+	assert.Equal(t, 2, logs.FilterMessageSnippet("info").Len(), "Unexpected count filtering by message snippet.")
+	assert.Equal(t, 2, logs.FilterField(zap.Int("n", 1)).Len(), "Unexpected count filtering by field.")
+	assert.Equal(t, 0, logs.FilterField(zap.String("n", "1")).Len(), "Expected field filters to compare types.")
+	assert.Equal(t, 1, logs.FilterField(zap.Int("n", 1)).FilterLevel(zap.ErrorLevel).Len(), "Expected filters to compose.")
+	assert.Equal(t, 4, logs.Len(), "Expected filtering not to modify the original logs.")
+
+	taken := logs.TakeAll()
+	assert.Len(t, taken, 4, "Expected TakeAll to return all entries.")
+	assert.Equal(t, 0, logs.Len(), "Expected TakeAll to reset the logs.")
+	assert.Empty(t, logs.All(), "Expected no entries after TakeAll.")
+}
+
+func TestObserverAssertions(t *testing.T) {
+	logger, logs := New()
+	logger.Info("foo")
+
+	ft := &fakeT{}
+	assert.True(t, logs.AssertCount(ft, 1), "Expected AssertCount to succeed.")
+	assert.True(t, logs.AssertEntries(ft, LoggedEntry{
+		Level:   zap.InfoLevel,
+		Message: "foo",
+		Context: map[string]interface{}{},
+	}), "Expected AssertEntries to succeed.")
+	assert.Empty(t, ft.failures, "Unexpected failures.")
+
+	assert.False(t, logs.AssertCount(ft, 2), "Expected AssertCount to fail.")
+	assert.False(t, logs.AssertEntries(ft), "Expected AssertEntries to fail.")
+	assert.Len(t, ft.failures, 2, "Expected a failure for each failed assertion.")
+
+	_, empty := New()
+	assert.True(t, empty.AssertEntries(ft), "Expected no entries to match no expectations.")
+}
@@ -68,3 +68,13 @@ func Development() Option {
 		m.Development = true
 	})
 }
+
+// CallerSkip increases the number of stack frames skipped when the AddCaller
+// hook looks for the caller. It's intended for libraries that wrap a Logger,
+// so that the wrapper's own frames aren't reported as the call site. Frames
+// within zap and its own wrapper packages are always skipped.
+func CallerSkip(skip int) Option {
+	return optionFunc(func(m *Meta) {
+		m.CallerSkip += skip
+	})
+}
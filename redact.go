@@ -0,0 +1,433 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const _defaultRedactionMask = "[REDACTED]"
+
+var (
+	// 13 to 19 digits, optionally grouped with spaces or dashes.
+	_creditCardPattern  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	_bearerTokenPattern = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`)
+	_emailPattern       = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+)
+
+// A KeyMatcher reports whether a field's key is sensitive.
+type KeyMatcher func(key string) bool
+
+// ExactKeys matches keys that are exactly equal to one of the supplied keys.
+func ExactKeys(keys ...string) KeyMatcher {
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[k] = struct{}{}
+	}
+	return KeyMatcher(func(key string) bool {
+		_, ok := set[key]
+		return ok
+	})
+}
+
+// GlobKeys matches keys against shell-style patterns, using the syntax of
+// path.Match (e.g., "*password*" or "x-api-?ey"). Like regexp.MustCompile, it
+// panics if a pattern is malformed.
+func GlobKeys(patterns ...string) KeyMatcher {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			panic(fmt.Sprintf("zap: malformed glob pattern %q: %v", p, err))
+		}
+	}
+	return KeyMatcher(func(key string) bool {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, key); ok {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// RegexpKeys matches keys against a regular expression.
+func RegexpKeys(re *regexp.Regexp) KeyMatcher {
+	return KeyMatcher(re.MatchString)
+}
+
+// A RedactOption configures the Redact option.
+type RedactOption interface {
+	apply(*redactor)
+}
+
+type redactOptionFunc func(*redactor)
+
+func (f redactOptionFunc) apply(r *redactor) {
+	f(r)
+}
+
+// MaskKeys replaces the values of fields whose keys match with the redaction
+// mask.
+func MaskKeys(m KeyMatcher) RedactOption {
+	return redactOptionFunc(func(r *redactor) {
+		r.mask = append(r.mask, m)
+	})
+}
+
+// DropKeys removes fields whose keys match altogether. If a key matches both
+// DropKeys and MaskKeys, the field is dropped.
+func DropKeys(m KeyMatcher) RedactOption {
+	return redactOptionFunc(func(r *redactor) {
+		r.drop = append(r.drop, m)
+	})
+}
+
+// MaskValues replaces each match of the supplied patterns in string values
+// (and in log messages) with the redaction mask.
+func MaskValues(patterns ...*regexp.Regexp) RedactOption {
+	return redactOptionFunc(func(r *redactor) {
+		for _, re := range patterns {
+			r.values = append(r.values, valueRule{re: re})
+		}
+	})
+}
+
+// MaskCreditCards masks sequences of 13 to 19 digits (optionally grouped with
+// spaces or dashes) that pass the Luhn checksum used by payment card numbers.
+func MaskCreditCards() RedactOption {
+	return redactOptionFunc(func(r *redactor) {
+		r.values = append(r.values, valueRule{re: _creditCardPattern, check: luhnValid})
+	})
+}
+
+// MaskBearerTokens masks the credentials in bearer authorization values,
+// leaving the "Bearer" scheme itself in place.
+func MaskBearerTokens() RedactOption {
+	return redactOptionFunc(func(r *redactor) {
+		r.values = append(r.values, valueRule{re: _bearerTokenPattern, keepPrefix: true})
+	})
+}
+
+// MaskEmails masks email addresses.
+func MaskEmails() RedactOption {
+	return redactOptionFunc(func(r *redactor) {
+		r.values = append(r.values, valueRule{re: _emailPattern})
+	})
+}
+
+// RedactionMask sets the string that replaces redacted data. The default is
+// "[REDACTED]".
+func RedactionMask(mask string) RedactOption {
+	return redactOptionFunc(func(r *redactor) {
+		r.replacement = mask
+	})
+}
+
+// Redact masks or drops sensitive data before it reaches the logger's
+// encoder. Since hooks only see context that's already been serialized,
+// redaction wraps the encoder instead: it applies to fields added with With,
+// fields passed to each logging call, fields added by hooks, and fields
+// nested inside Nest and LogMarshaler values (including those in arrays).
+//
+// Keys are matched both on their own and as dot-separated paths from the
+// top-level field, so MaskKeys(ExactKeys("password")) masks any field named
+// "password", while MaskKeys(ExactKeys("user.password")) masks only the
+// password nested in the "user" field. Value patterns apply to strings,
+// including log messages and array elements. Objects serialized by reflection
+// (see Object) can't be inspected, so only key-based rules apply to them.
+//
+// Fields added by options that precede Redact (e.g., Fields) aren't redacted,
+// so pass Redact first.
+func Redact(options ...RedactOption) Option {
+	r := &redactor{replacement: _defaultRedactionMask}
+	for _, opt := range options {
+		opt.apply(r)
+	}
+	return optionFunc(func(m *Meta) {
+		m.Encoder = newRedactingEncoder(m.Encoder, r)
+	})
+}
+
+type valueRule struct {
+	re *regexp.Regexp
+	// check, if set, filters the pattern's matches.
+	check func(string) bool
+	// keepPrefix leaves everything up to the last space in each match alone.
+	keepPrefix bool
+}
+
+type redactor struct {
+	mask        []KeyMatcher
+	drop        []KeyMatcher
+	values      []valueRule
+	replacement string
+}
+
+type redactAction int
+
+const (
+	keepField redactAction = iota
+	maskField
+	dropField
+)
+
+func (r *redactor) action(prefix, key string) redactAction {
+	full := key
+	if prefix != "" {
+		full = prefix + "." + key
+	}
+	if matchKey(r.drop, key, full) {
+		return dropField
+	}
+	if matchKey(r.mask, key, full) {
+		return maskField
+	}
+	return keepField
+}
+
+func matchKey(matchers []KeyMatcher, key, full string) bool {
+	for _, m := range matchers {
+		if m(key) || (full != key && m(full)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redactor) maskValue(s string) string {
+	for _, rule := range r.values {
+		if !rule.re.MatchString(s) {
+			continue
+		}
+		s = rule.re.ReplaceAllStringFunc(s, func(match string) string {
+			if rule.check != nil && !rule.check(match) {
+				return match
+			}
+			if rule.keepPrefix {
+				if i := strings.LastIndexAny(match, " \t"); i >= 0 {
+					return match[:i+1] + r.replacement
+				}
+			}
+			return r.replacement
+		})
+	}
+	return s
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum.
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// redactingKV applies a redactor to everything added to the wrapped KeyValue.
+type redactingKV struct {
+	KeyValue
+
+	r      *redactor
+	prefix string
+}
+
+// redacted applies the key rules, reporting whether the field was masked or
+// dropped.
+func (kv redactingKV) redacted(key string) bool {
+	switch kv.r.action(kv.prefix, key) {
+	case dropField:
+		return true
+	case maskField:
+		kv.KeyValue.AddString(key, kv.r.replacement)
+		return true
+	default:
+		return false
+	}
+}
+
+func (kv redactingKV) nestedPrefix(key string) string {
+	if kv.prefix == "" {
+		return key
+	}
+	return kv.prefix + "." + key
+}
+
+func (kv redactingKV) AddString(key, val string) {
+	if !kv.redacted(key) {
+		kv.KeyValue.AddString(key, kv.r.maskValue(val))
+	}
+}
+
+func (kv redactingKV) AddBool(key string, val bool) {
+	if !kv.redacted(key) {
+		kv.KeyValue.AddBool(key, val)
+	}
+}
+
+func (kv redactingKV) AddFloat64(key string, val float64) {
+	if !kv.redacted(key) {
+		kv.KeyValue.AddFloat64(key, val)
+	}
+}
+
+func (kv redactingKV) AddInt(key string, val int) {
+	if !kv.redacted(key) {
+		kv.KeyValue.AddInt(key, val)
+	}
+}
+
+func (kv redactingKV) AddInt64(key string, val int64) {
+	if !kv.redacted(key) {
+		kv.KeyValue.AddInt64(key, val)
+	}
+}
+
+func (kv redactingKV) AddUint(key string, val uint) {
+	if !kv.redacted(key) {
+		kv.KeyValue.AddUint(key, val)
+	}
+}
+
+func (kv redactingKV) AddUint64(key string, val uint64) {
+	if !kv.redacted(key) {
+		kv.KeyValue.AddUint64(key, val)
+	}
+}
+
+func (kv redactingKV) AddUintptr(key string, val uintptr) {
+	if !kv.redacted(key) {
+		kv.KeyValue.AddUintptr(key, val)
+	}
+}
+
+func (kv redactingKV) AddTime(key string, val time.Time) {
+	if !kv.redacted(key) {
+		kv.KeyValue.AddTime(key, val)
+	}
+}
+
+func (kv redactingKV) AddDuration(key string, val time.Duration) {
+	if !kv.redacted(key) {
+		kv.KeyValue.AddDuration(key, val)
+	}
+}
+
+func (kv redactingKV) AddObject(key string, val interface{}) error {
+	if kv.redacted(key) {
+		return nil
+	}
+	return kv.KeyValue.AddObject(key, val)
+}
+
+func (kv redactingKV) AddMarshaler(key string, obj LogMarshaler) error {
+	if kv.redacted(key) {
+		return nil
+	}
+	return kv.KeyValue.AddMarshaler(key, redactingMarshaler{obj, kv.r, kv.nestedPrefix(key)})
+}
+
+func (kv redactingKV) AddArray(key string, arr ArrayMarshaler) error {
+	if kv.redacted(key) {
+		return nil
+	}
+	return kv.KeyValue.AddArray(key, redactingArray{arr, kv.r, kv.nestedPrefix(key)})
+}
+
+type redactingMarshaler struct {
+	obj    LogMarshaler
+	r      *redactor
+	prefix string
+}
+
+func (m redactingMarshaler) MarshalLog(kv KeyValue) error {
+	return m.obj.MarshalLog(redactingKV{kv, m.r, m.prefix})
+}
+
+type redactingArray struct {
+	arr    ArrayMarshaler
+	r      *redactor
+	prefix string
+}
+
+func (a redactingArray) MarshalLogArray(enc ArrayEncoder) error {
+	return a.arr.MarshalLogArray(redactingArrayEncoder{enc, a.r, a.prefix})
+}
+
+// redactingArrayEncoder applies value rules to strings in arrays. Objects in
+// arrays use the array's key as their prefix.
+type redactingArrayEncoder struct {
+	ArrayEncoder
+
+	r      *redactor
+	prefix string
+}
+
+func (enc redactingArrayEncoder) AppendString(val string) {
+	enc.ArrayEncoder.AppendString(enc.r.maskValue(val))
+}
+
+func (enc redactingArrayEncoder) AppendArray(arr ArrayMarshaler) error {
+	return enc.ArrayEncoder.AppendArray(redactingArray{arr, enc.r, enc.prefix})
+}
+
+func (enc redactingArrayEncoder) AppendMarshaler(obj LogMarshaler) error {
+	return enc.ArrayEncoder.AppendMarshaler(redactingMarshaler{obj, enc.r, enc.prefix})
+}
+
+// redactingEncoder wraps an Encoder, redacting all fields and messages.
+type redactingEncoder struct {
+	redactingKV
+
+	enc Encoder
+}
+
+func newRedactingEncoder(enc Encoder, r *redactor) Encoder {
+	return redactingEncoder{redactingKV{KeyValue: enc, r: r}, enc}
+}
+
+func (e redactingEncoder) Clone() Encoder {
+	return newRedactingEncoder(e.enc.Clone(), e.r)
+}
+
+func (e redactingEncoder) Free() {
+	e.enc.Free()
+}
+
+func (e redactingEncoder) WriteEntry(sink io.Writer, ent Entry) error {
+	ent.Message = e.r.maskValue(ent.Message)
+	return e.enc.WriteEntry(sink, ent)
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type credentials struct {
+	user, password string
+}
+
+func (c credentials) MarshalLog(kv KeyValue) error {
+	kv.AddString("user", c.user)
+	kv.AddString("password", c.password)
+	return nil
+}
+
+func TestRedactKeys(t *testing.T) {
+	tests := []struct {
+		desc     string
+		opt      RedactOption
+		fields   []Field
+		expected string
+	}{
+		{
+			"exact",
+			MaskKeys(ExactKeys("password", "token")),
+			[]Field{String("password", "hunter2"), Int("token", 42), String("user", "jane")},
+			`"password":"[REDACTED]","token":"[REDACTED]","user":"jane"`,
+		},
+		{
+			"glob",
+			DropKeys(GlobKeys("*secret*", "x-api-?ey")),
+			[]Field{String("client_secret", "s"), String("x-api-key", "k"), Bool("ok", true)},
+			`"ok":true`,
+		},
+		{
+			"regexp",
+			MaskKeys(RegexpKeys(regexp.MustCompile(`(?i)^auth`))),
+			[]Field{String("Authorization", "Basic Zm9v"), Duration("authDelay", 1)},
+			`"Authorization":"[REDACTED]","authDelay":"[REDACTED]"`,
+		},
+		{
+			"nested key",
+			MaskKeys(ExactKeys("password")),
+			[]Field{Nest("db", String("password", "p"), Nest("replica", String("password", "q")))},
+			`"db":{"password":"[REDACTED]","replica":{"password":"[REDACTED]"}}`,
+		},
+		{
+			"nested path",
+			DropKeys(ExactKeys("db.password")),
+			[]Field{String("password", "p"), Nest("db", String("password", "q"), String("host", "h"))},
+			`"password":"p","db":{"host":"h"}`,
+		},
+		{
+			"marshaler",
+			MaskKeys(ExactKeys("password")),
+			[]Field{Marshaler("creds", credentials{"jane", "hunter2"})},
+			`"creds":{"user":"jane","password":"[REDACTED]"}`,
+		},
+		{
+			"marshalers in arrays",
+			MaskKeys(ExactKeys("creds.password")),
+			[]Field{Array("creds", ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+				return arr.AppendMarshaler(credentials{"jane", "hunter2"})
+			}))},
+			`"creds":[{"user":"jane","password":"[REDACTED]"}]`,
+		},
+		{
+			"whole objects",
+			MaskKeys(ExactKeys("creds", "obj", "list")),
+			[]Field{Marshaler("creds", credentials{}), Object("obj", map[string]int{}), Ints("list", []int{1})},
+			`"creds":"[REDACTED]","obj":"[REDACTED]","list":"[REDACTED]"`,
+		},
+	}
+
+	for _, tt := range tests {
+		withJSONLogger(t, opts(Redact(tt.opt)), func(logger Logger, buf *testBuffer) {
+			logger.Info("", tt.fields...)
+			assert.Equal(t, `{"level":"info","msg":"",`+tt.expected+`}`, buf.Stripped(), "Unexpected output redacting %s keys.", tt.desc)
+		})
+	}
+}
+
+func TestRedactDropWinsOverMask(t *testing.T) {
+	withJSONLogger(t, opts(Redact(MaskKeys(ExactKeys("k")), DropKeys(ExactKeys("k")))), func(logger Logger, buf *testBuffer) {
+		logger.Info("", String("k", "v"))
+		assert.Equal(t, `{"level":"info","msg":""}`, buf.Stripped(), "Expected dropping to take precedence over masking.")
+	})
+}
+
+func TestRedactValues(t *testing.T) {
+	tests := []struct {
+		desc     string
+		opt      RedactOption
+		in       string
+		expected string
+	}{
+		{"card", MaskCreditCards(), "card 4111 1111 1111 1111 charged", "card [REDACTED] charged"},
+		{"card", MaskCreditCards(), "id 4111-1111-1111-1112", "id 4111-1111-1111-1112"},
+		{"card", MaskCreditCards(), "order 5500005555555559", "order [REDACTED]"},
+		{"bearer", MaskBearerTokens(), "Authorization: Bearer abc.DEF-123==", "Authorization: Bearer [REDACTED]"},
+		{"email", MaskEmails(), "sent to jane.doe+x@example.co.uk today", "sent to [REDACTED] today"},
+		{"custom", MaskValues(regexp.MustCompile(`ssn=\d+`)), "ssn=123456789", "[REDACTED]"},
+	}
+
+	for _, tt := range tests {
+		withJSONLogger(t, opts(Redact(tt.opt)), func(logger Logger, buf *testBuffer) {
+			logger.Info(tt.in, String("k", tt.in), Strings("arr", []string{tt.in}))
+			assert.Equal(
+				t,
+				`{"level":"info","msg":"`+tt.expected+`","k":"`+tt.expected+`","arr":["`+tt.expected+`"]}`,
+				buf.Stripped(),
+				"Unexpected output masking %s values.", tt.desc,
+			)
+		})
+	}
+}
+
+func TestRedactContext(t *testing.T) {
+	hook := Hook(func(e *Entry) error {
+		e.Fields().AddString("token", "from-hook")
+		return nil
+	})
+	withJSONLogger(t, opts(Redact(MaskKeys(ExactKeys("token")), RedactionMask("***")), hook), func(logger Logger, buf *testBuffer) {
+		child := logger.With(String("token", "from-with"))
+		child.Info("", String("token", "from-call"))
+		assert.Equal(
+			t,
+			`{"level":"info","msg":"","token":"***","token":"***","token":"***"}`,
+			buf.Stripped(),
+			"Expected context, per-call, and hook fields to be redacted.",
+		)
+
+		// Context shouldn't leak between clones.
+		buf.Reset()
+		logger.Info("")
+		assert.Equal(t, `{"level":"info","msg":"","token":"***"}`, buf.Stripped(), "Unexpected context on the parent logger.")
+	})
+}
+
+func TestGlobKeysMalformed(t *testing.T) {
+	assert.Panics(t, func() { GlobKeys("[") }, "Expected malformed glob patterns to panic.")
+}
+
+func TestLuhn(t *testing.T) {
+	assert.True(t, luhnValid("4111 1111 1111 1111"), "Expected a valid card number to pass.")
+	assert.True(t, luhnValid("79927398713"), "Expected a valid Luhn number to pass.")
+	assert.False(t, luhnValid("79927398710"), "Expected an invalid Luhn number to fail.")
+}
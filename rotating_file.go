@@ -0,0 +1,417 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backups are named by inserting the rotation time between the log file's
+// base name and its extension, so they sort chronologically.
+const _backupTimeFormat = "2006-01-02T15-04-05.000"
+
+var (
+	errRotatingFileClosed = errors.New("can't write to a closed RotatingFile")
+
+	// _rename is stubbed in tests.
+	_rename = os.Rename
+)
+
+// A RotatingFileOption configures a RotatingFile.
+type RotatingFileOption interface {
+	apply(*RotatingFile)
+}
+
+type rotatingFileOptionFunc func(*RotatingFile)
+
+func (f rotatingFileOptionFunc) apply(rf *RotatingFile) {
+	f(rf)
+}
+
+// MaxSize rotates the file before a write would grow it beyond the given
+// number of bytes. Writes larger than the limit are written to a fresh file
+// in their entirety. A non-positive size disables size-based rotation.
+func MaxSize(bytes int64) RotatingFileOption {
+	return rotatingFileOptionFunc(func(rf *RotatingFile) {
+		rf.maxSize = bytes
+	})
+}
+
+// RotateEvery rotates the file at every multiple of the given interval (e.g.,
+// every hour on the hour). Rotation happens on the first write after each
+// boundary, so idle files aren't rotated. A non-positive interval disables
+// time-based rotation.
+func RotateEvery(interval time.Duration) RotatingFileOption {
+	return rotatingFileOptionFunc(func(rf *RotatingFile) {
+		rf.interval = interval
+	})
+}
+
+// MaxBackups limits the number of rotated files that are retained; the oldest
+// backups are deleted first. A non-positive limit keeps all backups.
+func MaxBackups(n int) RotatingFileOption {
+	return rotatingFileOptionFunc(func(rf *RotatingFile) {
+		rf.maxBackups = n
+	})
+}
+
+// CompressBackups gzips rotated files. Compression happens synchronously
+// during rotation.
+func CompressBackups() RotatingFileOption {
+	return rotatingFileOptionFunc(func(rf *RotatingFile) {
+		rf.compress = true
+	})
+}
+
+// RotationClock sets the source of the current time, which is used both to
+// schedule time-based rotation and to name backups. It's primarily useful in
+// tests.
+func RotationClock(now func() time.Time) RotatingFileOption {
+	return rotatingFileOptionFunc(func(rf *RotatingFile) {
+		rf.now = now
+	})
+}
+
+// A RotatingFile is a WriteSyncer that writes to a file on disk, moving it
+// aside to a timestamped backup when it grows too large or too old. For
+// example, rotating "/var/log/app.log" produces backups like
+// "/var/log/app-2016-12-22T15-04-05.000.log" (with a ".gz" suffix if backups
+// are compressed).
+//
+// RotatingFiles are safe for concurrent use, so they work both on their own
+// and when wrapped by the Output option.
+type RotatingFile struct {
+	sync.Mutex
+
+	path       string
+	maxSize    int64
+	interval   time.Duration
+	maxBackups int
+	compress   bool
+	now        func() time.Time
+
+	file       *os.File
+	closed     bool
+	size       int64
+	nextRotate time.Time
+}
+
+// NewRotatingFile opens (or creates) the file at the given path, creating any
+// missing parent directories, and returns a WriteSyncer that rotates it
+// according to the supplied options. Without any options, the file is never
+// rotated.
+func NewRotatingFile(path string, options ...RotatingFileOption) (*RotatingFile, error) {
+	rf := &RotatingFile{
+		path: path,
+		now:  time.Now,
+	}
+	for _, opt := range options {
+		opt.apply(rf)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+// Write implements io.Writer, rotating the underlying file first if
+// necessary.
+func (rf *RotatingFile) Write(bs []byte) (int, error) {
+	rf.Lock()
+	defer rf.Unlock()
+
+	if err := rf.ensureOpen(); err != nil {
+		return 0, err
+	}
+	var rotateErr error
+	if rf.shouldRotate(len(bs)) {
+		// A failed rotation shouldn't lose the write, so keep appending to
+		// the current file and report the error afterwards.
+		if rotateErr = rf.rotate(); rf.file == nil {
+			return 0, rotateErr
+		}
+	}
+	n, err := rf.file.Write(bs)
+	rf.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// Sync flushes the current file to stable storage.
+func (rf *RotatingFile) Sync() error {
+	rf.Lock()
+	defer rf.Unlock()
+
+	if err := rf.ensureOpen(); err != nil {
+		return err
+	}
+	return rf.file.Sync()
+}
+
+// Rotate forces an immediate rotation, regardless of the file's size and age.
+func (rf *RotatingFile) Rotate() error {
+	rf.Lock()
+	defer rf.Unlock()
+
+	if err := rf.ensureOpen(); err != nil {
+		return err
+	}
+	return rf.rotate()
+}
+
+// Close closes the current file. Subsequent writes return an error.
+func (rf *RotatingFile) Close() error {
+	rf.Lock()
+	defer rf.Unlock()
+
+	if rf.closed {
+		return nil
+	}
+	rf.closed = true
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
+
+// ensureOpen reopens the file if a failed rotation left it closed.
+func (rf *RotatingFile) ensureOpen() error {
+	if rf.closed {
+		return errRotatingFileClosed
+	}
+	if rf.file == nil {
+		return rf.open()
+	}
+	return nil
+}
+
+func (rf *RotatingFile) shouldRotate(n int) bool {
+	if rf.maxSize > 0 && rf.size > 0 && rf.size+int64(n) > rf.maxSize {
+		return true
+	}
+	return rf.interval > 0 && !rf.now().Before(rf.nextRotate)
+}
+
+func (rf *RotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	rf.file = f
+	rf.size = info.Size()
+	if rf.interval > 0 {
+		rf.nextRotate = rf.now().Truncate(rf.interval).Add(rf.interval)
+	}
+	return nil
+}
+
+func (rf *RotatingFile) rotate() error {
+	err := rf.file.Close()
+	rf.file = nil
+
+	var backup string
+	if err == nil {
+		backup = rf.backupName(rf.now())
+		err = _rename(rf.path, backup)
+	}
+	if err != nil {
+		// Keep appending to the current file rather than leaving the sink
+		// unusable; the next write that's due for rotation tries again.
+		if openErr := rf.open(); openErr != nil {
+			return multiError{err, openErr}.asError()
+		}
+		return err
+	}
+	if err := rf.open(); err != nil {
+		// Write tries to reopen the file again later.
+		return err
+	}
+
+	var errs multiError
+	if rf.compress {
+		if err := compressFile(backup); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := rf.removeOldBackups(); err != nil {
+		errs = append(errs, err)
+	}
+	return errs.asError()
+}
+
+func (rf *RotatingFile) prefixAndExt() (string, string) {
+	ext := filepath.Ext(rf.path)
+	return strings.TrimSuffix(rf.path, ext) + "-", ext
+}
+
+func (rf *RotatingFile) backupName(t time.Time) string {
+	prefix, ext := rf.prefixAndExt()
+	base := prefix + t.UTC().Format(_backupTimeFormat)
+	name := base + ext
+	// Avoid clobbering an existing backup if we rotate twice in the same
+	// millisecond.
+	for i := 1; fileExists(name) || fileExists(name+".gz"); i++ {
+		name = fmt.Sprintf("%s.%d%s", base, i, ext)
+	}
+	return name
+}
+
+func (rf *RotatingFile) removeOldBackups() error {
+	if rf.maxBackups <= 0 {
+		return nil
+	}
+	backups, err := rf.backups()
+	if err != nil {
+		return err
+	}
+	if len(backups) <= rf.maxBackups {
+		return nil
+	}
+
+	var errs multiError
+	for _, name := range backups[:len(backups)-rf.maxBackups] {
+		if err := os.Remove(name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs.asError()
+}
+
+// backups returns the paths of all the file's backups, oldest first.
+func (rf *RotatingFile) backups() ([]string, error) {
+	prefix, ext := rf.prefixAndExt()
+	matches, err := filepath.Glob(prefix + "*")
+	if err != nil {
+		return nil, err
+	}
+	backups := make([]backupFile, 0, len(matches))
+	for _, name := range matches {
+		if b, ok := parseBackupName(name, prefix, ext); ok {
+			backups = append(backups, b)
+		}
+	}
+	sort.Sort(byRotationTime(backups))
+	names := make([]string, len(backups))
+	for i, b := range backups {
+		names[i] = b.name
+	}
+	return names, nil
+}
+
+// A backupFile is a backup's path, along with the rotation time and
+// collision suffix (see backupName) parsed from it.
+type backupFile struct {
+	name string
+	t    time.Time
+	seq  int
+}
+
+func parseBackupName(name, prefix, ext string) (backupFile, bool) {
+	rest := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext)
+	rest = strings.TrimPrefix(rest, prefix)
+	if len(rest) < len(_backupTimeFormat) {
+		return backupFile{}, false
+	}
+	t, err := time.Parse(_backupTimeFormat, rest[:len(_backupTimeFormat)])
+	if err != nil {
+		return backupFile{}, false
+	}
+	seq := 0
+	if suffix := rest[len(_backupTimeFormat):]; suffix != "" {
+		if suffix[0] != '.' {
+			return backupFile{}, false
+		}
+		if seq, err = strconv.Atoi(suffix[1:]); err != nil || seq < 1 {
+			return backupFile{}, false
+		}
+	}
+	return backupFile{name: name, t: t, seq: seq}, true
+}
+
+// byRotationTime sorts backups oldest first. Backups rotated in the same
+// millisecond are ordered by their collision suffix, since a lexical sort
+// would put "app-<ts>.1.log" before "app-<ts>.log".
+type byRotationTime []backupFile
+
+func (bs byRotationTime) Len() int      { return len(bs) }
+func (bs byRotationTime) Swap(i, j int) { bs[i], bs[j] = bs[j], bs[i] }
+func (bs byRotationTime) Less(i, j int) bool {
+	if !bs[i].t.Equal(bs[j].t) {
+		return bs[i].t.Before(bs[j].t)
+	}
+	return bs[i].seq < bs[j].seq
+}
+
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		dst.Close()
+		os.Remove(name + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(name + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
+
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
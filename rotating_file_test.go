@@ -0,0 +1,262 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	sync.Mutex
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.Lock()
+	c.t = c.t.Add(d)
+	c.Unlock()
+}
+
+func withRotatingFile(t testing.TB, opts []RotatingFileOption, f func(*RotatingFile, string, *fakeClock)) {
+	dir, err := ioutil.TempDir("", "zap-rotate")
+	require.NoError(t, err, "Failed to create temporary directory.")
+	defer os.RemoveAll(dir)
+
+	clock := &fakeClock{t: time.Date(2016, time.December, 22, 15, 4, 5, 0, time.UTC)}
+	allOpts := append([]RotatingFileOption{RotationClock(clock.Now)}, opts...)
+	rf, err := NewRotatingFile(filepath.Join(dir, "logs", "app.log"), allOpts...)
+	require.NoError(t, err, "Unexpected error opening a rotating file.")
+	defer rf.Close()
+
+	f(rf, dir, clock)
+}
+
+func listFiles(t testing.TB, dir string) []string {
+	infos, err := ioutil.ReadDir(filepath.Join(dir, "logs"))
+	require.NoError(t, err, "Failed to list log directory.")
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readFile(t testing.TB, dir, name string) string {
+	bs, err := ioutil.ReadFile(filepath.Join(dir, "logs", name))
+	require.NoError(t, err, "Failed to read file %s.", name)
+	return string(bs)
+}
+
+func writeString(t testing.TB, ws WriteSyncer, s string) {
+	n, err := ws.Write([]byte(s))
+	require.NoError(t, err, "Unexpected error writing to rotating file.")
+	require.Equal(t, len(s), n, "Wrote an unexpected number of bytes.")
+}
+
+func TestRotatingFileNoRotation(t *testing.T) {
+	withRotatingFile(t, nil, func(rf *RotatingFile, dir string, clock *fakeClock) {
+		writeString(t, rf, "foo\n")
+		clock.Add(24 * time.Hour)
+		writeString(t, rf, "bar\n")
+		require.NoError(t, rf.Sync(), "Unexpected error syncing rotating file.")
+		assert.Equal(t, []string{"app.log"}, listFiles(t, dir), "Expected no rotation without options.")
+		assert.Equal(t, "foo\nbar\n", readFile(t, dir, "app.log"), "Unexpected file contents.")
+	})
+}
+
+func TestRotatingFileMaxSize(t *testing.T) {
+	withRotatingFile(t, []RotatingFileOption{MaxSize(8)}, func(rf *RotatingFile, dir string, clock *fakeClock) {
+		writeString(t, rf, "foo\n")
+		writeString(t, rf, "bar\n")
+		clock.Add(time.Second)
+		writeString(t, rf, "baz\n")
+		clock.Add(time.Second)
+		writeString(t, rf, "this is too long\n")
+
+		assert.Equal(t, []string{
+			"app-2016-12-22T15-04-06.000.log",
+			"app-2016-12-22T15-04-07.000.log",
+			"app.log",
+		}, listFiles(t, dir), "Unexpected files after size-based rotation.")
+		assert.Equal(t, "foo\nbar\n", readFile(t, dir, "app-2016-12-22T15-04-06.000.log"), "Unexpected contents in first backup.")
+		assert.Equal(t, "baz\n", readFile(t, dir, "app-2016-12-22T15-04-07.000.log"), "Unexpected contents in second backup.")
+		assert.Equal(t, "this is too long\n", readFile(t, dir, "app.log"), "Expected oversized writes to go to a fresh file.")
+	})
+}
+
+func TestRotatingFileInterval(t *testing.T) {
+	withRotatingFile(t, []RotatingFileOption{RotateEvery(time.Hour)}, func(rf *RotatingFile, dir string, clock *fakeClock) {
+		writeString(t, rf, "foo\n")
+		clock.Add(50 * time.Minute)
+		writeString(t, rf, "bar\n")
+		assert.Equal(t, []string{"app.log"}, listFiles(t, dir), "Unexpected rotation before the interval elapsed.")
+
+		// The first interval ends at 16:00.
+		clock.Add(10 * time.Minute)
+		writeString(t, rf, "baz\n")
+		assert.Equal(t, []string{
+			"app-2016-12-22T16-04-05.000.log",
+			"app.log",
+		}, listFiles(t, dir), "Expected rotation on the interval boundary.")
+		assert.Equal(t, "foo\nbar\n", readFile(t, dir, "app-2016-12-22T16-04-05.000.log"), "Unexpected contents in backup.")
+		assert.Equal(t, "baz\n", readFile(t, dir, "app.log"), "Unexpected contents in new file.")
+	})
+}
+
+func TestRotatingFileMaxBackupsAndCompression(t *testing.T) {
+	opts := []RotatingFileOption{MaxBackups(2), CompressBackups()}
+	withRotatingFile(t, opts, func(rf *RotatingFile, dir string, clock *fakeClock) {
+		for _, s := range []string{"one\n", "two\n", "three\n"} {
+			writeString(t, rf, s)
+			clock.Add(time.Second)
+			require.NoError(t, rf.Rotate(), "Unexpected error rotating file.")
+		}
+
+		assert.Equal(t, []string{
+			"app-2016-12-22T15-04-07.000.log.gz",
+			"app-2016-12-22T15-04-08.000.log.gz",
+			"app.log",
+		}, listFiles(t, dir), "Expected only the newest backups to be kept.")
+
+		f, err := os.Open(filepath.Join(dir, "logs", "app-2016-12-22T15-04-08.000.log.gz"))
+		require.NoError(t, err, "Failed to open compressed backup.")
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		require.NoError(t, err, "Failed to read compressed backup.")
+		contents, err := ioutil.ReadAll(gz)
+		require.NoError(t, err, "Failed to decompress backup.")
+		assert.Equal(t, "three\n", string(contents), "Unexpected contents in compressed backup.")
+	})
+}
+
+func TestRotatingFileSameTimestamp(t *testing.T) {
+	withRotatingFile(t, nil, func(rf *RotatingFile, dir string, _ *fakeClock) {
+		require.NoError(t, rf.Rotate(), "Unexpected error rotating file.")
+		require.NoError(t, rf.Rotate(), "Unexpected error rotating file.")
+		assert.Equal(t, []string{
+			"app-2016-12-22T15-04-05.000.1.log",
+			"app-2016-12-22T15-04-05.000.log",
+			"app.log",
+		}, listFiles(t, dir), "Expected backups not to clobber each other.")
+	})
+}
+
+func TestRotatingFileMaxBackupsSameTimestamp(t *testing.T) {
+	withRotatingFile(t, []RotatingFileOption{MaxBackups(2)}, func(rf *RotatingFile, dir string, clock *fakeClock) {
+		for _, s := range []string{"one\n", "two\n", "three\n"} {
+			writeString(t, rf, s)
+			require.NoError(t, rf.Rotate(), "Unexpected error rotating file.")
+		}
+		assert.Equal(t, []string{
+			"app-2016-12-22T15-04-05.000.1.log",
+			"app-2016-12-22T15-04-05.000.2.log",
+			"app.log",
+		}, listFiles(t, dir), "Expected the oldest backup to be removed.")
+		assert.Equal(t, "three\n", readFile(t, dir, "app-2016-12-22T15-04-05.000.2.log"), "Unexpected contents in newest backup.")
+	})
+}
+
+func TestRotatingFileRenameFailure(t *testing.T) {
+	withRotatingFile(t, []RotatingFileOption{MaxSize(8)}, func(rf *RotatingFile, dir string, _ *fakeClock) {
+		renameErr := errors.New("fail")
+		_rename = func(string, string) error { return renameErr }
+		defer func() { _rename = os.Rename }()
+
+		writeString(t, rf, "foo\n")
+		writeString(t, rf, "bar\n")
+		n, err := rf.Write([]byte("baz\n"))
+		assert.Equal(t, renameErr, err, "Expected rotation errors to be reported.")
+		assert.Equal(t, 4, n, "Expected to write despite the failed rotation.")
+		assert.Equal(t, []string{"app.log"}, listFiles(t, dir), "Unexpected backup after a failed rotation.")
+		assert.Equal(t, "foo\nbar\nbaz\n", readFile(t, dir, "app.log"), "Expected to keep appending to the current file.")
+
+		_rename = os.Rename
+		writeString(t, rf, "qux\n")
+		assert.Equal(t, 2, len(listFiles(t, dir)), "Expected the next write to retry rotation.")
+		assert.Equal(t, "qux\n", readFile(t, dir, "app.log"), "Unexpected contents after a successful rotation.")
+	})
+}
+
+func TestRotatingFileAppendsToExisting(t *testing.T) {
+	withRotatingFile(t, []RotatingFileOption{MaxSize(8)}, func(rf *RotatingFile, dir string, _ *fakeClock) {
+		writeString(t, rf, "foo\n")
+		require.NoError(t, rf.Close(), "Unexpected error closing file.")
+
+		reopened, err := NewRotatingFile(filepath.Join(dir, "logs", "app.log"), MaxSize(8))
+		require.NoError(t, err, "Unexpected error reopening file.")
+		defer reopened.Close()
+		writeString(t, reopened, "bar\n")
+		writeString(t, reopened, "baz\n")
+
+		assert.Equal(t, 2, len(listFiles(t, dir)), "Expected existing file size to count towards rotation.")
+		assert.Equal(t, "baz\n", readFile(t, dir, "app.log"), "Unexpected contents after reopening.")
+	})
+}
+
+func TestRotatingFileClosed(t *testing.T) {
+	withRotatingFile(t, nil, func(rf *RotatingFile, _ string, _ *fakeClock) {
+		require.NoError(t, rf.Close(), "Unexpected error closing file.")
+		assert.NoError(t, rf.Close(), "Expected closing twice to be a no-op.")
+
+		_, err := rf.Write([]byte("foo"))
+		assert.Equal(t, errRotatingFileClosed, err, "Expected writes to a closed file to fail.")
+		assert.Equal(t, errRotatingFileClosed, rf.Sync(), "Expected syncing a closed file to fail.")
+		assert.Equal(t, errRotatingFileClosed, rf.Rotate(), "Expected rotating a closed file to fail.")
+	})
+}
+
+func TestRotatingFileLogger(t *testing.T) {
+	withRotatingFile(t, []RotatingFileOption{MaxSize(64)}, func(rf *RotatingFile, dir string, clock *fakeClock) {
+		logger := New(NewJSONEncoder(NoTime()), Output(rf))
+		var wg sync.WaitGroup
+		runConcurrently(5, 10, &wg, func() {
+			logger.Info("concurrent")
+		})
+		wg.Wait()
+
+		var lines int
+		for _, name := range listFiles(t, dir) {
+			contents := readFile(t, dir, name)
+			assert.True(t, len(contents) <= 64, "Expected file %s to respect the size limit.", name)
+			lines += strings.Count(contents, `{"level":"info","msg":"concurrent"}`+"\n")
+		}
+		assert.Equal(t, 50, lines, "Expected no entries to be lost during rotation.")
+	})
+}
@@ -29,6 +29,7 @@ import (
 // A Log is an encoding-agnostic representation of a log message.
 type Log struct {
 	Level  zap.Level
+	Name   string
 	Msg    string
 	Fields []zap.Field
 }
@@ -42,12 +43,15 @@ type Sink struct {
 
 // WriteLog writes a log message to the LogSink.
 func (s *Sink) WriteLog(lvl zap.Level, msg string, fields []zap.Field) {
-	s.Lock()
-	log := Log{
+	s.write(Log{
 		Msg:    msg,
 		Level:  lvl,
 		Fields: fields,
-	}
+	})
+}
+
+func (s *Sink) write(log Log) {
+	s.Lock()
 	s.logs = append(s.logs, log)
 	s.Unlock()
 }
@@ -92,6 +96,16 @@ func (l *Logger) With(fields ...zap.Field) zap.Logger {
 	}
 }
 
+// Named creates a new Logger with the supplied name segment appended to its
+// name.
+func (l *Logger) Named(name string) zap.Logger {
+	return &Logger{
+		Meta:    l.Meta.Named(name),
+		sink:    l.sink,
+		context: l.context,
+	}
+}
+
 // Check returns a CheckedMessage if logging a particular message would succeed.
 func (l *Logger) Check(lvl zap.Level, msg string) *zap.CheckedMessage {
 	return l.Meta.Check(l, lvl, msg)
@@ -142,9 +156,19 @@ func (l *Logger) DPanic(msg string, fields ...zap.Field) {
 	}
 }
 
+// Sync is a no-op, since the spy Logger doesn't buffer.
+func (l *Logger) Sync() error {
+	return nil
+}
+
 func (l *Logger) log(lvl zap.Level, msg string, fields []zap.Field) {
 	if l.Meta.Enabled(lvl) {
-		l.sink.WriteLog(lvl, msg, l.allFields(fields))
+		l.sink.write(Log{
+			Level:  lvl,
+			Name:   l.Name,
+			Msg:    msg,
+			Fields: l.allFields(fields),
+		})
 	}
 }
 
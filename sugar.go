@@ -0,0 +1,267 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+var errSugarOutput = newLockedWriteSyncer(os.Stderr)
+
+// A SugaredLogger wraps a Logger in a slower, but less verbose, API. It offers
+// three flavors of each leveled method: Info uses fmt.Sprint to build the
+// message, Infof uses fmt.Sprintf, and Infow adds loosely-typed key-value pairs
+// to the structured context.
+//
+// Key-value pairs are converted to Fields using Any, so common types don't
+// require reflection. Fields may also be passed directly, in which case they
+// don't need a key. Malformed pairs (a trailing key without a value, or a key
+// that isn't a string) are dropped from the entry and reported to the wrapped
+// logger's error output.
+//
+// Like Loggers, SugaredLoggers are safe for concurrent use.
+type SugaredLogger struct {
+	base          Logger
+	internalError func(string, error)
+}
+
+// Sugar wraps a Logger to provide a more ergonomic, but slightly slower, API.
+// If the Logger embeds a Meta, problems with key-value pairs are reported to
+// its ErrorOutput; otherwise, they're written to standard error.
+func Sugar(l Logger) *SugaredLogger {
+	s := &SugaredLogger{base: l}
+	if reporter, ok := l.(interface {
+		InternalError(string, error)
+	}); ok {
+		s.internalError = reporter.InternalError
+	} else {
+		s.internalError = func(cause string, err error) {
+			fmt.Fprintf(errSugarOutput, "%v %s error: %v\n", time.Now().UTC(), cause, err)
+			errSugarOutput.Sync()
+		}
+	}
+	return s
+}
+
+// Desugar unwraps a SugaredLogger, exposing the original Logger.
+func (s *SugaredLogger) Desugar() Logger {
+	return s.base
+}
+
+// With adds a variadic number of key-value pairs or Fields to the logging
+// context.
+func (s *SugaredLogger) With(args ...interface{}) *SugaredLogger {
+	return &SugaredLogger{
+		base:          s.base.With(s.sweetenFields(args)...),
+		internalError: s.internalError,
+	}
+}
+
+// Named adds a segment to the logger's name. See Logger.Named.
+func (s *SugaredLogger) Named(name string) *SugaredLogger {
+	return &SugaredLogger{
+		base:          s.base.Named(name),
+		internalError: s.internalError,
+	}
+}
+
+// Debug uses fmt.Sprint to construct and log a message.
+func (s *SugaredLogger) Debug(args ...interface{}) {
+	s.log(DebugLevel, "", args, nil)
+}
+
+// Info uses fmt.Sprint to construct and log a message.
+func (s *SugaredLogger) Info(args ...interface{}) {
+	s.log(InfoLevel, "", args, nil)
+}
+
+// Warn uses fmt.Sprint to construct and log a message.
+func (s *SugaredLogger) Warn(args ...interface{}) {
+	s.log(WarnLevel, "", args, nil)
+}
+
+// Error uses fmt.Sprint to construct and log a message.
+func (s *SugaredLogger) Error(args ...interface{}) {
+	s.log(ErrorLevel, "", args, nil)
+}
+
+// DPanic uses fmt.Sprint to construct and log a message. In development, the
+// logger then panics.
+func (s *SugaredLogger) DPanic(args ...interface{}) {
+	s.log(DPanicLevel, "", args, nil)
+}
+
+// Panic uses fmt.Sprint to construct and log a message, then panics.
+func (s *SugaredLogger) Panic(args ...interface{}) {
+	s.log(PanicLevel, "", args, nil)
+}
+
+// Fatal uses fmt.Sprint to construct and log a message, then calls os.Exit.
+func (s *SugaredLogger) Fatal(args ...interface{}) {
+	s.log(FatalLevel, "", args, nil)
+}
+
+// Debugf uses fmt.Sprintf to log a templated message.
+func (s *SugaredLogger) Debugf(template string, args ...interface{}) {
+	s.log(DebugLevel, template, args, nil)
+}
+
+// Infof uses fmt.Sprintf to log a templated message.
+func (s *SugaredLogger) Infof(template string, args ...interface{}) {
+	s.log(InfoLevel, template, args, nil)
+}
+
+// Warnf uses fmt.Sprintf to log a templated message.
+func (s *SugaredLogger) Warnf(template string, args ...interface{}) {
+	s.log(WarnLevel, template, args, nil)
+}
+
+// Errorf uses fmt.Sprintf to log a templated message.
+func (s *SugaredLogger) Errorf(template string, args ...interface{}) {
+	s.log(ErrorLevel, template, args, nil)
+}
+
+// DPanicf uses fmt.Sprintf to log a templated message. In development, the
+// logger then panics.
+func (s *SugaredLogger) DPanicf(template string, args ...interface{}) {
+	s.log(DPanicLevel, template, args, nil)
+}
+
+// Panicf uses fmt.Sprintf to log a templated message, then panics.
+func (s *SugaredLogger) Panicf(template string, args ...interface{}) {
+	s.log(PanicLevel, template, args, nil)
+}
+
+// Fatalf uses fmt.Sprintf to log a templated message, then calls os.Exit.
+func (s *SugaredLogger) Fatalf(template string, args ...interface{}) {
+	s.log(FatalLevel, template, args, nil)
+}
+
+// Debugw logs a message with some additional context. The variadic key-value
+// pairs are treated as they are in With.
+func (s *SugaredLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	s.log(DebugLevel, msg, nil, keysAndValues)
+}
+
+// Infow logs a message with some additional context. The variadic key-value
+// pairs are treated as they are in With.
+func (s *SugaredLogger) Infow(msg string, keysAndValues ...interface{}) {
+	s.log(InfoLevel, msg, nil, keysAndValues)
+}
+
+// Warnw logs a message with some additional context. The variadic key-value
+// pairs are treated as they are in With.
+func (s *SugaredLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	s.log(WarnLevel, msg, nil, keysAndValues)
+}
+
+// Errorw logs a message with some additional context. The variadic key-value
+// pairs are treated as they are in With.
+func (s *SugaredLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	s.log(ErrorLevel, msg, nil, keysAndValues)
+}
+
+// DPanicw logs a message with some additional context. In development, the
+// logger then panics. The variadic key-value pairs are treated as they are in
+// With.
+func (s *SugaredLogger) DPanicw(msg string, keysAndValues ...interface{}) {
+	s.log(DPanicLevel, msg, nil, keysAndValues)
+}
+
+// Panicw logs a message with some additional context, then panics. The
+// variadic key-value pairs are treated as they are in With.
+func (s *SugaredLogger) Panicw(msg string, keysAndValues ...interface{}) {
+	s.log(PanicLevel, msg, nil, keysAndValues)
+}
+
+// Fatalw logs a message with some additional context, then calls os.Exit. The
+// variadic key-value pairs are treated as they are in With.
+func (s *SugaredLogger) Fatalw(msg string, keysAndValues ...interface{}) {
+	s.log(FatalLevel, msg, nil, keysAndValues)
+}
+
+// Sync flushes any buffered log entries.
+func (s *SugaredLogger) Sync() error {
+	return s.base.Sync()
+}
+
+func (s *SugaredLogger) log(lvl Level, template string, fmtArgs []interface{}, context []interface{}) {
+	switch lvl {
+	case DPanicLevel:
+		s.base.DPanic(getMessage(template, fmtArgs), s.sweetenFields(context)...)
+	default:
+		// Avoid formatting the message and building fields for disabled levels.
+		// Check always succeeds for PanicLevel and FatalLevel, and writing the
+		// CheckedMessage calls the Panic and Fatal methods.
+		if cm := s.base.Check(lvl, getMessage(template, fmtArgs)); cm.OK() {
+			cm.Write(s.sweetenFields(context)...)
+		}
+	}
+}
+
+// getMessage formats a message with fmt.Sprint or fmt.Sprintf, as
+// appropriate.
+func getMessage(template string, fmtArgs []interface{}) string {
+	if len(fmtArgs) == 0 {
+		return template
+	}
+	if template != "" {
+		return fmt.Sprintf(template, fmtArgs...)
+	}
+	return fmt.Sprint(fmtArgs...)
+}
+
+func (s *SugaredLogger) sweetenFields(args []interface{}) []Field {
+	if len(args) == 0 {
+		return nil
+	}
+
+	fields := make([]Field, 0, len(args)/2+1)
+	var errs multiError
+	for i := 0; i < len(args); {
+		// Fields don't need a key.
+		if f, ok := args[i].(Field); ok {
+			fields = append(fields, f)
+			i++
+			continue
+		}
+
+		if i == len(args)-1 {
+			errs = append(errs, fmt.Errorf("ignored key without a value: %v", args[i]))
+			break
+		}
+
+		key, val := args[i], args[i+1]
+		if keyStr, ok := key.(string); ok {
+			fields = append(fields, Any(keyStr, val))
+		} else {
+			errs = append(errs, fmt.Errorf("ignored non-string key %v (%T) with value %v", key, key, val))
+		}
+		i += 2
+	}
+
+	if err := errs.asError(); err != nil {
+		s.internalError("sugar", err)
+	}
+	return fields
+}
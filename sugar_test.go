@@ -0,0 +1,189 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/uber-go/zap/spywrite"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withSugar(t testing.TB, opts []Option, f func(*SugaredLogger, *testBuffer, *testBuffer)) {
+	sink := &testBuffer{}
+	errSink := &testBuffer{}
+
+	allOpts := make([]Option, 0, 3+len(opts))
+	allOpts = append(allOpts, DebugLevel, Output(sink), ErrorOutput(errSink))
+	allOpts = append(allOpts, opts...)
+	logger := New(newJSONEncoder(NoTime()), allOpts...)
+
+	f(Sugar(logger), sink, errSink)
+}
+
+func TestSugarWith(t *testing.T) {
+	withSugar(t, nil, func(logger *SugaredLogger, buf, errBuf *testBuffer) {
+		logger.With("foo", 42, Int("bar", 1)).Named("sugar").Info("")
+		logger.Info("")
+		assert.Equal(t, []string{
+			`{"level":"info","logger":"sugar","msg":"","foo":42,"bar":1}`,
+			`{"level":"info","msg":""}`,
+		}, buf.Lines(), "Unexpected output from SugaredLogger.With.")
+		assert.Empty(t, errBuf.String(), "Expected error output to be empty.")
+	})
+}
+
+func TestSugarFieldsInvalidPairs(t *testing.T) {
+	tests := []struct {
+		args     []interface{}
+		expected string
+		errMsg   string
+	}{
+		{
+			args:     []interface{}{"foo", 42, "bar"},
+			expected: `{"level":"info","msg":"","foo":42}`,
+			errMsg:   "ignored key without a value: bar",
+		},
+		{
+			args:     []interface{}{42, "foo", "bar", true},
+			expected: `{"level":"info","msg":"","bar":true}`,
+			errMsg:   "ignored non-string key 42 (int) with value foo",
+		},
+	}
+
+	for _, tt := range tests {
+		withSugar(t, nil, func(logger *SugaredLogger, buf, errBuf *testBuffer) {
+			logger.Infow("", tt.args...)
+			assert.Equal(t, tt.expected, buf.Stripped(), "Expected malformed pairs to be dropped.")
+			assert.Contains(t, errBuf.String(), "sugar error: "+tt.errMsg, "Expected malformed pairs to be reported.")
+		})
+	}
+}
+
+func TestSugarTypedFields(t *testing.T) {
+	withSugar(t, nil, func(logger *SugaredLogger, buf, errBuf *testBuffer) {
+		logger.Infow("",
+			"b", true,
+			"f", float32(1.5),
+			"i8", int8(-1),
+			"u16", uint16(2),
+			"s", "bar",
+			"ints", []int{1, 2},
+			"d", time.Millisecond,
+			"err", errors.New("fail"),
+			"m", loggable{true},
+			"obj", map[string]int{"a": 1},
+		)
+		assert.Equal(t,
+			`{"level":"info","msg":"","b":true,"f":1.5,"i8":-1,"u16":2,"s":"bar","ints":[1,2],"d":1000000,"err":"fail","m":{"loggable":"yes"},"obj":{"a":1}}`,
+			buf.Stripped(),
+			"Unexpected output converting loosely-typed values.",
+		)
+		assert.Empty(t, errBuf.String(), "Expected error output to be empty.")
+	})
+}
+
+func TestSugarLeveledMethods(t *testing.T) {
+	withSugar(t, nil, func(logger *SugaredLogger, buf, _ *testBuffer) {
+		tests := []struct {
+			level Level
+			funcs []func()
+		}{
+			{DebugLevel, []func(){
+				func() { logger.Debug("foo ", 42) },
+				func() { logger.Debugf("foo %d", 42) },
+				func() { logger.Debugw("foo 42") },
+			}},
+			{InfoLevel, []func(){
+				func() { logger.Info("foo ", 42) },
+				func() { logger.Infof("foo %d", 42) },
+				func() { logger.Infow("foo 42") },
+			}},
+			{WarnLevel, []func(){
+				func() { logger.Warn("foo ", 42) },
+				func() { logger.Warnf("foo %d", 42) },
+				func() { logger.Warnw("foo 42") },
+			}},
+			{ErrorLevel, []func(){
+				func() { logger.Error("foo ", 42) },
+				func() { logger.Errorf("foo %d", 42) },
+				func() { logger.Errorw("foo 42") },
+			}},
+			{DPanicLevel, []func(){
+				func() { logger.DPanic("foo ", 42) },
+				func() { logger.DPanicf("foo %d", 42) },
+				func() { logger.DPanicw("foo 42") },
+			}},
+		}
+
+		for _, tt := range tests {
+			for _, f := range tt.funcs {
+				buf.Reset()
+				f()
+				assert.Equal(t, `{"level":"`+tt.level.String()+`","msg":"foo 42"}`, buf.Stripped(), "Unexpected output at level %v.", tt.level)
+			}
+		}
+	})
+}
+
+func TestSugarDisabledLevels(t *testing.T) {
+	withSugar(t, opts(ErrorLevel), func(logger *SugaredLogger, buf, errBuf *testBuffer) {
+		logger.Infow("foo", "bar")
+		assert.Empty(t, buf.String(), "Expected disabled levels to be no-ops.")
+		assert.Empty(t, errBuf.String(), "Expected fields not to be built for disabled levels.")
+	})
+}
+
+func TestSugarPanicAndFatal(t *testing.T) {
+	withSugar(t, nil, func(logger *SugaredLogger, buf, _ *testBuffer) {
+		assert.Panics(t, func() { logger.Panicw("foo", "bar", 1) }, "Expected Panicw to panic.")
+		assert.Panics(t, func() { logger.Panicf("foo %d", 1) }, "Expected Panicf to panic.")
+		assert.Panics(t, func() { logger.Panic("foo") }, "Expected Panic to panic.")
+
+		stub := stubExit()
+		defer stub.Unstub()
+		logger.Fatalw("foo", "bar", 1)
+		stub.AssertStatus(t, 1)
+		assert.Contains(t, buf.String(), `{"level":"fatal","msg":"foo","bar":1}`, "Unexpected output from Fatalw.")
+	})
+}
+
+func TestSugarDevelopmentDPanic(t *testing.T) {
+	withSugar(t, opts(Development()), func(logger *SugaredLogger, _, _ *testBuffer) {
+		assert.Panics(t, func() { logger.DPanicw("foo") }, "Expected DPanicw to panic in development.")
+	})
+}
+
+func TestSugarDesugar(t *testing.T) {
+	logger := New(NullEncoder())
+	assert.Equal(t, logger, Sugar(logger).Desugar(), "Expected Desugar to return the wrapped logger.")
+}
+
+func TestSugarSync(t *testing.T) {
+	sink := &spywrite.WriteSyncer{Writer: ioutil.Discard}
+	logger := Sugar(New(NullEncoder(), Output(sink)))
+	assert.NoError(t, logger.Sync(), "Unexpected error syncing.")
+	assert.True(t, sink.Called(), "Expected Sync to sync the underlying logger's output.")
+}
@@ -0,0 +1,621 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// RFC 5424 limits timestamps to microsecond precision.
+	_syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+	// The SD-ID used when none is configured. 32473 is the private
+	// enterprise number reserved for documentation (RFC 5612).
+	_defaultSDID = "zap@32473"
+
+	// Length limits from RFC 5424, section 6.
+	_maxHostname  = 255
+	_maxAppName   = 48
+	_maxProcID    = 128
+	_maxParamName = 32
+)
+
+var syslogPool = sync.Pool{New: func() interface{} {
+	return &syslogEncoder{
+		// Pre-allocate a reasonably-sized buffer for each encoder.
+		bytes: make([]byte, 0, _initialBufSize),
+	}
+}}
+
+// A Facility identifies the part of the system that produced a syslog
+// message. Together with the entry's Level, it determines the message's
+// priority.
+type Facility int
+
+// Facilities defined by RFC 5424.
+const (
+	KernFacility Facility = iota
+	UserFacility
+	MailFacility
+	DaemonFacility
+	AuthFacility
+	SyslogdFacility
+	LPRFacility
+	NewsFacility
+	UUCPFacility
+	CronFacility
+	AuthPrivFacility
+	FTPFacility
+)
+
+// Facilities reserved for local use.
+const (
+	Local0Facility Facility = iota + 16
+	Local1Facility
+	Local2Facility
+	Local3Facility
+	Local4Facility
+	Local5Facility
+	Local6Facility
+	Local7Facility
+)
+
+// A SyslogOption configures a syslog encoder. TimeEncoders and
+// DurationEncoders also implement the SyslogOption interface.
+type SyslogOption interface {
+	applySyslog(*syslogEncoder)
+}
+
+type syslogOptionFunc func(*syslogEncoder)
+
+func (f syslogOptionFunc) applySyslog(enc *syslogEncoder) {
+	f(enc)
+}
+
+func (te TimeEncoder) applySyslog(enc *syslogEncoder) {
+	enc.timeEnc = te
+}
+
+func (de DurationEncoder) applySyslog(enc *syslogEncoder) {
+	enc.durationEnc = de
+}
+
+// SyslogFacility sets the facility used to compute each message's priority.
+// The default is UserFacility.
+func SyslogFacility(f Facility) SyslogOption {
+	return syslogOptionFunc(func(enc *syslogEncoder) {
+		enc.facility = f
+	})
+}
+
+// SyslogHostname sets the HOSTNAME header field. The default is the host name
+// reported by the kernel.
+func SyslogHostname(name string) SyslogOption {
+	return syslogOptionFunc(func(enc *syslogEncoder) {
+		enc.hostname = syslogHeaderField(name, _maxHostname)
+	})
+}
+
+// SyslogAppName sets the APP-NAME header field. The default is the base name
+// of the running executable.
+func SyslogAppName(name string) SyslogOption {
+	return syslogOptionFunc(func(enc *syslogEncoder) {
+		enc.appName = syslogHeaderField(name, _maxAppName)
+	})
+}
+
+// SyslogProcID sets the PROCID header field. The default is the process ID.
+func SyslogProcID(id string) SyslogOption {
+	return syslogOptionFunc(func(enc *syslogEncoder) {
+		enc.procID = syslogHeaderField(id, _maxProcID)
+	})
+}
+
+// SyslogSDID sets the ID of the structured-data element that carries the
+// entry's fields. The default is "zap@32473"; organizations with their own
+// private enterprise number should use "name@<number>".
+func SyslogSDID(id string) SyslogOption {
+	return syslogOptionFunc(func(enc *syslogEncoder) {
+		enc.sdID = syslogParamName(id)
+	})
+}
+
+// syslogEncoder is an Encoder implementation that writes RFC 5424 messages.
+type syslogEncoder struct {
+	// Encoded SD-PARAMs, each preceded by a space.
+	bytes []byte
+
+	facility Facility
+	hostname string
+	appName  string
+	procID   string
+	sdID     string
+
+	timeEnc     TimeEncoder
+	durationEnc DurationEncoder
+
+	// As in the logfmt encoder, nested objects and arrays are flattened by
+	// prefixing keys with the parent's key.
+	prefix string
+	index  int
+	keyed  bool
+}
+
+// NewSyslogEncoder creates an encoder that writes RFC 5424 syslog messages,
+// suitable for forwarding to rsyslog, syslog-ng, or any other modern syslog
+// daemon. Each entry becomes a single message of the form
+//
+//	<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID - [SD-ID logger="..." k="v" ...] MSG
+//
+// where the priority combines the encoder's Facility with the entry's Level
+// (see SyslogLevelEncoder for the mapping to severities). The logger's name
+// and all the entry's fields are carried as parameters of a single
+// structured-data element; like the logfmt encoder, the syslog encoder
+// flattens nested objects and arrays into dotted parameter names. Since RFC
+// 5424 limits parameter names to 32 printable ASCII characters, longer names
+// are truncated and unsafe characters are replaced with underscores.
+//
+// Messages end with a newline, which SyslogWriteSyncers strip before sending.
+// By default, times are encoded with RFC3339NanoTimeEncoder and durations
+// with StringDurationEncoder.
+func NewSyslogEncoder(options ...SyslogOption) Encoder {
+	enc := syslogPool.Get().(*syslogEncoder)
+	enc.truncate()
+
+	enc.facility = UserFacility
+	enc.hostname = syslogHeaderField(defaultHostname(), _maxHostname)
+	enc.appName = syslogHeaderField(filepath.Base(os.Args[0]), _maxAppName)
+	enc.procID = strconv.Itoa(os.Getpid())
+	enc.sdID = _defaultSDID
+	enc.timeEnc = RFC3339NanoTimeEncoder()
+	enc.durationEnc = StringDurationEncoder()
+	for _, opt := range options {
+		opt.applySyslog(enc)
+	}
+
+	return enc
+}
+
+func defaultHostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+func (enc *syslogEncoder) Free() {
+	syslogPool.Put(enc)
+}
+
+// AddString adds a string key and value to the encoder's fields.
+func (enc *syslogEncoder) AddString(key, val string) {
+	enc.addKey(key)
+	enc.safeAddValue(val)
+	enc.endValue()
+}
+
+// AddBool adds a string key and a boolean value to the encoder's fields.
+func (enc *syslogEncoder) AddBool(key string, val bool) {
+	enc.addKey(key)
+	enc.bytes = strconv.AppendBool(enc.bytes, val)
+	enc.endValue()
+}
+
+// AddInt adds a string key and integer value to the encoder's fields.
+func (enc *syslogEncoder) AddInt(key string, val int) {
+	enc.AddInt64(key, int64(val))
+}
+
+// AddInt64 adds a string key and int64 value to the encoder's fields.
+func (enc *syslogEncoder) AddInt64(key string, val int64) {
+	enc.addKey(key)
+	enc.bytes = strconv.AppendInt(enc.bytes, val, 10)
+	enc.endValue()
+}
+
+// AddUint adds a string key and integer value to the encoder's fields.
+func (enc *syslogEncoder) AddUint(key string, val uint) {
+	enc.AddUint64(key, uint64(val))
+}
+
+// AddUint64 adds a string key and integer value to the encoder's fields.
+func (enc *syslogEncoder) AddUint64(key string, val uint64) {
+	enc.addKey(key)
+	enc.bytes = strconv.AppendUint(enc.bytes, val, 10)
+	enc.endValue()
+}
+
+// AddUintptr adds a string key and uintptr value to the encoder's fields.
+func (enc *syslogEncoder) AddUintptr(key string, val uintptr) {
+	enc.AddUint64(key, uint64(val))
+}
+
+// AddFloat64 adds a string key and float64 value to the encoder's fields.
+func (enc *syslogEncoder) AddFloat64(key string, val float64) {
+	enc.addKey(key)
+	enc.appendFloat64(val)
+	enc.endValue()
+}
+
+// AddTime adds a string key and time.Time value to the encoder's fields,
+// using the encoder's TimeEncoder.
+func (enc *syslogEncoder) AddTime(key string, val time.Time) {
+	enc.addKey(key)
+	enc.keyed = true
+	enc.timeEnc(val, enc)
+	enc.keyed = false
+}
+
+// AddDuration adds a string key and time.Duration value to the encoder's
+// fields, using the encoder's DurationEncoder.
+func (enc *syslogEncoder) AddDuration(key string, val time.Duration) {
+	enc.addKey(key)
+	enc.keyed = true
+	enc.durationEnc(val, enc)
+	enc.keyed = false
+}
+
+// AddMarshaler adds a LogMarshaler to the encoder's fields, prefixing each of
+// its keys with the supplied key.
+func (enc *syslogEncoder) AddMarshaler(key string, obj LogMarshaler) error {
+	return enc.nested(enc.fullKey(key), obj)
+}
+
+// AddArray adds an ArrayMarshaler to the encoder's fields, using each
+// element's index as a key.
+func (enc *syslogEncoder) AddArray(key string, arr ArrayMarshaler) error {
+	return enc.nestedArray(enc.fullKey(key), arr)
+}
+
+// AddObject uses reflection to serialize an arbitrary object to JSON, then
+// adds it to the encoder's fields as a single value.
+func (enc *syslogEncoder) AddObject(key string, obj interface{}) error {
+	marshaled, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	enc.addKey(key)
+	enc.safeAddValue(string(marshaled))
+	enc.endValue()
+	return nil
+}
+
+// AppendString adds a string to the current array.
+func (enc *syslogEncoder) AppendString(val string) {
+	enc.addIndex()
+	enc.safeAddValue(val)
+	enc.endValue()
+}
+
+// AppendBool adds a boolean to the current array.
+func (enc *syslogEncoder) AppendBool(val bool) {
+	enc.addIndex()
+	enc.bytes = strconv.AppendBool(enc.bytes, val)
+	enc.endValue()
+}
+
+// AppendInt adds an integer to the current array.
+func (enc *syslogEncoder) AppendInt(val int) {
+	enc.AppendInt64(int64(val))
+}
+
+// AppendInt64 adds an int64 to the current array.
+func (enc *syslogEncoder) AppendInt64(val int64) {
+	enc.addIndex()
+	enc.bytes = strconv.AppendInt(enc.bytes, val, 10)
+	enc.endValue()
+}
+
+// AppendUint adds an unsigned integer to the current array.
+func (enc *syslogEncoder) AppendUint(val uint) {
+	enc.AppendUint64(uint64(val))
+}
+
+// AppendUint64 adds a uint64 to the current array.
+func (enc *syslogEncoder) AppendUint64(val uint64) {
+	enc.addIndex()
+	enc.bytes = strconv.AppendUint(enc.bytes, val, 10)
+	enc.endValue()
+}
+
+// AppendFloat64 adds a float64 to the current array.
+func (enc *syslogEncoder) AppendFloat64(val float64) {
+	enc.addIndex()
+	enc.appendFloat64(val)
+	enc.endValue()
+}
+
+// AppendTime adds a time.Time to the current array, using the encoder's
+// TimeEncoder.
+func (enc *syslogEncoder) AppendTime(val time.Time) {
+	enc.timeEnc(val, enc)
+}
+
+// AppendDuration adds a time.Duration to the current array, using the
+// encoder's DurationEncoder.
+func (enc *syslogEncoder) AppendDuration(val time.Duration) {
+	enc.durationEnc(val, enc)
+}
+
+// AppendArray adds a nested array to the current array.
+func (enc *syslogEncoder) AppendArray(arr ArrayMarshaler) error {
+	return enc.nestedArray(enc.nextIndex(), arr)
+}
+
+// AppendMarshaler adds a LogMarshaler to the current array.
+func (enc *syslogEncoder) AppendMarshaler(obj LogMarshaler) error {
+	return enc.nested(enc.nextIndex(), obj)
+}
+
+// Clone copies the current encoder, including any data already encoded.
+func (enc *syslogEncoder) Clone() Encoder {
+	clone := syslogPool.Get().(*syslogEncoder)
+	clone.truncate()
+	clone.bytes = append(clone.bytes, enc.bytes...)
+	clone.facility = enc.facility
+	clone.hostname = enc.hostname
+	clone.appName = enc.appName
+	clone.procID = enc.procID
+	clone.sdID = enc.sdID
+	clone.timeEnc = enc.timeEnc
+	clone.durationEnc = enc.durationEnc
+	return clone
+}
+
+// WriteEntry writes a complete syslog message to the supplied writer,
+// including the encoder's accumulated fields. It doesn't modify or lock the
+// encoder's underlying byte slice. It's safe to call from multiple goroutines,
+// but it's not safe to call WriteEntry while adding fields.
+func (enc *syslogEncoder) WriteEntry(sink io.Writer, ent Entry) error {
+	if sink == nil {
+		return errNilSink
+	}
+
+	final := syslogPool.Get().(*syslogEncoder)
+	final.truncate()
+	final.bytes = append(final.bytes, '<')
+	final.bytes = strconv.AppendInt(final.bytes, int64(enc.facility)*8+int64(syslogSeverity(ent.Level)), 10)
+	final.bytes = append(final.bytes, '>', '1', ' ')
+	if ent.Time.IsZero() {
+		final.bytes = append(final.bytes, '-')
+	} else {
+		final.bytes = ent.Time.AppendFormat(final.bytes, _syslogTimeFormat)
+	}
+	final.bytes = append(final.bytes, ' ')
+	final.bytes = append(final.bytes, enc.hostname...)
+	final.bytes = append(final.bytes, ' ')
+	final.bytes = append(final.bytes, enc.appName...)
+	final.bytes = append(final.bytes, ' ')
+	final.bytes = append(final.bytes, enc.procID...)
+	// We don't use MSGID.
+	final.bytes = append(final.bytes, " - "...)
+
+	if ent.LoggerName == "" && len(enc.bytes) == 0 {
+		final.bytes = append(final.bytes, '-')
+	} else {
+		final.bytes = append(final.bytes, '[')
+		final.bytes = append(final.bytes, enc.sdID...)
+		if ent.LoggerName != "" {
+			final.AddString("logger", ent.LoggerName)
+		}
+		final.bytes = append(final.bytes, enc.bytes...)
+		final.bytes = append(final.bytes, ']')
+	}
+	if ent.Message != "" {
+		final.bytes = append(final.bytes, ' ')
+		final.bytes = append(final.bytes, ent.Message...)
+	}
+	final.bytes = append(final.bytes, '\n')
+
+	expectedBytes := len(final.bytes)
+	n, err := sink.Write(final.bytes)
+	final.Free()
+	if err != nil {
+		return err
+	}
+	if n != expectedBytes {
+		return fmt.Errorf("incomplete write: only wrote %v of %v bytes", n, expectedBytes)
+	}
+	return nil
+}
+
+func (enc *syslogEncoder) truncate() {
+	enc.bytes = enc.bytes[:0]
+	enc.prefix = ""
+	enc.index = 0
+	enc.keyed = false
+}
+
+// addKey starts an SD-PARAM, leaving the value's opening quote in place.
+func (enc *syslogEncoder) addKey(key string) {
+	enc.bytes = append(enc.bytes, ' ')
+	start := len(enc.bytes)
+	if enc.prefix != "" {
+		enc.safeAddName(enc.prefix)
+		enc.bytes = append(enc.bytes, '.')
+	}
+	enc.safeAddName(key)
+	enc.endName(start)
+}
+
+func (enc *syslogEncoder) addIndex() {
+	if enc.keyed {
+		// The key was already written by AddTime or AddDuration.
+		enc.keyed = false
+		return
+	}
+	enc.bytes = append(enc.bytes, ' ')
+	start := len(enc.bytes)
+	enc.safeAddName(enc.prefix)
+	enc.bytes = append(enc.bytes, '.')
+	enc.bytes = strconv.AppendInt(enc.bytes, int64(enc.index), 10)
+	enc.endName(start)
+	enc.index++
+}
+
+// endName enforces the length limit on the parameter name that starts at the
+// given offset, then opens the parameter's value.
+func (enc *syslogEncoder) endName(start int) {
+	switch n := len(enc.bytes) - start; {
+	case n == 0:
+		enc.bytes = append(enc.bytes, '_')
+	case n > _maxParamName:
+		enc.bytes = enc.bytes[:start+_maxParamName]
+	}
+	enc.bytes = append(enc.bytes, '=', '"')
+}
+
+func (enc *syslogEncoder) endValue() {
+	enc.bytes = append(enc.bytes, '"')
+}
+
+// fullKey returns the supplied key, prefixed with the current object's key.
+func (enc *syslogEncoder) fullKey(key string) string {
+	if enc.prefix == "" {
+		return key
+	}
+	return enc.prefix + "." + key
+}
+
+// nextIndex returns the full key for the next element of the current array.
+func (enc *syslogEncoder) nextIndex() string {
+	key := enc.prefix + "." + strconv.Itoa(enc.index)
+	enc.index++
+	return key
+}
+
+func (enc *syslogEncoder) nested(prefix string, obj LogMarshaler) error {
+	oldPrefix, oldIndex := enc.prefix, enc.index
+	enc.prefix = prefix
+	err := obj.MarshalLog(enc)
+	enc.prefix, enc.index = oldPrefix, oldIndex
+	return err
+}
+
+func (enc *syslogEncoder) nestedArray(prefix string, arr ArrayMarshaler) error {
+	oldPrefix, oldIndex := enc.prefix, enc.index
+	enc.prefix, enc.index = prefix, 0
+	err := arr.MarshalLogArray(enc)
+	enc.prefix, enc.index = oldPrefix, oldIndex
+	return err
+}
+
+func (enc *syslogEncoder) appendFloat64(val float64) {
+	switch {
+	case math.IsNaN(val):
+		enc.bytes = append(enc.bytes, "NaN"...)
+	case math.IsInf(val, 1):
+		enc.bytes = append(enc.bytes, "+Inf"...)
+	case math.IsInf(val, -1):
+		enc.bytes = append(enc.bytes, "-Inf"...)
+	default:
+		enc.bytes = strconv.AppendFloat(enc.bytes, val, 'f', -1, 64)
+	}
+}
+
+// safeAddName appends part of an SD-PARAM name, replacing any characters that
+// RFC 5424 doesn't allow with underscores.
+func (enc *syslogEncoder) safeAddName(name string) {
+	for i := 0; i < len(name); {
+		b := name[i]
+		if b >= utf8.RuneSelf {
+			_, size := utf8.DecodeRuneInString(name[i:])
+			enc.bytes = append(enc.bytes, '_')
+			i += size
+			continue
+		}
+		if !isSyslogNameByte(b) {
+			b = '_'
+		}
+		enc.bytes = append(enc.bytes, b)
+		i++
+	}
+}
+
+// safeAddValue appends an SD-PARAM value, escaping quotes, backslashes, and
+// closing brackets and replacing invalid UTF-8.
+func (enc *syslogEncoder) safeAddValue(s string) {
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b == '"' || b == '\\' || b == ']' {
+				enc.bytes = append(enc.bytes, '\\')
+			}
+			enc.bytes = append(enc.bytes, b)
+			i++
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			enc.bytes = append(enc.bytes, string(utf8.RuneError)...)
+			i++
+			continue
+		}
+		enc.bytes = append(enc.bytes, s[i:i+size]...)
+		i += size
+	}
+}
+
+func isSyslogNameByte(b byte) bool {
+	return '!' <= b && b <= '~' && b != '=' && b != ']' && b != '"'
+}
+
+// syslogParamName sanitizes an SD-ID, which follows the same rules as
+// parameter names.
+func syslogParamName(s string) string {
+	enc := &syslogEncoder{}
+	enc.safeAddName(s)
+	switch {
+	case len(enc.bytes) == 0:
+		return "_"
+	case len(enc.bytes) > _maxParamName:
+		return string(enc.bytes[:_maxParamName])
+	default:
+		return string(enc.bytes)
+	}
+}
+
+// syslogHeaderField sanitizes a header field, which must be printable ASCII
+// (without spaces) and no longer than the supplied limit. Empty fields are
+// replaced with the NILVALUE.
+func syslogHeaderField(s string, limit int) string {
+	if s == "" {
+		return "-"
+	}
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(buf) < limit; i++ {
+		b := s[i]
+		if b < '!' || b > '~' {
+			b = '_'
+		}
+		buf = append(buf, b)
+	}
+	return string(buf)
+}
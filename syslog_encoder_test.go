@@ -0,0 +1,184 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uber-go/zap/spywrite"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSyslogEncoder(opts ...SyslogOption) *syslogEncoder {
+	return NewSyslogEncoder(opts...).(*syslogEncoder)
+}
+
+func testSyslogOptions() []SyslogOption {
+	return []SyslogOption{
+		SyslogHostname("myhost"),
+		SyslogAppName("myapp"),
+		SyslogProcID("42"),
+	}
+}
+
+func TestSyslogEncoderFields(t *testing.T) {
+	tests := []struct {
+		desc     string
+		expected string
+		f        func(Encoder)
+	}{
+		{"string", ` k="v"`, func(e Encoder) { e.AddString("k", "v") }},
+		{"string", ` k=""`, func(e Encoder) { e.AddString("k", "") }},
+		{"string", ` k="a \"b\" \\ [c\]"`, func(e Encoder) { e.AddString("k", `a "b" \ [c]`) }},
+		{"string", ` k="☃` + "\ufffd" + `"`, func(e Encoder) { e.AddString("k", "☃\xff") }},
+		{"string", ` k_x_____="v"`, func(e Encoder) { e.AddString(`k x="]☃`+"\n", "v") }},
+		{"string", ` _="v"`, func(e Encoder) { e.AddString("", "v") }},
+		{"string", ` ` + strings.Repeat("k", 32) + `="v"`, func(e Encoder) { e.AddString(strings.Repeat("k", 40), "v") }},
+		{"bool", ` k="true"`, func(e Encoder) { e.AddBool("k", true) }},
+		{"int", ` k="-42"`, func(e Encoder) { e.AddInt("k", -42) }},
+		{"uint64", ` k="` + strconv.FormatUint(math.MaxUint64, 10) + `"`, func(e Encoder) { e.AddUint64("k", math.MaxUint64) }},
+		{"uintptr", ` k="42"`, func(e Encoder) { e.AddUintptr("k", 42) }},
+		{"float64", ` k="1.5"`, func(e Encoder) { e.AddFloat64("k", 1.5) }},
+		{"float64", ` k="NaN"`, func(e Encoder) { e.AddFloat64("k", math.NaN()) }},
+		{"time", ` k="1970-01-01T00:00:00.5Z"`, func(e Encoder) { e.AddTime("k", epoch.Add(500*time.Millisecond)) }},
+		{"duration", ` k="1.5s"`, func(e Encoder) { e.AddDuration("k", 1500*time.Millisecond) }},
+		{"marshaler", ` k.name="jane doe" k.inner.loggable="yes" k.ids.0="1" k.ids.1="2"`, func(e Encoder) {
+			assert.NoError(t, e.AddMarshaler("k", nestedLoggable{}), "Unexpected error calling MarshalLog.")
+		}},
+		{"array", ` k.0.0="1" k.1.loggable="yes" k.2="1s" k.3="x"`, func(e Encoder) {
+			assert.NoError(t, e.AddArray("k", ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+				arr.AppendArray(ints{1})
+				arr.AppendMarshaler(loggable{true})
+				arr.AppendDuration(time.Second)
+				arr.AppendString("x")
+				return nil
+			})), "Unexpected error adding an array.")
+		}},
+		{"arbitrary object", ` k="{\"loggable\":\"yes\"}"`, func(e Encoder) {
+			assert.NoError(t, e.AddObject("k", map[string]string{"loggable": "yes"}), "Unexpected error JSON-serializing a map.")
+		}},
+		{"arbitrary object", "", func(e Encoder) {
+			assert.Error(t, e.AddObject("k", noJSON{}), "Unexpected success JSON-serializing a noJSON.")
+		}},
+	}
+
+	for _, tt := range tests {
+		enc := newSyslogEncoder()
+		tt.f(enc)
+		assert.Equal(t, tt.expected, string(enc.bytes), "Unexpected encoder output after adding a %s.", tt.desc)
+		enc.Free()
+	}
+}
+
+func TestSyslogWriteEntry(t *testing.T) {
+	enc := NewSyslogEncoder(append(testSyslogOptions(), SyslogFacility(Local0Facility))...)
+	entry := Entry{Level: InfoLevel, Message: "hello world", Time: epoch}
+	assert.Equal(t, errNilSink, enc.WriteEntry(nil, entry), "Expected an error writing to a nil sink.")
+
+	sink := &testBuffer{}
+	require.NoError(t, enc.WriteEntry(sink, entry), "Unexpected error writing entry.")
+	assert.Equal(t, "<134>1 1970-01-01T00:00:00.000000Z myhost myapp 42 - - hello world\n", sink.String(), "Unexpected message without fields.")
+
+	enc.AddString("foo", "bar")
+	for _, e := range []Encoder{enc, enc.Clone()} {
+		sink.Reset()
+		require.NoError(t, e.WriteEntry(sink, Entry{Level: ErrorLevel, LoggerName: "a.b", Time: epoch.Add(time.Microsecond)}), "Unexpected error writing entry.")
+		assert.Equal(
+			t,
+			`<131>1 1970-01-01T00:00:00.000001Z myhost myapp 42 - [zap@32473 logger="a.b" foo="bar"]`+"\n",
+			sink.String(),
+			"Unexpected message with fields.",
+		)
+	}
+}
+
+func TestSyslogPriorities(t *testing.T) {
+	tests := []struct {
+		facility Facility
+		level    Level
+		pri      string
+	}{
+		{UserFacility, DebugLevel, "<15>"},
+		{UserFacility, WarnLevel, "<12>"},
+		{KernFacility, FatalLevel, "<2>"},
+		{DaemonFacility, PanicLevel, "<26>"},
+		{Local7Facility, InfoLevel, "<190>"},
+	}
+
+	for _, tt := range tests {
+		sink := &testBuffer{}
+		enc := NewSyslogEncoder(SyslogFacility(tt.facility))
+		require.NoError(t, enc.WriteEntry(sink, Entry{Level: tt.level, Time: epoch}), "Unexpected error writing entry.")
+		assert.True(t, strings.HasPrefix(sink.String(), tt.pri+"1 "), "Expected priority %s for %v at %v, got %q.", tt.pri, tt.facility, tt.level, sink.String())
+	}
+}
+
+func TestSyslogHeaderOptions(t *testing.T) {
+	sink := &testBuffer{}
+	enc := NewSyslogEncoder(
+		SyslogHostname(""),
+		SyslogAppName("my app"),
+		SyslogProcID(strings.Repeat("1", 200)),
+		SyslogSDID("my sd@1"),
+		EpochNanosTimeEncoder(),
+		SecondsDurationEncoder(),
+	)
+	enc.AddTime("t", epoch)
+	enc.AddDuration("d", time.Second)
+	require.NoError(t, enc.WriteEntry(sink, Entry{Level: InfoLevel, Message: "hi"}), "Unexpected error writing entry.")
+	assert.Equal(
+		t,
+		`<14>1 - - my_app `+strings.Repeat("1", 128)+` - [my_sd@1 t="0" d="1"] hi`+"\n",
+		sink.String(),
+		"Unexpected message with non-default header options.",
+	)
+}
+
+func TestSyslogDefaultHeader(t *testing.T) {
+	sink := &testBuffer{}
+	NewSyslogEncoder().WriteEntry(sink, Entry{Level: InfoLevel, Time: epoch})
+	fields := strings.Fields(sink.String())
+	require.Len(t, fields, 7, "Expected a header without structured data or a message.")
+	assert.Equal(t, strconv.Itoa(os.Getpid()), fields[4], "Expected the process ID in the header.")
+	assert.NotEqual(t, "-", fields[3], "Expected the executable's name in the header.")
+}
+
+func TestSyslogWriteEntryFailure(t *testing.T) {
+	enc := NewSyslogEncoder()
+	tests := []struct {
+		sink io.Writer
+		msg  string
+	}{
+		{spywrite.FailWriter{}, "Expected an error when writing to sink fails."},
+		{spywrite.ShortWriter{}, "Expected an error on partial writes to sink."},
+	}
+	for _, tt := range tests {
+		assert.Error(t, enc.WriteEntry(tt.sink, Entry{Message: "hello", Time: epoch}), tt.msg)
+	}
+}
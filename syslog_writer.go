@@ -0,0 +1,213 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// Bound how long a slow or unreachable daemon can block the logger, since
+	// dialing and writing happen with the lock held.
+	_syslogDialTimeout  = 5 * time.Second
+	_syslogWriteTimeout = 5 * time.Second
+)
+
+var (
+	errSyslogClosed = errors.New("can't write to a closed SyslogWriteSyncer")
+
+	// Where local syslog daemons usually listen, in order of preference.
+	_syslogPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+)
+
+// A SyslogWriteSyncer is a WriteSyncer that sends each write to a syslog
+// daemon as a single message. It's designed to be used with the syslog
+// encoder (see NewSyslogEncoder).
+//
+// On datagram sockets (unixgram, udp), each message is sent as its own
+// datagram. On stream sockets (unix, tcp), messages are framed using octet
+// counting, as described in RFC 6587: each message is preceded by its length
+// in bytes and a space. In both cases, a single trailing newline is stripped
+// from each message.
+//
+// Connecting and each write time out after five seconds. If a write on a
+// stream socket is interrupted partway through a message, only the remaining
+// bytes are retried, on the same connection, so that the framing stays
+// intact. If sending a message fails otherwise, the SyslogWriteSyncer
+// reconnects and sends the whole message once more before returning an
+// error. SyslogWriteSyncers are safe for concurrent use.
+type SyslogWriteSyncer struct {
+	sync.Mutex
+
+	network string
+	addr    string
+	framed  bool
+
+	conn   net.Conn
+	buf    []byte
+	closed bool
+}
+
+// NewSyslogWriteSyncer connects to the syslog daemon at the given address. The
+// network must be "unix", "unixgram", "tcp", "tcp4", "tcp6", "udp", "udp4", or
+// "udp6"; see net.Dial for the address format. If both the network and the
+// address are empty, it connects to the local daemon's unix socket, trying
+// /dev/log, /var/run/syslog, and /var/run/log in turn.
+func NewSyslogWriteSyncer(network, addr string) (*SyslogWriteSyncer, error) {
+	s := &SyslogWriteSyncer{network: network, addr: addr}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write sends the supplied bytes as a single syslog message.
+func (s *SyslogWriteSyncer) Write(bs []byte) (int, error) {
+	msg := bytes.TrimSuffix(bs, []byte{'\n'})
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return 0, errSyslogClosed
+	}
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return 0, err
+		}
+	}
+	if err := s.send(msg); err != nil {
+		// The daemon may have restarted, so try again on a fresh connection.
+		s.conn.Close()
+		s.conn = nil
+		if err := s.connect(); err != nil {
+			return 0, err
+		}
+		if err := s.send(msg); err != nil {
+			return 0, err
+		}
+	}
+	return len(bs), nil
+}
+
+// Sync is a no-op, since messages aren't buffered.
+func (s *SyslogWriteSyncer) Sync() error {
+	return nil
+}
+
+// Close closes the connection to the syslog daemon. Subsequent writes return
+// an error.
+func (s *SyslogWriteSyncer) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// connect dials the daemon. It must be called with the lock held (or before
+// the SyslogWriteSyncer is shared).
+func (s *SyslogWriteSyncer) connect() error {
+	if s.network == "" && s.addr == "" {
+		return s.connectLocal()
+	}
+	framed, err := isStreamNetwork(s.network)
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout(s.network, s.addr, _syslogDialTimeout)
+	if err != nil {
+		return err
+	}
+	s.conn, s.framed = conn, framed
+	return nil
+}
+
+func (s *SyslogWriteSyncer) connectLocal() error {
+	var errs multiError
+	for _, path := range _syslogPaths {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.DialTimeout(network, path, _syslogDialTimeout)
+			if err == nil {
+				s.conn, s.framed = conn, network == "unix"
+				return nil
+			}
+			errs = append(errs, err)
+		}
+	}
+	return fmt.Errorf("can't connect to the local syslog daemon: %v", errs)
+}
+
+func (s *SyslogWriteSyncer) send(msg []byte) error {
+	out := msg
+	if s.framed {
+		s.buf = strconv.AppendInt(s.buf[:0], int64(len(msg)), 10)
+		s.buf = append(s.buf, ' ')
+		s.buf = append(s.buf, msg...)
+		out = s.buf
+	}
+	n, err := s.write(out)
+	if err != nil && s.framed && n > 0 {
+		// Part of the frame is already on the wire, so starting it over (on
+		// this connection or a new one) would corrupt the stream. Finish it
+		// instead; if that fails too, the connection is abandoned and the
+		// daemon discards the truncated frame.
+		_, err = s.write(out[n:])
+	}
+	return err
+}
+
+func (s *SyslogWriteSyncer) write(out []byte) (int, error) {
+	if err := s.conn.SetWriteDeadline(time.Now().Add(_syslogWriteTimeout)); err != nil {
+		return 0, err
+	}
+	n, err := s.conn.Write(out)
+	if err == nil && n != len(out) {
+		err = io.ErrShortWrite
+	}
+	return n, err
+}
+
+func isStreamNetwork(network string) (bool, error) {
+	switch network {
+	case "unix", "tcp", "tcp4", "tcp6":
+		return true, nil
+	case "unixgram", "udp", "udp4", "udp6":
+		return false, nil
+	default:
+		return false, fmt.Errorf("unsupported syslog network %q", network)
+	}
+}
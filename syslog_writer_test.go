@@ -0,0 +1,236 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readFramed reads octet-counted messages from a stream connection.
+func readFramed(t testing.TB, conn net.Conn, n int) []string {
+	r := bufio.NewReader(conn)
+	msgs := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		length, err := r.ReadString(' ')
+		require.NoError(t, err, "Unexpected error reading message length.")
+		size, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+		require.NoError(t, err, "Expected message to start with its length.")
+		buf := make([]byte, size)
+		_, err = io.ReadFull(r, buf)
+		require.NoError(t, err, "Unexpected error reading message.")
+		msgs = append(msgs, string(buf))
+	}
+	return msgs
+}
+
+func readDatagram(t testing.TB, conn net.PacketConn) string {
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err, "Unexpected error reading datagram.")
+	return string(buf[:n])
+}
+
+func withSocketDir(t testing.TB, f func(dir string)) {
+	dir, err := ioutil.TempDir("", "zap-syslog")
+	require.NoError(t, err, "Failed to create temporary directory.")
+	defer os.RemoveAll(dir)
+	f(dir)
+}
+
+func TestSyslogWriteSyncerTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "Failed to listen on TCP.")
+	defer ln.Close()
+
+	received := make(chan []string)
+	go func() {
+		conn, err := ln.Accept()
+		if !assert.NoError(t, err, "Unexpected error accepting connection.") {
+			close(received)
+			return
+		}
+		defer conn.Close()
+		received <- readFramed(t, conn, 2)
+	}()
+
+	ws, err := NewSyslogWriteSyncer("tcp", ln.Addr().String())
+	require.NoError(t, err, "Unexpected error connecting over TCP.")
+	defer ws.Close()
+
+	logger := New(NewSyslogEncoder(testSyslogOptions()...), Output(ws))
+	logger.Info("one")
+	logger.Warn("two\nlines", String("k", "v"))
+	assert.NoError(t, logger.Sync(), "Unexpected error syncing.")
+
+	msgs := <-received
+	require.Len(t, msgs, 2, "Expected two framed messages.")
+	assert.Regexp(t, `^<14>1 \S+ myhost myapp 42 - - one$`, msgs[0], "Unexpected first message.")
+	assert.Regexp(t, `^<12>1 \S+ myhost myapp 42 - \[zap@32473 k="v"\] two\nlines$`, msgs[1], "Unexpected second message.")
+}
+
+func TestSyslogWriteSyncerUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err, "Failed to listen on UDP.")
+	defer conn.Close()
+
+	ws, err := NewSyslogWriteSyncer("udp", conn.LocalAddr().String())
+	require.NoError(t, err, "Unexpected error connecting over UDP.")
+	defer ws.Close()
+
+	n, err := ws.Write([]byte("<14>1 hello\n"))
+	assert.NoError(t, err, "Unexpected error writing.")
+	assert.Equal(t, 12, n, "Expected to report writing the whole input, including the newline.")
+	assert.Equal(t, "<14>1 hello", readDatagram(t, conn), "Expected one unframed datagram without the newline.")
+
+	// Break the connection to make sure that we reconnect.
+	ws.conn.Close()
+	_, err = ws.Write([]byte("<14>1 again\n"))
+	assert.NoError(t, err, "Expected to reconnect after a failed write.")
+	assert.Equal(t, "<14>1 again", readDatagram(t, conn), "Unexpected datagram after reconnecting.")
+}
+
+func TestSyslogWriteSyncerLocal(t *testing.T) {
+	withSocketDir(t, func(dir string) {
+		path := filepath.Join(dir, "log")
+		conn, err := net.ListenPacket("unixgram", path)
+		require.NoError(t, err, "Failed to listen on a unix datagram socket.")
+		defer conn.Close()
+
+		defer func(paths []string) { _syslogPaths = paths }(_syslogPaths)
+		_syslogPaths = []string{filepath.Join(dir, "missing"), path}
+
+		ws, err := NewSyslogWriteSyncer("", "")
+		require.NoError(t, err, "Unexpected error connecting to local socket.")
+		defer ws.Close()
+
+		_, err = ws.Write([]byte("<14>1 local\n"))
+		assert.NoError(t, err, "Unexpected error writing.")
+		assert.Equal(t, "<14>1 local", readDatagram(t, conn), "Unexpected datagram on local socket.")
+
+		_syslogPaths = []string{filepath.Join(dir, "missing")}
+		_, err = NewSyslogWriteSyncer("", "")
+		assert.Error(t, err, "Expected an error when there's no local syslog socket.")
+	})
+}
+
+func TestSyslogWriteSyncerUnixStream(t *testing.T) {
+	withSocketDir(t, func(dir string) {
+		path := filepath.Join(dir, "log")
+		ln, err := net.Listen("unix", path)
+		require.NoError(t, err, "Failed to listen on a unix stream socket.")
+		defer ln.Close()
+
+		received := make(chan []string)
+		go func() {
+			conn, err := ln.Accept()
+			if !assert.NoError(t, err, "Unexpected error accepting connection.") {
+				close(received)
+				return
+			}
+			defer conn.Close()
+			received <- readFramed(t, conn, 1)
+		}()
+
+		ws, err := NewSyslogWriteSyncer("unix", path)
+		require.NoError(t, err, "Unexpected error connecting to unix socket.")
+		defer ws.Close()
+
+		_, err = ws.Write([]byte("<14>1 stream\n"))
+		assert.NoError(t, err, "Unexpected error writing.")
+		assert.Equal(t, []string{"<14>1 stream"}, <-received, "Expected octet-counted framing on stream sockets.")
+	})
+}
+
+func TestSyslogWriteSyncerErrors(t *testing.T) {
+	_, err := NewSyslogWriteSyncer("ip4", "127.0.0.1")
+	assert.Error(t, err, "Expected an error using an unsupported network.")
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err, "Failed to listen on UDP.")
+	defer conn.Close()
+
+	ws, err := NewSyslogWriteSyncer("udp", conn.LocalAddr().String())
+	require.NoError(t, err, "Unexpected error connecting over UDP.")
+	assert.NoError(t, ws.Sync(), "Unexpected error syncing.")
+	assert.NoError(t, ws.Close(), "Unexpected error closing.")
+	assert.NoError(t, ws.Close(), "Expected closing twice to be a no-op.")
+	_, err = ws.Write([]byte("closed"))
+	assert.Equal(t, errSyslogClosed, err, "Expected an error writing after Close.")
+}
+
+// shortConn is a stream connection that accepts at most max bytes on its
+// first write, then fails that write.
+type shortConn struct {
+	net.Conn
+
+	max       int
+	writes    [][]byte
+	deadlines int
+}
+
+func (c *shortConn) Write(bs []byte) (int, error) {
+	n := len(bs)
+	var err error
+	if len(c.writes) == 0 && n > c.max {
+		n, err = c.max, errors.New("interrupted")
+	}
+	c.writes = append(c.writes, append([]byte(nil), bs[:n]...))
+	return n, err
+}
+
+func (c *shortConn) SetWriteDeadline(time.Time) error {
+	c.deadlines++
+	return nil
+}
+
+func (c *shortConn) Close() error { return nil }
+
+func TestSyslogWriteSyncerPartialWrite(t *testing.T) {
+	conn := &shortConn{max: 4}
+	ws := &SyslogWriteSyncer{network: "tcp", framed: true, conn: conn}
+
+	n, err := ws.Write([]byte("hello world\n"))
+	require.NoError(t, err, "Unexpected error finishing a partial write.")
+	assert.Equal(t, len("hello world\n"), n, "Unexpected number of bytes written.")
+	assert.Equal(t, []string{"11 h", "ello world"}, stringifyWrites(conn.writes), "Expected only the unsent bytes to be retried.")
+	assert.Equal(t, 2, conn.deadlines, "Expected a write deadline before each write.")
+}
+
+func stringifyWrites(writes [][]byte) []string {
+	strs := make([]string, len(writes))
+	for i, w := range writes {
+		strs[i] = string(w)
+	}
+	return strs
+}
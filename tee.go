@@ -32,14 +32,16 @@ package zap
 // the message, then the Tee terminates the process (using os.Exit or panic()
 // per usual semantics).
 //
-// NOTE: DPanic will currently never panic, since the Tee Logger does not
-// accept options (nor even have a development flag).
+// The Tee is in development mode if any of its sub-loggers are (see
+// IsDevelopment), in which case DPanic panics after all sub-loggers have
+// received the message.
 //
 // Check returns a CheckedMessage chain of any OK CheckedMessages returned by
 // all sub-loggers. The returned message is OK if any of the sub-messages are.
-// An exception is made for FatalLevel and PanicLevel, where a CheckedMessage
-// is returned against the Tee itself. This is so that tlog.Check(PanicLevel,
-// ...).Write(...) is equivalent to tlog.Panic(...) (likewise for FatalLevel).
+// An exception is made for FatalLevel and PanicLevel (and for DPanicLevel in
+// development mode), where a CheckedMessage is returned against the Tee
+// itself. This is so that tlog.Check(PanicLevel, ...).Write(...) is
+// equivalent to tlog.Panic(...) (likewise for the other levels).
 func Tee(logs ...Logger) Logger {
 	switch len(logs) {
 	case 0:
@@ -91,17 +93,70 @@ func (ml multiLogger) log(lvl Level, msg string, fields []Field) {
 
 func (ml multiLogger) DPanic(msg string, fields ...Field) {
 	ml.log(DPanicLevel, msg, fields)
-	// TODO: Implement development/DPanic?
+	if ml.IsDevelopment() {
+		panic(msg)
+	}
+}
+
+func (ml multiLogger) IsDevelopment() bool {
+	for _, log := range ml {
+		if IsDevelopment(log) {
+			return true
+		}
+	}
+	return false
 }
 
 func (ml multiLogger) With(fields ...Field) Logger {
+	ctx := contextEncoder{fields: fields}
+	return ml.with(&ctx)
+}
+
+// with adds the same context to each sub-logger. Sub-loggers created by New
+// are allocated together, share the supplied fields rather than copying them,
+// and serialize them once per distinct encoder.
+func (ml multiLogger) with(ctx *contextEncoder) multiLogger {
+	n := 0
+	for _, log := range ml {
+		if _, ok := log.(*logger); ok {
+			n++
+		}
+	}
+	loggers := make([]logger, n)
+	clone := make(multiLogger, len(ml))
+	for i, log := range ml {
+		switch l := log.(type) {
+		case *logger:
+			l.withContext(&loggers[0], ctx)
+			clone[i] = &loggers[0]
+			loggers = loggers[1:]
+		case multiLogger:
+			clone[i] = l.with(ctx)
+		default:
+			clone[i] = log.With(ctx.fields...)
+		}
+	}
+	return clone
+}
+
+func (ml multiLogger) Named(name string) Logger {
 	clone := make(multiLogger, len(ml))
 	for i := range ml {
-		clone[i] = ml[i].With(fields...)
+		clone[i] = ml[i].Named(name)
 	}
 	return clone
 }
 
+func (ml multiLogger) Sync() error {
+	var errs multiError
+	for _, log := range ml {
+		if err := log.Sync(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs.asError()
+}
+
 func (ml multiLogger) Check(lvl Level, msg string) *CheckedMessage {
 	switch lvl {
 	case FatalLevel, PanicLevel:
@@ -109,6 +164,12 @@ func (ml multiLogger) Check(lvl Level, msg string) *CheckedMessage {
 		// sub-logger termination (by merely logging at FatalLevel and
 		// PanicLevel).
 		return NewCheckedMessage(ml, lvl, msg)
+	case DPanicLevel:
+		// Likewise, a sub-logger in development mode would panic before the
+		// remaining sub-loggers received the message.
+		if ml.IsDevelopment() {
+			return NewCheckedMessage(ml, lvl, msg)
+		}
 	}
 	var cm *CheckedMessage
 	for _, log := range ml {
@@ -21,6 +21,7 @@
 package zap_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/uber-go/zap"
@@ -94,6 +95,23 @@ func TestTeeLogsBoth(t *testing.T) {
 	}, sink2.Logs())
 }
 
+func TestTeeNamed(t *testing.T) {
+	log1, sink1 := spy.New(zap.DebugLevel)
+	log2, sink2 := spy.New(zap.DebugLevel)
+	log := zap.Tee(log1, log2).Named("foo").Named("bar")
+
+	log.Info("named")
+
+	expected := []spy.Log{{
+		Level:  zap.InfoLevel,
+		Name:   "foo.bar",
+		Msg:    "named",
+		Fields: []zap.Field{},
+	}}
+	assert.Equal(t, expected, sink1.Logs(), "Expected first logger to be named.")
+	assert.Equal(t, expected, sink2.Logs(), "Expected second logger to be named.")
+}
+
 func TestTee_Panic(t *testing.T) {
 	log1, sink1 := spy.New(zap.DebugLevel)
 	log2, sink2 := spy.New(zap.WarnLevel)
@@ -140,6 +158,56 @@ func TestTee_Panic(t *testing.T) {
 	}, sink2.Logs())
 }
 
+func TestTee_DPanic(t *testing.T) {
+	log1, sink1 := spy.New(zap.DebugLevel)
+	log2, sink2 := spy.New(zap.DebugLevel)
+	log := zap.Tee(log1, log2)
+
+	assert.False(t, zap.IsDevelopment(log), "Expected Tee of production loggers not to be in development.")
+	assert.NotPanics(t, func() { log.DPanic("foo") }, "tee logger.DPanic doesn't panic in production")
+
+	log2.Development = true
+	log = zap.Tee(log1, log2)
+	assert.True(t, zap.IsDevelopment(log), "Expected Tee to be in development if any sub-logger is.")
+	assert.Panics(t, func() { log.DPanic("bar") }, "tee logger.DPanic panics in development")
+	assert.Panics(t, func() { log.Check(zap.DPanicLevel, "baz").Write() }, "tee logger.Check(DPanicLevel).Write() panics in development")
+
+	expected := []spy.Log{
+		{Level: zap.DPanicLevel, Msg: "foo", Fields: []zap.Field{}},
+		{Level: zap.DPanicLevel, Msg: "bar", Fields: []zap.Field{}},
+		{Level: zap.DPanicLevel, Msg: "baz", Fields: []zap.Field{}},
+	}
+	assert.Equal(t, expected, sink1.Logs(), "Expected all sub-loggers to log before panicking.")
+	assert.Equal(t, expected, sink2.Logs(), "Expected all sub-loggers to log before panicking.")
+}
+
 // XXX: we cannot presently write `func TestTee_Fatal(t *testing.T)`,
 // because we can't have both a spy logger and an exit stub without a
 // dependency cycle.
+
+type syncer struct {
+	zap.Logger
+	err    error
+	called bool
+}
+
+func (s *syncer) Sync() error {
+	s.called = true
+	return s.err
+}
+
+func TestTeeSync(t *testing.T) {
+	log1, _ := spy.New()
+	log2, _ := spy.New()
+	s1, s2 := &syncer{Logger: log1}, &syncer{Logger: log2}
+	assert.NoError(t, zap.Tee(s1, s2).Sync(), "Unexpected error syncing Tee.")
+	assert.True(t, s1.called && s2.called, "Expected Tee to sync all sub-loggers.")
+
+	s1.err = errors.New("foo")
+	s2.err = errors.New("bar")
+	err := zap.Tee(s1, s2).Sync()
+	if assert.Error(t, err, "Expected Tee to return errors from sub-loggers.") {
+		assert.Contains(t, err.Error(), "foo", "Expected error from first sub-logger.")
+		assert.Contains(t, err.Error(), "bar", "Expected error from second sub-logger.")
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package testutils
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TBWriter is an io.Writer that passes each write to a testing.TB's Logf
+// method, stripping a single trailing newline. It's useful for routing a
+// logger's output to the test that owns it, where each write is a complete
+// entry.
+type TBWriter struct {
+	TB testing.TB
+}
+
+// Write implements io.Writer.
+func (w TBWriter) Write(b []byte) (int, error) {
+	w.TB.Logf("%s", bytes.TrimSuffix(b, []byte{'\n'}))
+	return len(b), nil
+}
+
+// A SpyTB is a testing.TB that records output and failures instead of
+// reporting them, which is useful when testing helpers that take a
+// testing.TB. Only the Log, Logf, Fail, FailNow, and Failed methods are
+// implemented; calling any other method panics. Unlike a real test's
+// FailNow, the spy's FailNow returns. SpyTBs are safe for concurrent use.
+type SpyTB struct {
+	testing.TB
+
+	mu        sync.Mutex
+	logs      []string
+	failed    bool
+	failedNow bool
+}
+
+// Log records the supplied arguments, formatted like fmt.Sprint.
+func (t *SpyTB) Log(args ...interface{}) {
+	t.record(fmt.Sprint(args...))
+}
+
+// Logf records the supplied arguments, formatted like fmt.Sprintf.
+func (t *SpyTB) Logf(format string, args ...interface{}) {
+	t.record(fmt.Sprintf(format, args...))
+}
+
+// Fail marks the spy as failed.
+func (t *SpyTB) Fail() {
+	t.mu.Lock()
+	t.failed = true
+	t.mu.Unlock()
+}
+
+// FailNow marks the spy as failed and records that the test would have
+// stopped.
+func (t *SpyTB) FailNow() {
+	t.mu.Lock()
+	t.failed, t.failedNow = true, true
+	t.mu.Unlock()
+}
+
+// Failed reports whether Fail or FailNow was called.
+func (t *SpyTB) Failed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.failed
+}
+
+// FailedNow reports whether FailNow was called.
+func (t *SpyTB) FailedNow() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.failedNow
+}
+
+// Logs returns the output recorded so far.
+func (t *SpyTB) Logs() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.logs...)
+}
+
+// Reset clears the recorded output and failures.
+func (t *SpyTB) Reset() {
+	t.mu.Lock()
+	t.logs, t.failed, t.failedNow = nil, false, false
+	t.mu.Unlock()
+}
+
+func (t *SpyTB) record(s string) {
+	t.mu.Lock()
+	t.logs = append(t.logs, s)
+	t.mu.Unlock()
+}
@@ -37,18 +37,25 @@ var textPool = sync.Pool{New: func() interface{} {
 type textEncoder struct {
 	bytes       []byte
 	timeFmt     string
+	timeEnc     TimeEncoder
+	durationEnc DurationEncoder
+	levelEnc    LevelEncoder
+	nameKey     string
 	firstNested bool
+	elements    int // in the innermost array being encoded
 }
 
 // NewTextEncoder creates a line-oriented text encoder whose output is optimized
 // for human, rather than machine, consumption. By default, the encoder uses
-// RFC3339-formatted timestamps.
+// RFC3339-formatted timestamps and adds the logger's name (if any) under the
+// "logger" key.
 func NewTextEncoder(options ...TextOption) Encoder {
 	enc := textPool.Get().(*textEncoder)
 	enc.truncate()
 	enc.timeFmt = time.RFC3339
+	enc.nameKey = "logger"
 	for _, opt := range options {
-		opt.apply(enc)
+		opt.applyText(enc)
 	}
 	return enc
 }
@@ -96,6 +103,24 @@ func (enc *textEncoder) AddFloat64(key string, val float64) {
 	enc.bytes = strconv.AppendFloat(enc.bytes, val, 'f', -1, 64)
 }
 
+func (enc *textEncoder) AddTime(key string, val time.Time) {
+	if enc.timeEnc == nil {
+		enc.AddFloat64(key, timeToSeconds(val))
+		return
+	}
+	enc.addKey(key)
+	enc.encodeTime(enc.timeEnc, val)
+}
+
+func (enc *textEncoder) AddDuration(key string, val time.Duration) {
+	if enc.durationEnc == nil {
+		enc.AddInt64(key, int64(val))
+		return
+	}
+	enc.addKey(key)
+	enc.encodeDuration(enc.durationEnc, val)
+}
+
 func (enc *textEncoder) AddMarshaler(key string, obj LogMarshaler) error {
 	enc.addKey(key)
 	enc.firstNested = true
@@ -106,30 +131,106 @@ func (enc *textEncoder) AddMarshaler(key string, obj LogMarshaler) error {
 	return err
 }
 
+func (enc *textEncoder) AddArray(key string, arr ArrayMarshaler) error {
+	enc.addKey(key)
+	return enc.appendArray(arr)
+}
+
 func (enc *textEncoder) AddObject(key string, obj interface{}) error {
 	enc.AddString(key, fmt.Sprintf("%+v", obj))
 	return nil
 }
 
+func (enc *textEncoder) AppendString(val string) {
+	enc.addElementSeparator()
+	enc.bytes = append(enc.bytes, val...)
+}
+
+func (enc *textEncoder) AppendBool(val bool) {
+	enc.addElementSeparator()
+	enc.bytes = strconv.AppendBool(enc.bytes, val)
+}
+
+func (enc *textEncoder) AppendInt(val int) {
+	enc.AppendInt64(int64(val))
+}
+
+func (enc *textEncoder) AppendInt64(val int64) {
+	enc.addElementSeparator()
+	enc.bytes = strconv.AppendInt(enc.bytes, val, 10)
+}
+
+func (enc *textEncoder) AppendUint(val uint) {
+	enc.AppendUint64(uint64(val))
+}
+
+func (enc *textEncoder) AppendUint64(val uint64) {
+	enc.addElementSeparator()
+	enc.bytes = strconv.AppendUint(enc.bytes, val, 10)
+}
+
+func (enc *textEncoder) AppendFloat64(val float64) {
+	enc.addElementSeparator()
+	enc.bytes = strconv.AppendFloat(enc.bytes, val, 'f', -1, 64)
+}
+
+func (enc *textEncoder) AppendTime(val time.Time) {
+	if enc.timeEnc == nil {
+		enc.AppendFloat64(timeToSeconds(val))
+		return
+	}
+	enc.addElementSeparator()
+	enc.encodeTime(enc.timeEnc, val)
+}
+
+func (enc *textEncoder) AppendDuration(val time.Duration) {
+	if enc.durationEnc == nil {
+		enc.AppendInt64(int64(val))
+		return
+	}
+	enc.addElementSeparator()
+	enc.encodeDuration(enc.durationEnc, val)
+}
+
+func (enc *textEncoder) AppendArray(arr ArrayMarshaler) error {
+	enc.addElementSeparator()
+	return enc.appendArray(arr)
+}
+
+func (enc *textEncoder) AppendMarshaler(obj LogMarshaler) error {
+	enc.addElementSeparator()
+	enc.firstNested = true
+	enc.bytes = append(enc.bytes, '{')
+	err := obj.MarshalLog(enc)
+	enc.bytes = append(enc.bytes, '}')
+	enc.firstNested = false
+	return err
+}
+
 func (enc *textEncoder) Clone() Encoder {
 	clone := textPool.Get().(*textEncoder)
 	clone.truncate()
 	clone.bytes = append(clone.bytes, enc.bytes...)
 	clone.timeFmt = enc.timeFmt
+	clone.timeEnc = enc.timeEnc
+	clone.durationEnc = enc.durationEnc
+	clone.levelEnc = enc.levelEnc
+	clone.nameKey = enc.nameKey
 	clone.firstNested = enc.firstNested
 	return clone
 }
 
-func (enc *textEncoder) WriteEntry(sink io.Writer, msg string, lvl Level, t time.Time) error {
+func (enc *textEncoder) WriteEntry(sink io.Writer, ent Entry) error {
 	if sink == nil {
 		return errNilSink
 	}
 
 	final := textPool.Get().(*textEncoder)
 	final.truncate()
-	enc.addLevel(final, lvl)
-	enc.addTime(final, t)
-	enc.addMessage(final, msg)
+	enc.addLevel(final, ent.Level)
+	enc.addTime(final, ent.Time)
+	enc.addMessage(final, ent.Message)
+	enc.addName(final, ent.LoggerName)
 
 	if len(enc.bytes) > 0 {
 		final.bytes = append(final.bytes, ' ')
@@ -151,6 +252,7 @@ func (enc *textEncoder) WriteEntry(sink io.Writer, msg string, lvl Level, t time
 
 func (enc *textEncoder) truncate() {
 	enc.bytes = enc.bytes[:0]
+	enc.elements = 0
 }
 
 func (enc *textEncoder) addKey(key string) {
@@ -164,8 +266,57 @@ func (enc *textEncoder) addKey(key string) {
 	enc.bytes = append(enc.bytes, '=')
 }
 
+// addElementSeparator adds a space between array elements. It counts
+// elements rather than inspecting the previous byte, since elements (e.g.,
+// empty strings) may not add any bytes.
+func (enc *textEncoder) addElementSeparator() {
+	if enc.elements > 0 {
+		enc.bytes = append(enc.bytes, ' ')
+	}
+	enc.elements++
+}
+
+func (enc *textEncoder) appendArray(arr ArrayMarshaler) error {
+	outer := enc.elements
+	enc.elements = 0
+	enc.bytes = append(enc.bytes, '[')
+	err := arr.MarshalLogArray(enc)
+	enc.bytes = append(enc.bytes, ']')
+	enc.elements = outer
+	return err
+}
+
+// encodeTime, encodeDuration, and encodeLevel run user-supplied value
+// encoders, which append exactly one element, without separating that
+// element from whatever precedes it.
+func (enc *textEncoder) encodeTime(te TimeEncoder, t time.Time) {
+	outer := enc.elements
+	enc.elements = 0
+	te(t, enc)
+	enc.elements = outer
+}
+
+func (enc *textEncoder) encodeDuration(de DurationEncoder, d time.Duration) {
+	outer := enc.elements
+	enc.elements = 0
+	de(d, enc)
+	enc.elements = outer
+}
+
+func (enc *textEncoder) encodeLevel(le LevelEncoder, l Level) {
+	outer := enc.elements
+	enc.elements = 0
+	le(l, enc)
+	enc.elements = outer
+}
+
 func (enc *textEncoder) addLevel(final *textEncoder, lvl Level) {
 	final.bytes = append(final.bytes, '[')
+	if enc.levelEnc != nil {
+		final.encodeLevel(enc.levelEnc, lvl)
+		final.bytes = append(final.bytes, ']')
+		return
+	}
 	switch lvl {
 	case DebugLevel:
 		final.bytes = append(final.bytes, 'D')
@@ -190,6 +341,10 @@ func (enc *textEncoder) addTime(final *textEncoder, t time.Time) {
 		return
 	}
 	final.bytes = append(final.bytes, ' ')
+	if enc.timeEnc != nil {
+		final.encodeTime(enc.timeEnc, t)
+		return
+	}
 	final.bytes = t.AppendFormat(final.bytes, enc.timeFmt)
 }
 
@@ -198,17 +353,44 @@ func (enc *textEncoder) addMessage(final *textEncoder, msg string) {
 	final.bytes = append(final.bytes, msg...)
 }
 
+func (enc *textEncoder) addName(final *textEncoder, name string) {
+	if enc.nameKey == "" || name == "" {
+		return
+	}
+	final.bytes = append(final.bytes, ' ')
+	final.bytes = append(final.bytes, enc.nameKey...)
+	final.bytes = append(final.bytes, '=')
+	final.bytes = append(final.bytes, name...)
+}
+
 // A TextOption is used to set options for a text encoder.
 type TextOption interface {
-	apply(*textEncoder)
+	applyText(*textEncoder)
 }
 
 type textOptionFunc func(*textEncoder)
 
-func (opt textOptionFunc) apply(enc *textEncoder) {
+func (opt textOptionFunc) applyText(enc *textEncoder) {
 	opt(enc)
 }
 
+// A TimeEncoder supplied to the text encoder serializes both time fields and
+// the entry's timestamp, in place of the TextTimeFormat layout. TextNoTime
+// still omits the entry's timestamp.
+func (te TimeEncoder) applyText(enc *textEncoder) {
+	enc.timeEnc = te
+}
+
+func (de DurationEncoder) applyText(enc *textEncoder) {
+	enc.durationEnc = de
+}
+
+// A LevelEncoder supplied to the text encoder serializes the entry's level
+// inside the usual brackets, in place of the single-letter abbreviation.
+func (le LevelEncoder) applyText(enc *textEncoder) {
+	enc.levelEnc = le
+}
+
 // TextTimeFormat sets the format for log timestamps, using the same layout
 // strings supported by time.Parse.
 func TextTimeFormat(layout string) TextOption {
@@ -221,3 +403,12 @@ func TextTimeFormat(layout string) TextOption {
 func TextNoTime() TextOption {
 	return TextTimeFormat("")
 }
+
+// TextNameKey sets the key under which the logger's name is added to named
+// loggers' entries. Passing an empty key omits logger names from the
+// serialized log entries.
+func TextNameKey(key string) TextOption {
+	return textOptionFunc(func(enc *textEncoder) {
+		enc.nameKey = key
+	})
+}
@@ -87,6 +87,32 @@ func TestTextEncoderFields(t *testing.T) {
 		{"marshaler", "k={}", func(e Encoder) {
 			assert.Error(t, e.AddMarshaler("k", loggable{false}), "Expected an error calling MarshalLog.")
 		}},
+		{"array", "k=[1 2 3]", func(e Encoder) {
+			assert.NoError(t, e.AddArray("k", ints{1, 2, 3}), "Unexpected error adding an array.")
+		}},
+		{"empty elements", "k=[ a] k2=[[ b] c]", func(e Encoder) {
+			Strings("k", []string{"", "a"}).AddTo(e)
+			e.AddArray("k2", ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+				arr.AppendArray(ArrayMarshalerFunc(func(inner ArrayEncoder) error {
+					inner.AppendString("")
+					inner.AppendString("b")
+					return nil
+				}))
+				arr.AppendString("c")
+				return nil
+			}))
+		}},
+		{"array", "k=[a true -1 1 2.5 [] {loggable=yes}]", func(e Encoder) {
+			assert.NoError(t, e.AddArray("k", ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+				arr.AppendString("a")
+				arr.AppendBool(true)
+				arr.AppendInt64(-1)
+				arr.AppendUint(1)
+				arr.AppendFloat64(2.5)
+				arr.AppendArray(ints{})
+				return arr.AppendMarshaler(loggable{true})
+			})), "Unexpected error adding an array.")
+		}},
 		{"ints", "k=[1 2 3]", func(e Encoder) { e.AddObject("k", []int{1, 2, 3}) }},
 		{"strings", `k=[bar 1 bar 2 bar 3]`,
 			func(e Encoder) {
@@ -132,13 +158,28 @@ func TestTextWriteEntry(t *testing.T) {
 			expected: "[I] Something happened.",
 			name:     "NoTime",
 		},
+		{
+			enc:      NewTextEncoder(EpochMillisTimeEncoder()),
+			expected: "[I] 0 Something happened.",
+			name:     "TimeEncoder",
+		},
+		{
+			enc:      NewTextEncoder(EpochMillisTimeEncoder(), TextNoTime()),
+			expected: "[I] Something happened.",
+			name:     "TimeEncoder and NoTime",
+		},
+		{
+			enc:      NewTextEncoder(CapitalLevelEncoder()),
+			expected: "[INFO] 1970-01-01T00:00:00Z Something happened.",
+			name:     "LevelEncoder",
+		},
 	}
 
 	sink := &testBuffer{}
 	for _, tt := range tests {
 		assert.NoError(
 			t,
-			tt.enc.WriteEntry(sink, entry.Message, entry.Level, entry.Time),
+			tt.enc.WriteEntry(sink, *entry),
 			"Unexpected failure writing entry with text time formatter %s.", tt.name,
 		)
 		assert.Equal(t, tt.expected, sink.Stripped(), "Unexpected output from text time formatter %s.", tt.name)
@@ -146,6 +187,39 @@ func TestTextWriteEntry(t *testing.T) {
 	}
 }
 
+func TestTextWriteEntryLoggerName(t *testing.T) {
+	entry := Entry{Level: InfoLevel, Message: "Something happened.", LoggerName: "rpc.client"}
+	tests := []struct {
+		enc      Encoder
+		expected string
+		name     string
+	}{
+		{
+			enc:      NewTextEncoder(TextNoTime()),
+			expected: "[I] Something happened. logger=rpc.client foo=bar",
+			name:     "default key",
+		},
+		{
+			enc:      NewTextEncoder(TextNoTime(), TextNameKey("name")),
+			expected: "[I] Something happened. name=rpc.client foo=bar",
+			name:     "custom key",
+		},
+		{
+			enc:      NewTextEncoder(TextNoTime(), TextNameKey("")),
+			expected: "[I] Something happened. foo=bar",
+			name:     "empty key",
+		},
+	}
+
+	sink := &testBuffer{}
+	for _, tt := range tests {
+		tt.enc.AddString("foo", "bar")
+		assert.NoError(t, tt.enc.WriteEntry(sink, entry), "Unexpected failure writing entry with %s.", tt.name)
+		assert.Equal(t, tt.expected, sink.Stripped(), "Unexpected output writing logger name with %s.", tt.name)
+		sink.Reset()
+	}
+}
+
 func TestTextWriteEntryLevels(t *testing.T) {
 	tests := []struct {
 		level    Level
@@ -165,7 +239,7 @@ func TestTextWriteEntryLevels(t *testing.T) {
 	for _, tt := range tests {
 		assert.NoError(
 			t,
-			enc.WriteEntry(sink, "Fake message.", tt.level, epoch),
+			enc.WriteEntry(sink, Entry{Message: "Fake message.", Level: tt.level, Time: epoch}),
 			"Unexpected failure writing entry with level %s.", tt.level,
 		)
 		expected := fmt.Sprintf("[%s] Fake message.", tt.expected)
@@ -197,7 +271,7 @@ func TestTextWriteEntryFailure(t *testing.T) {
 			{spywrite.ShortWriter{}, "Expected an error on partial writes to sink."},
 		}
 		for _, tt := range tests {
-			err := enc.WriteEntry(tt.sink, "hello", InfoLevel, time.Unix(0, 0))
+			err := enc.WriteEntry(tt.sink, Entry{Message: "hello", Level: InfoLevel, Time: time.Unix(0, 0)})
 			assert.Error(t, err, tt.msg)
 		}
 	})
@@ -211,7 +285,7 @@ func TestTextTimeOptions(t *testing.T) {
 
 	sink := &testBuffer{}
 	enc.AddString("foo", "bar")
-	err := enc.WriteEntry(sink, entry.Message, entry.Level, entry.Time)
+	err := enc.WriteEntry(sink, *entry)
 	assert.NoError(t, err, "WriteEntry returned an unexpected error.")
 	assert.Equal(
 		t,
@@ -219,3 +293,22 @@ func TestTextTimeOptions(t *testing.T) {
 		sink.Stripped(),
 	)
 }
+
+func TestTextValueEncoders(t *testing.T) {
+	enc := newTextEncoder(RFC3339TimeEncoder(), StringDurationEncoder())
+	defer enc.Free()
+
+	enc.AddTime("t", time.Date(2400, 1, 1, 0, 0, 0, 0, time.UTC))
+	enc.AddDuration("d", time.Second)
+	enc.AddArray("a", ArrayMarshalerFunc(func(arr ArrayEncoder) error {
+		arr.AppendTime(time.Unix(0, 0).UTC())
+		arr.AppendDuration(time.Millisecond)
+		return nil
+	}))
+	assert.Equal(
+		t,
+		"t=2400-01-01T00:00:00Z d=1s a=[1970-01-01T00:00:00Z 1ms]",
+		string(enc.bytes),
+		"Expected the TimeEncoder and DurationEncoder to serialize fields and array elements.",
+	)
+}
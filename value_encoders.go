@@ -0,0 +1,203 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import "time"
+
+const _iso8601Format = "2006-01-02T15:04:05.000Z0700"
+
+// A TimeEncoder serializes a time.Time to a primitive type. It's used to
+// render the Time and Times fields, as well as the entry time when it's
+// formatted with TimeKey. Implementations must append exactly one value.
+//
+// TimeEncoders implement the JSONOption, LogfmtOption, SyslogOption, and
+// TextOption interfaces.
+type TimeEncoder func(time.Time, ArrayEncoder)
+
+func (te TimeEncoder) apply(enc *jsonEncoder) {
+	enc.timeEnc = te
+}
+
+// EpochTimeEncoder serializes a time.Time to a floating-point number of
+// seconds since the Unix epoch. It's the default for the JSON and logfmt
+// encoders.
+func EpochTimeEncoder() TimeEncoder {
+	return TimeEncoder(func(t time.Time, enc ArrayEncoder) {
+		enc.AppendFloat64(timeToSeconds(t))
+	})
+}
+
+// EpochMillisTimeEncoder serializes a time.Time to a floating-point number of
+// milliseconds since the Unix epoch.
+func EpochMillisTimeEncoder() TimeEncoder {
+	return TimeEncoder(func(t time.Time, enc ArrayEncoder) {
+		enc.AppendFloat64(float64(t.UnixNano()) / float64(time.Millisecond))
+	})
+}
+
+// EpochNanosTimeEncoder serializes a time.Time to an integer number of
+// nanoseconds since the Unix epoch.
+func EpochNanosTimeEncoder() TimeEncoder {
+	return TimeEncoder(func(t time.Time, enc ArrayEncoder) {
+		enc.AppendInt64(t.UnixNano())
+	})
+}
+
+// RFC3339TimeEncoder serializes a time.Time to an RFC3339-formatted string
+// with second precision.
+func RFC3339TimeEncoder() TimeEncoder {
+	return TimeEncoder(func(t time.Time, enc ArrayEncoder) {
+		enc.AppendString(t.Format(time.RFC3339))
+	})
+}
+
+// RFC3339NanoTimeEncoder serializes a time.Time to an RFC3339-formatted string
+// with nanosecond precision.
+func RFC3339NanoTimeEncoder() TimeEncoder {
+	return TimeEncoder(func(t time.Time, enc ArrayEncoder) {
+		enc.AppendString(t.Format(time.RFC3339Nano))
+	})
+}
+
+// ISO8601TimeEncoder serializes a time.Time to an ISO8601-formatted string
+// with millisecond precision and a numeric zone offset (for example,
+// 2016-08-26T13:04:05.123-0700).
+func ISO8601TimeEncoder() TimeEncoder {
+	return TimeEncoder(func(t time.Time, enc ArrayEncoder) {
+		enc.AppendString(t.Format(_iso8601Format))
+	})
+}
+
+// A DurationEncoder serializes a time.Duration to a primitive type. It's used
+// to render the Duration and Durations fields. Implementations must append
+// exactly one value.
+//
+// DurationEncoders implement the JSONOption, LogfmtOption, SyslogOption, and
+// TextOption interfaces.
+type DurationEncoder func(time.Duration, ArrayEncoder)
+
+func (de DurationEncoder) apply(enc *jsonEncoder) {
+	enc.durationEnc = de
+}
+
+// NanosDurationEncoder serializes a time.Duration to an integer number of
+// nanoseconds. It's the default for the JSON and logfmt encoders.
+func NanosDurationEncoder() DurationEncoder {
+	return DurationEncoder(func(d time.Duration, enc ArrayEncoder) {
+		enc.AppendInt64(int64(d))
+	})
+}
+
+// SecondsDurationEncoder serializes a time.Duration to a floating-point
+// number of seconds.
+func SecondsDurationEncoder() DurationEncoder {
+	return DurationEncoder(func(d time.Duration, enc ArrayEncoder) {
+		enc.AppendFloat64(d.Seconds())
+	})
+}
+
+// StringDurationEncoder serializes a time.Duration using its String method
+// (for example, "1.5s").
+func StringDurationEncoder() DurationEncoder {
+	return DurationEncoder(func(d time.Duration, enc ArrayEncoder) {
+		enc.AppendString(d.String())
+	})
+}
+
+// A LevelEncoder serializes a Level to a primitive type. It's used to render
+// the entry's level when it's formatted with LevelKey. Implementations must
+// append exactly one value.
+//
+// LevelEncoders implement the JSONOption, LogfmtOption, and TextOption
+// interfaces.
+type LevelEncoder func(Level, ArrayEncoder)
+
+func (le LevelEncoder) apply(enc *jsonEncoder) {
+	enc.levelEnc = le
+}
+
+// LowercaseLevelEncoder serializes a Level to a lowercase string (for example,
+// "info"). It's the default for the JSON and logfmt encoders.
+func LowercaseLevelEncoder() LevelEncoder {
+	return LevelEncoder(func(l Level, enc ArrayEncoder) {
+		enc.AppendString(l.String())
+	})
+}
+
+// CapitalLevelEncoder serializes a Level to an all-caps string (for example,
+// "INFO").
+func CapitalLevelEncoder() LevelEncoder {
+	return LevelEncoder(func(l Level, enc ArrayEncoder) {
+		enc.AppendString(capitalLevelName(l))
+	})
+}
+
+// CapitalColorLevelEncoder serializes a Level to an all-caps string wrapped in
+// the ANSI escape codes used by the console encoder (for example, blue for
+// "INFO"). It's only useful when the output is a terminal.
+func CapitalColorLevelEncoder() LevelEncoder {
+	return LevelEncoder(func(l Level, enc ArrayEncoder) {
+		enc.AppendString(levelColor(l) + capitalLevelName(l) + _colorReset)
+	})
+}
+
+// SyslogLevelEncoder serializes a Level to the integer severity defined by
+// RFC 5424: debug is 7, info is 6, warn is 4, error is 3, and the DPanic,
+// Panic, and Fatal levels are all 2 (critical).
+func SyslogLevelEncoder() LevelEncoder {
+	return LevelEncoder(func(l Level, enc ArrayEncoder) {
+		enc.AppendInt(syslogSeverity(l))
+	})
+}
+
+func syslogSeverity(l Level) int {
+	switch l {
+	case DebugLevel:
+		return 7
+	case InfoLevel:
+		return 6
+	case WarnLevel:
+		return 4
+	case ErrorLevel:
+		return 3
+	case DPanicLevel, PanicLevel, FatalLevel:
+		return 2
+	default:
+		// Custom levels are treated as notices, between info and warn.
+		return 5
+	}
+}
+
+// levelAdder is implemented by encoders that render levels with a
+// LevelEncoder.
+type levelAdder interface {
+	addLevel(key string, lvl Level)
+}
+
+// addLevel adds a level to a KeyValue, falling back to the level's String
+// method if the KeyValue doesn't support LevelEncoders.
+func addLevel(kv KeyValue, key string, lvl Level) {
+	if la, ok := kv.(levelAdder); ok {
+		la.addLevel(key, lvl)
+		return
+	}
+	kv.AddString(key, lvl.String())
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertValueEncoderJSON(t testing.TB, expected string, opt JSONOption, f func(*jsonEncoder)) {
+	enc := newJSONEncoder(opt)
+	defer enc.Free()
+
+	f(enc)
+	assert.Equal(t, expected, string(enc.bytes), "Unexpected output from value encoder.")
+}
+
+func TestTimeEncoders(t *testing.T) {
+	moment := time.Unix(1, int64(500*time.Millisecond)).In(time.FixedZone("", -7*60*60))
+	tests := []struct {
+		name     string
+		te       TimeEncoder
+		expected string
+	}{
+		{"Epoch", EpochTimeEncoder(), `1.5`},
+		{"EpochMillis", EpochMillisTimeEncoder(), `1500`},
+		{"EpochNanos", EpochNanosTimeEncoder(), `1500000000`},
+		{"RFC3339", RFC3339TimeEncoder(), `"1969-12-31T17:00:01-07:00"`},
+		{"RFC3339Nano", RFC3339NanoTimeEncoder(), `"1969-12-31T17:00:01.5-07:00"`},
+		{"ISO8601", ISO8601TimeEncoder(), `"1969-12-31T17:00:01.500-0700"`},
+	}
+
+	for _, tt := range tests {
+		assertValueEncoderJSON(t, `"k":`+tt.expected, tt.te, func(enc *jsonEncoder) {
+			Time("k", moment).AddTo(enc)
+		})
+		assertValueEncoderJSON(t, `"k":[`+tt.expected+`,`+tt.expected+`]`, tt.te, func(enc *jsonEncoder) {
+			Times("k", []time.Time{moment, moment}).AddTo(enc)
+		})
+	}
+}
+
+func TestDurationEncoders(t *testing.T) {
+	tests := []struct {
+		name     string
+		de       DurationEncoder
+		expected string
+	}{
+		{"Nanos", NanosDurationEncoder(), `1500000000`},
+		{"Seconds", SecondsDurationEncoder(), `1.5`},
+		{"String", StringDurationEncoder(), `"1.5s"`},
+	}
+
+	for _, tt := range tests {
+		d := 1500 * time.Millisecond
+		assertValueEncoderJSON(t, `"k":`+tt.expected, tt.de, func(enc *jsonEncoder) {
+			Duration("k", d).AddTo(enc)
+		})
+		assertValueEncoderJSON(t, `"k":[`+tt.expected+`,`+tt.expected+`]`, tt.de, func(enc *jsonEncoder) {
+			Durations("k", []time.Duration{d, d}).AddTo(enc)
+		})
+	}
+}
+
+func TestLevelEncoders(t *testing.T) {
+	tests := []struct {
+		name     string
+		le       LevelEncoder
+		lvl      Level
+		expected string
+	}{
+		{"Lowercase", LowercaseLevelEncoder(), WarnLevel, `"warn"`},
+		{"Capital", CapitalLevelEncoder(), DPanicLevel, `"DPANIC"`},
+		{"CapitalColor", CapitalColorLevelEncoder(), InfoLevel, `"\u001b[34mINFO\u001b[0m"`},
+		{"Syslog", SyslogLevelEncoder(), DebugLevel, `7`},
+		{"Syslog", SyslogLevelEncoder(), InfoLevel, `6`},
+		{"Syslog", SyslogLevelEncoder(), WarnLevel, `4`},
+		{"Syslog", SyslogLevelEncoder(), ErrorLevel, `3`},
+		{"Syslog", SyslogLevelEncoder(), FatalLevel, `2`},
+		{"Syslog", SyslogLevelEncoder(), Level(42), `5`},
+	}
+
+	for _, tt := range tests {
+		assertValueEncoderJSON(t, `"k":`+tt.expected, tt.le, func(enc *jsonEncoder) {
+			LevelKey("k")(tt.lvl).AddTo(enc)
+		})
+	}
+}
+
+func TestLevelKeyFallback(t *testing.T) {
+	// KeyValues that don't support LevelEncoders get the level's string.
+	enc := newTextEncoder()
+	defer enc.Free()
+	LevelKey("k")(WarnLevel).AddTo(enc)
+	assert.Equal(t, "k=warn", string(enc.bytes), "Unexpected fallback encoding for LevelKey.")
+}
@@ -78,6 +78,10 @@ func (s *lockedWriteSyncer) Write(bs []byte) (int, error) {
 	return n, err
 }
 
+func (s *lockedWriteSyncer) IsTerminal() bool {
+	return isTerminal(s.ws)
+}
+
 func (s *lockedWriteSyncer) Sync() error {
 	s.Lock()
 	err := s.ws.Sync()
@@ -93,6 +97,10 @@ func (w writerWrapper) Sync() error {
 	return nil
 }
 
+func (w writerWrapper) IsTerminal() bool {
+	return isTerminal(w.Writer)
+}
+
 type flusherWrapper struct {
 	WriteFlusher
 }
@@ -162,4 +170,10 @@ func (m multiError) Error() string {
 	return sb.String()
 }
 
+// Errors returns the underlying errors, so that error fields can log them
+// individually.
+func (m multiError) Errors() []error {
+	return m
+}
+
 type multiWriteSyncer []WriteSyncer
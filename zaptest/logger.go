@@ -0,0 +1,226 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zaptest
+
+import (
+	"testing"
+
+	"github.com/uber-go/zap"
+	"github.com/uber-go/zap/spywrite"
+	"github.com/uber-go/zap/testutils"
+)
+
+// An Option configures a test logger.
+type Option interface {
+	apply(*config)
+}
+
+type optionFunc func(*config)
+
+func (f optionFunc) apply(c *config) {
+	f(c)
+}
+
+type config struct {
+	enc    zap.Encoder
+	level  zap.LevelEnabler
+	fail   bool
+	failAt zap.Level
+	opts   []zap.Option
+}
+
+// Encoder sets the encoder used to format entries. The default is a console
+// encoder without colors.
+func Encoder(enc zap.Encoder) Option {
+	return optionFunc(func(c *config) {
+		c.enc = enc
+	})
+}
+
+// Level sets the minimum enabled level. The default is DebugLevel.
+func Level(enab zap.LevelEnabler) Option {
+	return optionFunc(func(c *config) {
+		c.level = enab
+	})
+}
+
+// FailAt marks the test as failed whenever the logger is called at the
+// supplied level or above, whether or not that level is enabled. For example,
+// FailAt(zap.ErrorLevel) fails the test on any Error or DPanic call. The test
+// keeps running, so FailAt is safe to use from any goroutine.
+func FailAt(lvl zap.Level) Option {
+	return optionFunc(func(c *config) {
+		c.fail = true
+		c.failAt = lvl
+	})
+}
+
+// WrapOptions passes the supplied options (e.g., zap.Fields or zap.AddCaller)
+// through to the underlying logger. Output-related options are overridden.
+func WrapOptions(opts ...zap.Option) Option {
+	return optionFunc(func(c *config) {
+		c.opts = append(c.opts, opts...)
+	})
+}
+
+// NewLogger constructs a Logger that writes each encoded entry, along with
+// any internal errors, to the supplied testing.TB via its Logf method.
+//
+// Since the logger belongs to a test, neither Fatal nor Panic ends the
+// program: Fatal doesn't call os.Exit, and Panic doesn't panic. Instead, both
+// write the entry and then call the test's FailNow method, so code following
+// a Fatal or Panic call doesn't run, as usual. Like FailNow itself, Fatal and
+// Panic must be called from the goroutine running the test. On any other
+// goroutine, they mark the test as failed and exit only the calling
+// goroutine; the test itself keeps running.
+func NewLogger(t testing.TB, opts ...Option) zap.Logger {
+	cfg := config{
+		enc:   zap.NewConsoleEncoder(zap.ConsoleColors(false)),
+		level: zap.DebugLevel,
+	}
+	for _, opt := range opts {
+		opt.apply(&cfg)
+	}
+
+	// Levels, AtomicLevels, and LevelRegistries are options themselves.
+	level, ok := cfg.level.(zap.Option)
+	if !ok {
+		level = zap.LevelEnablerFunc(cfg.level.Enabled)
+	}
+
+	zopts := make([]zap.Option, 0, len(cfg.opts)+3)
+	zopts = append(zopts, cfg.opts...)
+	zopts = append(zopts,
+		level,
+		zap.Output(&spywrite.WriteSyncer{Writer: testutils.TBWriter{TB: t}}),
+		zap.ErrorOutput(&spywrite.WriteSyncer{Writer: testutils.TBWriter{TB: t}}),
+	)
+	return &logger{
+		Logger: zap.New(cfg.enc, zopts...),
+		t:      t,
+		fail:   cfg.fail,
+		failAt: cfg.failAt,
+	}
+}
+
+type logger struct {
+	zap.Logger
+
+	t      testing.TB
+	fail   bool
+	failAt zap.Level
+}
+
+func (l *logger) With(fields ...zap.Field) zap.Logger {
+	return l.wrap(l.Logger.With(fields...))
+}
+
+func (l *logger) Named(name string) zap.Logger {
+	return l.wrap(l.Logger.Named(name))
+}
+
+func (l *logger) Check(lvl zap.Level, msg string) *zap.CheckedMessage {
+	// Check the level directly rather than with the wrapped logger's Check,
+	// which would allocate a CheckedMessage only to discard it.
+	switch lvl {
+	case zap.PanicLevel, zap.FatalLevel:
+		// Like the wrapped logger, always fail the test.
+	case zap.DPanicLevel:
+		if !zap.IsDevelopment(l.Logger) && !l.enabled(lvl) {
+			l.check(lvl)
+			return nil
+		}
+	default:
+		if !l.enabled(lvl) {
+			l.check(lvl)
+			return nil
+		}
+	}
+	// Route writes through this logger, so that Fatal and FailAt apply.
+	return zap.NewCheckedMessage(l, lvl, msg)
+}
+
+func (l *logger) Log(lvl zap.Level, msg string, fields ...zap.Field) {
+	l.Logger.Log(lvl, msg, fields...)
+	l.check(lvl)
+}
+
+func (l *logger) Debug(msg string, fields ...zap.Field) {
+	l.Logger.Debug(msg, fields...)
+	l.check(zap.DebugLevel)
+}
+
+func (l *logger) Info(msg string, fields ...zap.Field) {
+	l.Logger.Info(msg, fields...)
+	l.check(zap.InfoLevel)
+}
+
+func (l *logger) Warn(msg string, fields ...zap.Field) {
+	l.Logger.Warn(msg, fields...)
+	l.check(zap.WarnLevel)
+}
+
+func (l *logger) Error(msg string, fields ...zap.Field) {
+	l.Logger.Error(msg, fields...)
+	l.check(zap.ErrorLevel)
+}
+
+func (l *logger) DPanic(msg string, fields ...zap.Field) {
+	// Check first, since the underlying logger may panic.
+	l.check(zap.DPanicLevel)
+	l.Logger.DPanic(msg, fields...)
+}
+
+func (l *logger) Panic(msg string, fields ...zap.Field) {
+	l.Logger.Log(zap.PanicLevel, msg, fields...)
+	l.t.FailNow()
+}
+
+func (l *logger) Fatal(msg string, fields ...zap.Field) {
+	l.Logger.Log(zap.FatalLevel, msg, fields...)
+	l.t.FailNow()
+}
+
+func (l *logger) IsDevelopment() bool {
+	return zap.IsDevelopment(l.Logger)
+}
+
+func (l *logger) wrap(log zap.Logger) zap.Logger {
+	return &logger{
+		Logger: log,
+		t:      l.t,
+		fail:   l.fail,
+		failAt: l.failAt,
+	}
+}
+
+func (l *logger) enabled(lvl zap.Level) bool {
+	if enab, ok := l.Logger.(zap.LevelEnabler); ok {
+		return enab.Enabled(lvl)
+	}
+	return true
+}
+
+func (l *logger) check(lvl zap.Level) {
+	if l.fail && lvl >= l.failAt {
+		l.t.Fail()
+	}
+}
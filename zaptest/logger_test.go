@@ -0,0 +1,164 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zaptest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/uber-go/zap"
+	"github.com/uber-go/zap/testutils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLogger(opts ...Option) (zap.Logger, *testutils.SpyTB) {
+	ft := &testutils.SpyTB{}
+	opts = append([]Option{Encoder(zap.NewJSONEncoder(zap.NoTime()))}, opts...)
+	return NewLogger(ft, opts...), ft
+}
+
+func TestLoggerWritesToTB(t *testing.T) {
+	logger, ft := newTestLogger(WrapOptions(zap.Fields(zap.Int("foo", 42))))
+	logger.Debug("debug")
+	logger.With(zap.String("bar", "baz")).Named("child").Info("info")
+	logger.Check(zap.WarnLevel, "warn").Write()
+	logger.Error("error")
+
+	assert.Equal(t, []string{
+		`{"level":"debug","msg":"debug","foo":42}`,
+		`{"level":"info","logger":"child","msg":"info","foo":42,"bar":"baz"}`,
+		`{"level":"warn","msg":"warn","foo":42}`,
+		`{"level":"error","msg":"error","foo":42}`,
+	}, ft.Logs(), "Unexpected output written to the testing.TB.")
+	assert.False(t, ft.Failed(), "Expected logging not to fail the test by default.")
+	assert.NoError(t, logger.Sync(), "Unexpected error syncing.")
+}
+
+func TestLoggerDefaultEncoder(t *testing.T) {
+	ft := &testutils.SpyTB{}
+	NewLogger(ft).Info("foo", zap.Int("n", 1))
+	if assert.Len(t, ft.Logs(), 1, "Expected a single line of output.") {
+		assert.True(t, strings.HasSuffix(ft.Logs()[0], "INFO   foo n=1"), "Unexpected console output %q.", ft.Logs()[0])
+	}
+}
+
+func TestLoggerLevel(t *testing.T) {
+	logger, ft := newTestLogger(Level(zap.WarnLevel))
+	logger.Info("info")
+	assert.Nil(t, logger.Check(zap.InfoLevel, "info"), "Expected a nil CheckedMessage at disabled levels.")
+	logger.Warn("warn")
+	assert.Equal(t, []string{`{"level":"warn","msg":"warn"}`}, ft.Logs(), "Unexpected output with a minimum level.")
+}
+
+func TestLoggerFailAt(t *testing.T) {
+	tests := []struct {
+		desc   string
+		f      func(zap.Logger)
+		failed bool
+	}{
+		{"warn", func(l zap.Logger) { l.Warn("") }, false},
+		{"error", func(l zap.Logger) { l.Error("") }, true},
+		{"log error", func(l zap.Logger) { l.Log(zap.ErrorLevel, "") }, true},
+		{"check error", func(l zap.Logger) { l.Check(zap.ErrorLevel, "").Write() }, true},
+		{"child error", func(l zap.Logger) { l.With(zap.Int("foo", 1)).Named("bar").Error("") }, true},
+		{"dpanic", func(l zap.Logger) { l.DPanic("") }, true},
+	}
+
+	for _, tt := range tests {
+		logger, ft := newTestLogger(FailAt(zap.ErrorLevel))
+		tt.f(logger)
+		assert.Equal(t, tt.failed, ft.Failed(), "Unexpected test status after logging at %s.", tt.desc)
+		assert.False(t, ft.FailedNow(), "Expected FailAt not to stop the test after logging at %s.", tt.desc)
+	}
+}
+
+func TestLoggerFailAtDisabledLevel(t *testing.T) {
+	logger, ft := newTestLogger(Level(zap.FatalLevel), FailAt(zap.ErrorLevel))
+	assert.Nil(t, logger.Check(zap.ErrorLevel, ""), "Expected a nil CheckedMessage at disabled levels.")
+	assert.True(t, ft.Failed(), "Expected FailAt to apply to disabled levels.")
+	assert.Empty(t, ft.Logs(), "Expected no output at disabled levels.")
+}
+
+func TestLoggerCheck(t *testing.T) {
+	logger, _ := newTestLogger(Level(zap.ErrorLevel))
+	assert.Nil(t, logger.Check(zap.InfoLevel, ""), "Expected a nil CheckedMessage at disabled levels.")
+	assert.NotNil(t, logger.Check(zap.ErrorLevel, ""), "Expected a CheckedMessage at enabled levels.")
+
+	logger, _ = newTestLogger(Level(zap.FatalLevel))
+	assert.Nil(t, logger.Check(zap.DPanicLevel, ""), "Expected a nil CheckedMessage for DPanic outside development.")
+	assert.NotNil(t, logger.Check(zap.PanicLevel, ""), "Expected a CheckedMessage for Panic at any level.")
+
+	logger, _ = newTestLogger(Level(zap.FatalLevel), WrapOptions(zap.Development()))
+	assert.NotNil(t, logger.Check(zap.DPanicLevel, ""), "Expected a CheckedMessage for DPanic in development.")
+}
+
+func TestLoggerPanicAndFatal(t *testing.T) {
+	logger, ft := newTestLogger(WrapOptions(zap.Development()))
+	assert.True(t, zap.IsDevelopment(logger), "Expected test loggers to report the wrapped logger's mode.")
+	assert.Panics(t, func() { logger.DPanic("dpanic") }, "Expected DPanic to panic in development.")
+	assert.False(t, ft.Failed(), "Expected DPanic not to fail the test directly.")
+
+	assert.NotPanics(t, func() { logger.Panic("panic") }, "Expected Panic not to panic.")
+	assert.True(t, ft.FailedNow(), "Expected Panic to call FailNow.")
+
+	ft.Reset()
+	logger.Check(zap.PanicLevel, "checked panic").Write()
+	assert.True(t, ft.FailedNow(), "Expected checked Panic to call FailNow.")
+
+	ft.Reset()
+	logger.Fatal("fatal")
+	assert.True(t, ft.FailedNow(), "Expected Fatal to call FailNow.")
+
+	ft.Reset()
+	logger.Check(zap.FatalLevel, "checked fatal").Write()
+	assert.True(t, ft.FailedNow(), "Expected checked Fatal to call FailNow.")
+	assert.Equal(t, []string{`{"level":"fatal","msg":"checked fatal"}`}, ft.Logs(), "Unexpected output from a checked Fatal.")
+}
+
+func TestLoggerPanicInGoroutine(t *testing.T) {
+	ft := &testutils.SpyTB{}
+	logger := NewLogger(ft, Encoder(zap.NewJSONEncoder(zap.NoTime())))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		logger.Panic("panic")
+	}()
+	<-done
+	assert.True(t, ft.FailedNow(), "Expected Panic on another goroutine to call FailNow.")
+	assert.Equal(t, []string{`{"level":"panic","msg":"panic"}`}, ft.Logs(), "Unexpected output from Panic.")
+}
+
+func TestLoggerInternalErrors(t *testing.T) {
+	logger, ft := newTestLogger(WrapOptions(zap.Hook(func(*zap.Entry) error {
+		return fmt.Errorf("hook failed")
+	})))
+	logger.Info("foo")
+	if assert.Len(t, ft.Logs(), 2, "Expected an internal error and an entry.") {
+		assert.Contains(t, ft.Logs()[0], "hook error: hook failed", "Expected internal errors to be written to the test.")
+	}
+}
+
+func TestLoggerRealTB(t *testing.T) {
+	logger := NewLogger(t)
+	logger.Info("Logging to a real testing.T.")
+}
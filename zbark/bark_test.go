@@ -169,3 +169,11 @@ func TestFields(t *testing.T) {
 	assert.Equal(t, 2, fields["bar"], "Unexpected value for field.")
 	assert.Equal(t, 3, fields["baz"], "Unexpected value for field.")
 }
+
+func TestBarkCaller(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := zap.New(zap.NewJSONEncoder(), zap.Output(zap.AddSync(buf)), zap.AddCaller())
+
+	Barkify(logger).WithField("foo", "bar").Infof("foo")
+	assert.Regexp(t, `"caller":"bark_test.go:\d+"`, buf.String(), "Expected caller to be the bark call site.")
+}
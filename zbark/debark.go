@@ -31,13 +31,18 @@ import (
 
 type zapperBarkFields zwrap.KeyValueMap
 
-// Debarkify wraps bark.Logger to make it compatible with zap's JSON logger
-func Debarkify(bl bark.Logger, lvl zap.Level) zap.Logger {
+// Debarkify wraps bark.Logger to make it compatible with zap's JSON logger.
+// Options other than the level and zap.Development have no effect; in
+// development mode, DPanic panics after logging.
+func Debarkify(bl bark.Logger, lvl zap.Level, options ...zap.Option) zap.Logger {
 	if wrapper, ok := bl.(*barker); ok {
 		return wrapper.zl
 	}
+	opts := make([]zap.Option, 0, len(options)+1)
+	opts = append(opts, lvl)
+	opts = append(opts, options...)
 	return &zapper{
-		Meta: zap.MakeMeta(nil, lvl),
+		Meta: zap.MakeMeta(nil, opts...),
 		bl:   bl,
 	}
 }
@@ -86,6 +91,20 @@ func (z *zapper) With(fields ...zap.Field) zap.Logger {
 	}
 }
 
+// Create a child logger with the supplied name segment appended to its name.
+// Since bark loggers have no notion of names, the full name is added to the
+// bark context under the "logger" key.
+func (z *zapper) Named(name string) zap.Logger {
+	m := z.Meta.Named(name)
+	if m.Name == z.Name {
+		return z
+	}
+	return &zapper{
+		Meta: m,
+		bl:   z.bl.WithField("logger", m.Name),
+	}
+}
+
 func (z *zapper) Check(l zap.Level, msg string) *zap.CheckedMessage {
 	return z.Meta.Check(z, l, msg)
 }
@@ -107,8 +126,10 @@ func (z *zapper) Error(msg string, fields ...zap.Field) {
 }
 
 func (z *zapper) DPanic(msg string, fields ...zap.Field) {
-	// TODO: Implement development/DPanic?
-	z.Error(msg, fields...)
+	z.Log(zap.DPanicLevel, msg, fields...)
+	if z.Development {
+		panic(msg)
+	}
 }
 
 func (z *zapper) Panic(msg string, fields ...zap.Field) {
@@ -119,6 +140,12 @@ func (z *zapper) Fatal(msg string, fields ...zap.Field) {
 	z.Log(zap.FatalLevel, msg, fields...)
 }
 
+// Sync is a no-op, since bark loggers don't expose a way to flush their
+// output.
+func (z *zapper) Sync() error {
+	return nil
+}
+
 func (zbf zapperBarkFields) Fields() map[string]interface{} {
 	return zbf
 }
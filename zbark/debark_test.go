@@ -174,9 +174,27 @@ func TestDebark_Methods(t *testing.T) {
 	assert.Panics(t, func() { logger.Panic("msg") })
 }
 
+func TestDebark_Named(t *testing.T) {
+	logger, buf := newDebark(zap.DebugLevel)
+	assert.True(t, logger == logger.Named(""), "Expected empty names to be no-ops.")
+
+	logger.Named("foo").Named("bar").Info("ohai")
+	assert.Contains(t, buf.String(), "logger=foo.bar", "Expected logger name in bark fields.")
+}
+
 func TestDebark_Stubs(t *testing.T) {
 	logger, _ := newDebark(zap.DebugLevel)
 	assert.NotPanics(t, func() { logger.DPanic("msg") })
+	assert.NoError(t, logger.Sync())
+}
+
+func TestDebark_Development(t *testing.T) {
+	logrus, buf := newLogrus()
+	logger := Debarkify(logrus, zap.FatalLevel+1, zap.Development())
+	assert.True(t, zap.IsDevelopment(logger), "Expected Development option to be honored.")
+	assert.Panics(t, func() { logger.DPanic("msg") }, "Expected DPanic to panic in development.")
+	assert.Panics(t, func() { logger.Check(zap.DPanicLevel, "msg").Write() }, "Expected checked DPanic to panic in development.")
+	assert.Empty(t, buf.String(), "Expected disabled levels not to produce output.")
 }
 
 func TestDebark_zapToBarkFields(t *testing.T) {
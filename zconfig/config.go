@@ -0,0 +1,353 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/uber-go/zap"
+	"github.com/uber-go/zap/zwrap"
+)
+
+const (
+	// JSONEncoding selects zap's JSON encoder.
+	JSONEncoding = "json"
+	// LogfmtEncoding selects zap's logfmt encoder.
+	LogfmtEncoding = "logfmt"
+	// TextEncoding selects zap's human-readable text encoder.
+	TextEncoding = "text"
+	// ConsoleEncoding selects zap's colorized console encoder, which is meant
+	// for development.
+	ConsoleEncoding = "console"
+
+	// EpochTimeFormat encodes timestamps as floating-point seconds since the
+	// Unix epoch. It isn't supported by the text and console encodings.
+	EpochTimeFormat = "epoch"
+	// RFC3339TimeFormat encodes timestamps as RFC3339 strings.
+	RFC3339TimeFormat = "rfc3339"
+)
+
+var errEpochText = errors.New("the text and console encodings don't support epoch timestamps")
+
+// SamplingConfig sets a sampling strategy for the logger. Each second, the
+// first Initial entries with a given level and message are logged, and every
+// Thereafter-th entry after that. See zwrap.Sample for details.
+type SamplingConfig struct {
+	Initial    int `json:"initial" yaml:"initial"`
+	Thereafter int `json:"thereafter" yaml:"thereafter"`
+}
+
+// EncoderConfig configures the keys and time format used by the encoder.
+// MessageKey and LevelKey default to "msg" and "level"; an empty TimeKey or
+// NameKey omits the timestamp or logger name. The text encoding only honors
+// TimeKey (to omit timestamps), NameKey, and TimeFormat; the console encoding
+// only honors TimeKey and TimeFormat.
+//
+// TimeFormat is EpochTimeFormat, RFC3339TimeFormat, or any layout understood
+// by the time package. If it's empty, the JSON and logfmt encodings use
+// EpochTimeFormat, the text encoding uses RFC3339TimeFormat, and the console
+// encoding uses ISO8601 with millisecond precision.
+type EncoderConfig struct {
+	MessageKey string `json:"messageKey" yaml:"messageKey"`
+	LevelKey   string `json:"levelKey" yaml:"levelKey"`
+	TimeKey    string `json:"timeKey" yaml:"timeKey"`
+	NameKey    string `json:"nameKey" yaml:"nameKey"`
+	TimeFormat string `json:"timeFormat" yaml:"timeFormat"`
+}
+
+// Config offers a declarative way to construct a Logger. Its zero value logs
+// JSON at InfoLevel to standard out, adding callers to all entries and
+// stacktraces to entries at ErrorLevel and above. Since its EncoderConfig is also empty,
+// those entries have no timestamps or logger names; start from
+// NewProductionConfig or NewDevelopmentConfig to include them.
+//
+// Output paths may be "stdout", "stderr", or the paths of files, which are
+// created if necessary and opened for appending. Empty path lists keep
+// zap's defaults.
+type Config struct {
+	Level             zap.Level              `json:"level" yaml:"level"`
+	Development       bool                   `json:"development" yaml:"development"`
+	DisableCaller     bool                   `json:"disableCaller" yaml:"disableCaller"`
+	DisableStacktrace bool                   `json:"disableStacktrace" yaml:"disableStacktrace"`
+	Sampling          *SamplingConfig        `json:"sampling" yaml:"sampling"`
+	Encoding          string                 `json:"encoding" yaml:"encoding"`
+	EncoderConfig     EncoderConfig          `json:"encoderConfig" yaml:"encoderConfig"`
+	OutputPaths       []string               `json:"outputPaths" yaml:"outputPaths"`
+	ErrorOutputPaths  []string               `json:"errorOutputPaths" yaml:"errorOutputPaths"`
+	InitialFields     map[string]interface{} `json:"initialFields" yaml:"initialFields"`
+}
+
+// NewProductionConfig returns a configuration suitable for production: JSON
+// output at InfoLevel, with sampling enabled and stacktraces added at
+// ErrorLevel and above.
+func NewProductionConfig() Config {
+	return Config{
+		Level:            zap.InfoLevel,
+		Sampling:         &SamplingConfig{Initial: 100, Thereafter: 100},
+		Encoding:         JSONEncoding,
+		EncoderConfig:    NewProductionEncoderConfig(),
+		OutputPaths:      []string{"stdout"},
+		ErrorOutputPaths: []string{"stderr"},
+	}
+}
+
+// NewProductionEncoderConfig returns the encoder configuration used by
+// NewProductionConfig.
+func NewProductionEncoderConfig() EncoderConfig {
+	return EncoderConfig{
+		MessageKey: "msg",
+		LevelKey:   "level",
+		TimeKey:    "ts",
+		NameKey:    "logger",
+		TimeFormat: EpochTimeFormat,
+	}
+}
+
+// NewDevelopmentConfig returns a configuration suitable for development:
+// console output at DebugLevel, without sampling, in development mode (so that
+// DPanic panics), and with stacktraces added at WarnLevel and above.
+func NewDevelopmentConfig() Config {
+	return Config{
+		Level:            zap.DebugLevel,
+		Development:      true,
+		Encoding:         ConsoleEncoding,
+		EncoderConfig:    NewDevelopmentEncoderConfig(),
+		OutputPaths:      []string{"stderr"},
+		ErrorOutputPaths: []string{"stderr"},
+	}
+}
+
+// NewDevelopmentEncoderConfig returns the encoder configuration used by
+// NewDevelopmentConfig.
+func NewDevelopmentEncoderConfig() EncoderConfig {
+	return EncoderConfig{
+		MessageKey: "msg",
+		LevelKey:   "level",
+		TimeKey:    "ts",
+		NameKey:    "logger",
+		TimeFormat: RFC3339TimeFormat,
+	}
+}
+
+// Build constructs a Logger from the Config and any additional Options, which
+// are applied after the configured ones. It also returns the AtomicLevel the
+// logger uses, so that the level can be changed at runtime.
+func (cfg Config) Build(opts ...zap.Option) (zap.Logger, zap.AtomicLevel, error) {
+	lvl := zap.DynamicLevel()
+	lvl.SetLevel(cfg.Level)
+
+	enc, err := cfg.buildEncoder()
+	if err != nil {
+		return nil, lvl, err
+	}
+	options, err := cfg.buildOptions(lvl)
+	if err != nil {
+		return nil, lvl, err
+	}
+
+	log := zap.New(enc, append(options, opts...)...)
+	if cfg.Sampling != nil {
+		log = zwrap.Sample(log, time.Second, cfg.Sampling.Initial, cfg.Sampling.Thereafter)
+	}
+	return log, lvl, nil
+}
+
+func (cfg Config) buildOptions(lvl zap.AtomicLevel) ([]zap.Option, error) {
+	opts := []zap.Option{lvl}
+	if cfg.Development {
+		opts = append(opts, zap.Development())
+	}
+	if !cfg.DisableCaller {
+		opts = append(opts, zap.AddCaller())
+	}
+	if !cfg.DisableStacktrace {
+		stackLevel := zap.ErrorLevel
+		if cfg.Development {
+			stackLevel = zap.WarnLevel
+		}
+		opts = append(opts, zap.AddStacks(stackLevel))
+	}
+	if len(cfg.InitialFields) > 0 {
+		opts = append(opts, zap.Fields(cfg.initialFields()...))
+	}
+
+	var outs []zap.WriteSyncer
+	if len(cfg.OutputPaths) > 0 {
+		ws, err := open(cfg.OutputPaths)
+		if err != nil {
+			return nil, err
+		}
+		outs = ws
+		opts = append(opts, zap.Output(combine(ws)))
+	}
+	if len(cfg.ErrorOutputPaths) > 0 {
+		ws, err := open(cfg.ErrorOutputPaths)
+		if err != nil {
+			closeFiles(outs)
+			return nil, err
+		}
+		opts = append(opts, zap.ErrorOutput(combine(ws)))
+	}
+	return opts, nil
+}
+
+func (cfg Config) initialFields() []zap.Field {
+	// Sort keys, so that output is deterministic.
+	keys := make([]string, 0, len(cfg.InitialFields))
+	for k := range cfg.InitialFields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]zap.Field, len(keys))
+	for i, k := range keys {
+		fields[i] = zap.Any(k, cfg.InitialFields[k])
+	}
+	return fields
+}
+
+func (cfg Config) buildEncoder() (zap.Encoder, error) {
+	switch cfg.Encoding {
+	case "", JSONEncoding:
+		return cfg.EncoderConfig.buildJSON(), nil
+	case LogfmtEncoding:
+		return cfg.EncoderConfig.buildLogfmt(), nil
+	case TextEncoding:
+		return cfg.EncoderConfig.buildText()
+	case ConsoleEncoding:
+		return cfg.EncoderConfig.buildConsole()
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", cfg.Encoding)
+	}
+}
+
+func (ec EncoderConfig) buildJSON() zap.Encoder {
+	msgF, lvlF, nameF, timeF := ec.formatters()
+	return zap.NewJSONEncoder(msgF, lvlF, nameF, timeF)
+}
+
+func (ec EncoderConfig) buildLogfmt() zap.Encoder {
+	msgF, lvlF, nameF, timeF := ec.formatters()
+	return zap.NewLogfmtEncoder(msgF, lvlF, nameF, timeF)
+}
+
+// formatters returns the formatters shared by the JSON and logfmt encodings.
+func (ec EncoderConfig) formatters() (zap.MessageFormatter, zap.LevelFormatter, zap.NameFormatter, zap.TimeFormatter) {
+	msgKey, lvlKey := ec.MessageKey, ec.LevelKey
+	if msgKey == "" {
+		msgKey = "msg"
+	}
+	if lvlKey == "" {
+		lvlKey = "level"
+	}
+	nameF := zap.NameKey(ec.NameKey)
+	if ec.NameKey == "" {
+		nameF = zap.NameFormatter(func(string) zap.Field { return zap.Skip() })
+	}
+	return zap.MessageKey(msgKey), zap.LevelKey(lvlKey), nameF, ec.structuredTime()
+}
+
+func (ec EncoderConfig) structuredTime() zap.TimeFormatter {
+	key := ec.TimeKey
+	switch {
+	case key == "":
+		return zap.NoTime()
+	case ec.TimeFormat == "" || ec.TimeFormat == EpochTimeFormat:
+		return zap.TimeKey(key)
+	case ec.TimeFormat == RFC3339TimeFormat:
+		return zap.RFC3339Formatter(key)
+	default:
+		layout := ec.TimeFormat
+		return zap.TimeFormatter(func(t time.Time) zap.Field {
+			return zap.String(key, t.Format(layout))
+		})
+	}
+}
+
+func (ec EncoderConfig) buildText() (zap.Encoder, error) {
+	opts := []zap.TextOption{zap.TextNameKey(ec.NameKey)}
+	switch {
+	case ec.TimeKey == "":
+		opts = append(opts, zap.TextNoTime())
+	case ec.TimeFormat == EpochTimeFormat:
+		return nil, errEpochText
+	case ec.TimeFormat == RFC3339TimeFormat:
+		opts = append(opts, zap.TextTimeFormat(time.RFC3339))
+	case ec.TimeFormat != "":
+		opts = append(opts, zap.TextTimeFormat(ec.TimeFormat))
+	}
+	return zap.NewTextEncoder(opts...), nil
+}
+
+func (ec EncoderConfig) buildConsole() (zap.Encoder, error) {
+	var opts []zap.ConsoleOption
+	switch {
+	case ec.TimeKey == "":
+		opts = append(opts, zap.ConsoleTimeFormat(""))
+	case ec.TimeFormat == EpochTimeFormat:
+		return nil, errEpochText
+	case ec.TimeFormat == RFC3339TimeFormat:
+		opts = append(opts, zap.ConsoleTimeFormat(time.RFC3339))
+	case ec.TimeFormat != "":
+		opts = append(opts, zap.ConsoleTimeFormat(ec.TimeFormat))
+	}
+	return zap.NewConsoleEncoder(opts...), nil
+}
+
+// open returns a WriteSyncer for each of the supplied paths.
+func open(paths []string) ([]zap.WriteSyncer, error) {
+	writers := make([]zap.WriteSyncer, 0, len(paths))
+	for _, path := range paths {
+		switch path {
+		case "stdout":
+			writers = append(writers, os.Stdout)
+		case "stderr":
+			writers = append(writers, os.Stderr)
+		default:
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+			if err != nil {
+				closeFiles(writers)
+				return nil, err
+			}
+			writers = append(writers, f)
+		}
+	}
+	return writers, nil
+}
+
+func combine(writers []zap.WriteSyncer) zap.WriteSyncer {
+	if len(writers) == 1 {
+		return writers[0]
+	}
+	return zap.MultiWriteSyncer(writers...)
+}
+
+func closeFiles(writers []zap.WriteSyncer) {
+	for _, w := range writers {
+		if f, ok := w.(*os.File); ok && f != os.Stdout && f != os.Stderr {
+			f.Close()
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zconfig
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uber-go/zap"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func withTempDir(t testing.TB, f func(dir string)) {
+	dir, err := ioutil.TempDir("", "zap-config")
+	require.NoError(t, err, "Failed to create temporary directory.")
+	defer os.RemoveAll(dir)
+	f(dir)
+}
+
+func readLines(t testing.TB, path string) []string {
+	contents, err := ioutil.ReadFile(path)
+	require.NoError(t, err, "Failed to read log file.")
+	return strings.Split(strings.TrimRight(string(contents), "\n"), "\n")
+}
+
+const _jsonConfig = `{
+	"level": "debug",
+	"encoding": "json",
+	"disableCaller": true,
+	"encoderConfig": {"messageKey": "message", "levelKey": "severity", "nameKey": "name"},
+	"outputPaths": [%q],
+	"errorOutputPaths": ["stderr"],
+	"initialFields": {"service": "foo", "shard": 3}
+}`
+
+const _yamlConfig = `
+level: debug
+encoding: json
+disableCaller: true
+encoderConfig:
+  messageKey: message
+  levelKey: severity
+  nameKey: name
+outputPaths: [%q]
+errorOutputPaths: [stderr]
+initialFields:
+  service: foo
+  shard: 3
+`
+
+func TestConfigUnmarshal(t *testing.T) {
+	tests := []struct {
+		format    string
+		template  string
+		unmarshal func([]byte, interface{}) error
+	}{
+		{"JSON", _jsonConfig, json.Unmarshal},
+		{"YAML", _yamlConfig, yaml.Unmarshal},
+	}
+
+	for _, tt := range tests {
+		withTempDir(t, func(dir string) {
+			path := filepath.Join(dir, "out.log")
+			var cfg Config
+			err := tt.unmarshal([]byte(strings.Replace(tt.template, "%q", `"`+path+`"`, 1)), &cfg)
+			require.NoError(t, err, "Unexpected error unmarshalling %s config.", tt.format)
+
+			logger, lvl, err := cfg.Build()
+			require.NoError(t, err, "Unexpected error building logger from %s config.", tt.format)
+			assert.Equal(t, zap.DebugLevel, lvl.Level(), "Unexpected level from %s config.", tt.format)
+
+			logger.Named("bar").Debug("hello")
+			lvl.SetLevel(zap.InfoLevel)
+			logger.Debug("disabled")
+
+			lines := readLines(t, path)
+			require.Equal(t, 1, len(lines), "Unexpected number of lines from %s config.", tt.format)
+			assert.Regexp(
+				t,
+				`^{"severity":"debug","name":"bar","message":"hello","service":"foo","shard":3}$`,
+				lines[0],
+				"Unexpected output from %s config.", tt.format,
+			)
+		})
+	}
+}
+
+func TestConfigPresets(t *testing.T) {
+	prod := NewProductionConfig()
+	assert.Equal(t, JSONEncoding, prod.Encoding, "Expected production config to use JSON.")
+	assert.NotNil(t, prod.Sampling, "Expected production config to sample.")
+
+	dev := NewDevelopmentConfig()
+	assert.Equal(t, ConsoleEncoding, dev.Encoding, "Expected development config to use the console encoder.")
+	assert.True(t, dev.Development, "Expected development config to be in development mode.")
+
+	for _, cfg := range []Config{prod, dev} {
+		logger, lvl, err := cfg.Build()
+		require.NoError(t, err, "Unexpected error building preset.")
+		assert.NotNil(t, logger, "Expected a logger from preset.")
+		assert.Equal(t, cfg.Level, lvl.Level(), "Unexpected level from preset.")
+	}
+}
+
+func TestConfigBuildOutput(t *testing.T) {
+	withTempDir(t, func(dir string) {
+		path := filepath.Join(dir, "out.log")
+		cfg := NewDevelopmentConfig()
+		cfg.OutputPaths = []string{path}
+		cfg.EncoderConfig.TimeKey = ""
+
+		logger, _, err := cfg.Build()
+		require.NoError(t, err, "Unexpected error building logger.")
+		logger.Info("hello")
+		assert.Panics(t, func() { logger.DPanic("oops") }, "Expected DPanic to panic in development.")
+
+		lines := readLines(t, path)
+		require.True(t, len(lines) > 2, "Expected a multi-line stacktrace.")
+		assert.Regexp(t, `^INFO   config_test.go:\d+ +hello$`, lines[0], "Unexpected console output.")
+		assert.Regexp(t, `^DPANIC config_test.go:\d+ +oops$`, lines[1], "Unexpected console output at DPanic level.")
+		assert.Regexp(t, `^goroutine \d+ \[running\]:$`, lines[2], "Expected a stacktrace at DPanic level in development.")
+	})
+}
+
+func TestConfigZeroValue(t *testing.T) {
+	withTempDir(t, func(dir string) {
+		path := filepath.Join(dir, "out.log")
+		cfg := Config{OutputPaths: []string{path}}
+		logger, _, err := cfg.Build()
+		require.NoError(t, err, "Unexpected error building logger.")
+		logger.Named("foo").Debug("disabled")
+		logger.Named("foo").Info("hello")
+		lines := readLines(t, path)
+		require.Len(t, lines, 1, "Expected one entry at InfoLevel.")
+		assert.Regexp(t, `^{"level":"info","msg":"hello","caller":"config_test.go:\d+"}$`, lines[0], "Expected the zero value to omit timestamps and names.")
+	})
+}
+
+func TestConfigBuildSampling(t *testing.T) {
+	withTempDir(t, func(dir string) {
+		path := filepath.Join(dir, "out.log")
+		cfg := NewProductionConfig()
+		cfg.OutputPaths = []string{path}
+		cfg.Sampling = &SamplingConfig{Initial: 2, Thereafter: 100}
+
+		logger, _, err := cfg.Build()
+		require.NoError(t, err, "Unexpected error building logger.")
+		for i := 0; i < 10; i++ {
+			logger.Info("sampled")
+		}
+		assert.Equal(t, 2, len(readLines(t, path)), "Expected sampling to drop entries.")
+	})
+}
+
+func TestConfigBuildTimeFormats(t *testing.T) {
+	tests := []struct {
+		encoding string
+		format   string
+		expected string
+	}{
+		{JSONEncoding, "", `"ts":[\d.]+`},
+		{JSONEncoding, EpochTimeFormat, `"ts":[\d.]+`},
+		{JSONEncoding, RFC3339TimeFormat, `"ts":"\d{4}-\d{2}-\d{2}T`},
+		{JSONEncoding, "2006/01/02", `"ts":"\d{4}/\d{2}/\d{2}"`},
+		{LogfmtEncoding, "", `^level=info ts=[\d.]+ msg=hello`},
+		{LogfmtEncoding, RFC3339TimeFormat, `^level=info ts=\d{4}-\d{2}-\d{2}T`},
+		{TextEncoding, RFC3339TimeFormat, `^\[I\] \d{4}-\d{2}-\d{2}T`},
+		{TextEncoding, "2006/01/02", `^\[I\] \d{4}/\d{2}/\d{2} hello`},
+		{ConsoleEncoding, "", `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}`},
+		{ConsoleEncoding, "2006/01/02", `^\d{4}/\d{2}/\d{2} INFO `},
+	}
+
+	for _, tt := range tests {
+		withTempDir(t, func(dir string) {
+			path := filepath.Join(dir, "out.log")
+			cfg := NewProductionConfig()
+			cfg.Encoding = tt.encoding
+			cfg.EncoderConfig.TimeFormat = tt.format
+			cfg.OutputPaths = []string{path}
+
+			logger, _, err := cfg.Build()
+			require.NoError(t, err, "Unexpected error building logger.")
+			logger.Info("hello")
+			assert.Regexp(t, tt.expected, readLines(t, path)[0], "Unexpected time format for %s encoding and format %q.", tt.encoding, tt.format)
+		})
+	}
+}
+
+func TestConfigBuildErrors(t *testing.T) {
+	withTempDir(t, func(dir string) {
+		missing := filepath.Join(dir, "missing", "out.log")
+		tests := []struct {
+			desc   string
+			modify func(*Config)
+		}{
+			{"unknown encoding", func(c *Config) { c.Encoding = "xml" }},
+			{"epoch text timestamps", func(c *Config) {
+				c.Encoding = TextEncoding
+				c.EncoderConfig.TimeFormat = EpochTimeFormat
+			}},
+			{"bad output path", func(c *Config) { c.OutputPaths = []string{"stdout", missing} }},
+			{"bad error output path", func(c *Config) { c.ErrorOutputPaths = []string{missing} }},
+		}
+
+		for _, tt := range tests {
+			cfg := NewProductionConfig()
+			tt.modify(&cfg)
+			logger, _, err := cfg.Build()
+			assert.Error(t, err, "Expected an error with %s.", tt.desc)
+			assert.Nil(t, logger, "Expected a nil logger with %s.", tt.desc)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zwrap
+
+// _defaultCapacity is the default number of slots in a sampling table. Keys
+// that hash to the same slot share counters, so sampling becomes less precise
+// as the number of distinct keys approaches the capacity; memory use, however,
+// stays fixed no matter how many keys there are.
+const _defaultCapacity = 4096
+
+// tableSize rounds the requested capacity up to a power of two, so that slots
+// can be chosen with a mask.
+func tableSize(capacity int) int {
+	if capacity <= 0 {
+		capacity = _defaultCapacity
+	}
+	size := 1
+	for size < capacity {
+		size <<= 1
+	}
+	return size
+}
+
+// slotIndex hashes the key and masks the result to choose a slot in a table
+// of the given power-of-two size.
+func slotIndex(key string, size int) int {
+	return int(hashKey(key) & uint32(size-1))
+}
+
+// hashKey hashes the key with 32-bit FNV-1a. Unlike hash/fnv, it doesn't
+// allocate.
+func hashKey(key string) uint32 {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= prime32
+	}
+	return h
+}
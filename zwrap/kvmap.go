@@ -20,7 +20,11 @@
 
 package zwrap
 
-import "github.com/uber-go/zap"
+import (
+	"time"
+
+	"github.com/uber-go/zap"
+)
 
 // KeyValueMap implements zap.KeyValue backed by a map.
 type KeyValueMap map[string]interface{}
@@ -46,6 +50,12 @@ func (m KeyValueMap) AddUint64(k string, v uint64) { m[k] = v }
 // AddUintptr adds the value under the specified key to the map.
 func (m KeyValueMap) AddUintptr(k string, v uintptr) { m[k] = v }
 
+// AddTime adds the value under the specified key to the map.
+func (m KeyValueMap) AddTime(k string, v time.Time) { m[k] = v }
+
+// AddDuration adds the value under the specified key to the map.
+func (m KeyValueMap) AddDuration(k string, v time.Duration) { m[k] = v }
+
 // AddObject adds the value under the specified key to the map.
 func (m KeyValueMap) AddObject(k string, v interface{}) error {
 	m[k] = v
@@ -60,9 +70,47 @@ func (m KeyValueMap) AddMarshaler(k string, v zap.LogMarshaler) error {
 	return m.Nest(k, v.MarshalLog)
 }
 
+// AddArray builds a slice and adds it under the specified key to the map.
+func (m KeyValueMap) AddArray(k string, v zap.ArrayMarshaler) error {
+	arr := &sliceArrayEncoder{elems: []interface{}{}}
+	err := v.MarshalLogArray(arr)
+	m[k] = arr.elems
+	return err
+}
+
 // Nest builds a object and adds the value under the specified key to the map.
 func (m KeyValueMap) Nest(k string, f func(zap.KeyValue) error) error {
 	newMap := make(KeyValueMap)
 	m[k] = newMap
 	return f(newMap)
 }
+
+// sliceArrayEncoder implements zap.ArrayEncoder backed by a slice.
+type sliceArrayEncoder struct {
+	elems []interface{}
+}
+
+func (s *sliceArrayEncoder) AppendBool(v bool)       { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendFloat64(v float64) { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendInt(v int)         { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendInt64(v int64)     { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendUint(v uint)       { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendUint64(v uint64)   { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendString(v string)   { s.elems = append(s.elems, v) }
+
+func (s *sliceArrayEncoder) AppendTime(v time.Time)         { s.elems = append(s.elems, v) }
+func (s *sliceArrayEncoder) AppendDuration(v time.Duration) { s.elems = append(s.elems, v) }
+
+func (s *sliceArrayEncoder) AppendArray(v zap.ArrayMarshaler) error {
+	nested := &sliceArrayEncoder{elems: []interface{}{}}
+	err := v.MarshalLogArray(nested)
+	s.elems = append(s.elems, nested.elems)
+	return err
+}
+
+func (s *sliceArrayEncoder) AppendMarshaler(v zap.LogMarshaler) error {
+	m := make(KeyValueMap)
+	err := v.MarshalLog(m)
+	s.elems = append(s.elems, m)
+	return err
+}
@@ -59,6 +59,15 @@ func TestKeyValueMapAdd(t *testing.T) {
 	assert.NoError(t, kv.AddObject("obj", arbitraryObj), "AddObject failed")
 	assert.NoError(t, kv.AddMarshaler("m1", loggable{}), "AddMarshaler failed")
 	assert.NoError(t, kv.Nest("m2", loggable{}.MarshalLog), "Nest failed")
+	assert.NoError(t, kv.AddArray("arr", zap.ArrayMarshalerFunc(func(arr zap.ArrayEncoder) error {
+		arr.AppendString("a")
+		arr.AppendInt(1)
+		arr.AppendBool(true)
+		if err := arr.AppendArray(zap.ArrayMarshalerFunc(func(zap.ArrayEncoder) error { return nil })); err != nil {
+			return err
+		}
+		return arr.AppendMarshaler(loggable{})
+	})), "AddArray failed")
 
 	want := KeyValueMap{
 		"b":       true,
@@ -74,6 +83,7 @@ func TestKeyValueMapAdd(t *testing.T) {
 		"m2": KeyValueMap{
 			"loggable": "yes",
 		},
+		"arr": []interface{}{"a", 1, true, []interface{}{}, KeyValueMap{"loggable": "yes"}},
 	}
 	assert.Equal(t, want, kv, "Unexpected result")
 }
@@ -83,8 +93,12 @@ func TestKeyValueMapAddFails(t *testing.T) {
 
 	assert.Error(t, kv.AddMarshaler("m1", unloggable{}), "AddMarshaler should fail")
 	assert.Error(t, kv.Nest("m2", unloggable{}.MarshalLog), "Nest should fail")
+	assert.Error(t, kv.AddArray("arr", zap.ArrayMarshalerFunc(func(arr zap.ArrayEncoder) error {
+		return arr.AppendMarshaler(unloggable{})
+	})), "AddArray should fail")
 	assert.Equal(t, KeyValueMap{
-		"m1": KeyValueMap{},
-		"m2": KeyValueMap{},
+		"m1":  KeyValueMap{},
+		"m2":  KeyValueMap{},
+		"arr": []interface{}{KeyValueMap{}},
 	}, kv, "Empty values on errors")
 }
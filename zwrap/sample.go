@@ -21,6 +21,7 @@
 package zwrap
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
@@ -29,43 +30,6 @@ import (
 	"github.com/uber-go/atomic"
 )
 
-type counters struct {
-	sync.RWMutex
-	counts map[string]*atomic.Uint64
-}
-
-func (c *counters) Inc(key string) uint64 {
-	c.RLock()
-	count, ok := c.counts[key]
-	c.RUnlock()
-	if ok {
-		return count.Inc()
-	}
-
-	c.Lock()
-	count, ok = c.counts[key]
-	if ok {
-		c.Unlock()
-		return count.Inc()
-	}
-
-	c.counts[key] = atomic.NewUint64(1)
-	c.Unlock()
-	return 1
-}
-
-func (c *counters) Reset(key string) {
-	c.Lock()
-	count := c.counts[key]
-	c.Unlock()
-	count.Store(0)
-}
-
-// TODO: implement (*sampler).DPanic so that if we're not going to panic, we
-// down sample the dpanic logs. Also will need custom case in Check (Log
-// already is compliant, since it didn't have to maintain "panic in dev"
-// semantics).
-
 // Sample returns a sampling logger. The logger maintains a separate bucket
 // for each message (e.g., "foo" in logger.Warn("foo")). In each tick, the
 // sampler will emit the first N logs in each bucket and every Mth log
@@ -76,95 +40,410 @@ func (c *counters) Reset(key string) {
 // FatalLevel, if it happens is sampled and will call the underlying logger Log
 // method, which should NOT panic() or terminate.
 //
-// NOTE: logging at DPanicLevel currently IS sampled, but calls to DPanic and
-// Check(DPanicLevvel) are NOT sampled.
+// DPanic is sampled only if the underlying logger isn't in development mode
+// (see zap.IsDevelopment); in development, DPanic and Check(DPanicLevel)
+// always reach the underlying logger so that it can panic.
 //
 // Per-message counts are shared between parent and child loggers, which allows
 // applications to more easily control global I/O load.
+//
+// Sample is equivalent to SampleBy(zl, FirstThereafter(tick, first,
+// thereafter)).
 func Sample(zl zap.Logger, tick time.Duration, first, thereafter int) zap.Logger {
-	return &sampler{
-		Logger:     zl,
-		tick:       tick,
-		counts:     &counters{counts: make(map[string]*atomic.Uint64)},
-		first:      uint64(first),
-		thereafter: uint64(thereafter),
-	}
+	return SampleBy(zl, FirstThereafter(tick, first, thereafter))
 }
 
-type sampler struct {
+// SampleBy returns a sampling logger that consults the supplied strategy
+// before writing each log call. Panic, Fatal, and DPanic are handled as they
+// are by Sample, and the strategy is shared between parent and child loggers.
+func SampleBy(zl zap.Logger, strategy SamplingStrategy) zap.Logger {
+	return NewSampler(zl, strategy)
+}
+
+// A SamplerOption configures a Sampler.
+type SamplerOption interface {
+	apply(*samplerCore)
+}
+
+type samplerOptionFunc func(*samplerCore)
+
+func (f samplerOptionFunc) apply(c *samplerCore) {
+	f(c)
+}
+
+// StatsKey sets the KeyFunc the Sampler uses to group its per-key counters and
+// summaries. It should usually match the strategy's key. The default is
+// MessageKey.
+func StatsKey(f KeyFunc) SamplerOption {
+	return samplerOptionFunc(func(c *samplerCore) {
+		c.key = f
+	})
+}
+
+// StatsCapacity sets the number of slots the Sampler uses for its per-key
+// counters, which fixes their memory use. Keys are hashed into slots, so if
+// there are more distinct keys than slots, some keys share counters. Since
+// the Sampler can't tell which of those keys a shared slot's calls belong to,
+// it reports all shared slots together under OtherKey. The capacity is
+// rounded up to a power of two; the default is 4096.
+func StatsCapacity(n int) SamplerOption {
+	return samplerOptionFunc(func(c *samplerCore) {
+		c.capacity = n
+	})
+}
+
+// Summarize configures the Sampler to write a summary entry at the supplied
+// level for each key that had log calls dropped during the last interval
+// (e.g., "sampled 10 of 250 messages for key \"foo\""). Summaries are
+// written by a single background goroutine, which runs until the Sampler is
+// stopped; they aren't themselves sampled. The level should be ErrorLevel or
+// lower.
+func Summarize(interval time.Duration, lvl zap.Level) SamplerOption {
+	return samplerOptionFunc(func(c *samplerCore) {
+		c.interval = interval
+		c.summaryLevel = lvl
+	})
+}
+
+// OtherKey is the key under which a Sampler reports the combined counts of
+// all slots that have been shared by more than one key (see StatsCapacity).
+const OtherKey = "<other>"
+
+// SamplingCounts counts the log calls a Sampler has considered. Calls at
+// disabled levels, and calls that are never sampled (e.g., to Panic), aren't
+// counted.
+type SamplingCounts struct {
+	Sampled uint64 // calls written
+	Dropped uint64 // calls dropped
+}
+
+// SamplerStats is a snapshot of a Sampler's counters since it was
+// constructed.
+type SamplerStats struct {
+	Levels map[zap.Level]SamplingCounts
+	Keys   map[string]SamplingCounts
+}
+
+// A Sampler is a zap.Logger that writes only the log calls its
+// SamplingStrategy allows, and keeps statistics about the calls it drops.
+// Sampler and all its child loggers share counters, strategy state, and
+// background resources.
+type Sampler struct {
 	zap.Logger
+	*samplerCore
+
+	context []zap.Field
+}
+
+type samplerCore struct {
+	root     zap.Logger
+	strategy SamplingStrategy
+	key      KeyFunc
+	capacity int
+	levels   [zap.FatalLevel - zap.DebugLevel + 1]counts
+
+	keys []keyCounts
+
+	interval     time.Duration
+	summaryLevel zap.Level
+	stop         chan struct{}
+	stopOnce     sync.Once
+	done         sync.WaitGroup
+}
+
+type counts struct {
+	sampled atomic.Uint64
+	dropped atomic.Uint64
+}
+
+func (c *counts) record(sampled bool) {
+	if sampled {
+		c.sampled.Inc()
+	} else {
+		c.dropped.Inc()
+	}
+}
+
+func (c *counts) load() SamplingCounts {
+	return SamplingCounts{Sampled: c.sampled.Load(), Dropped: c.dropped.Load()}
+}
+
+type keyCounts struct {
+	counts
+
+	// The first key recorded in this slot, and its hash with bit 32 set
+	// (so that it's never zero). The hash is claimed with a compare-and-swap,
+	// which lets later calls detect other keys without comparing strings.
+	key   atomic.String
+	owner atomic.Uint64
+	// Whether any key other than the first has used this slot.
+	shared atomic.Bool
+
+	// The counts at the time of the last summary. Only accessed while
+	// summarizing.
+	summarized SamplingCounts
+}
+
+// NewSampler returns a Sampler that wraps the supplied logger. Unless it's
+// configured to write summaries, a Sampler doesn't start any goroutines or
+// timers.
+func NewSampler(zl zap.Logger, strategy SamplingStrategy, opts ...SamplerOption) *Sampler {
+	core := &samplerCore{
+		root:     zl,
+		strategy: strategy,
+		key:      MessageKey,
+		stop:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt.apply(core)
+	}
+	core.keys = make([]keyCounts, tableSize(core.capacity))
+	if core.interval > 0 {
+		core.done.Add(1)
+		go core.summarizeEvery(core.interval)
+	}
+	return &Sampler{
+		Logger:      zl,
+		samplerCore: core,
+	}
+}
 
-	tick       time.Duration
-	counts     *counters
-	first      uint64
-	thereafter uint64
+// Stats returns a snapshot of the Sampler's counters.
+func (s *Sampler) Stats() SamplerStats {
+	stats := SamplerStats{
+		Levels: make(map[zap.Level]SamplingCounts, len(s.levels)),
+		Keys:   make(map[string]SamplingCounts),
+	}
+	for i := range s.levels {
+		if c := s.levels[i].load(); c != (SamplingCounts{}) {
+			stats.Levels[zap.DebugLevel+zap.Level(i)] = c
+		}
+	}
+	for i := range s.keys {
+		kc := &s.keys[i]
+		if c := kc.load(); c != (SamplingCounts{}) {
+			key := kc.label()
+			total := stats.Keys[key]
+			stats.Keys[key] = SamplingCounts{
+				Sampled: total.Sampled + c.Sampled,
+				Dropped: total.Dropped + c.Dropped,
+			}
+		}
+	}
+	return stats
+}
+
+// Stop stops writing periodic summaries, writes a final summary of any
+// unreported drops, and releases the Sampler's background resources. It's safe
+// to call more than once, and the Sampler remains usable afterwards.
+func (s *Sampler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		s.done.Wait()
+		if s.interval > 0 {
+			s.summarize()
+		}
+	})
 }
 
-func (s *sampler) With(fields ...zap.Field) zap.Logger {
-	return &sampler{
-		Logger:     s.Logger.With(fields...),
-		tick:       s.tick,
-		counts:     s.counts,
-		first:      s.first,
-		thereafter: s.thereafter,
+// With creates a child logger that shares the Sampler's state.
+func (s *Sampler) With(fields ...zap.Field) zap.Logger {
+	// Cap the context so that sibling loggers never share a backing array.
+	context := s.context[:len(s.context):len(s.context)]
+	return &Sampler{
+		Logger:      s.Logger.With(fields...),
+		samplerCore: s.samplerCore,
+		context:     append(context, fields...),
 	}
 }
 
-func (s *sampler) Check(lvl zap.Level, msg string) *zap.CheckedMessage {
-	cm := s.Logger.Check(lvl, msg)
+// Named creates a named child logger that shares the Sampler's state.
+func (s *Sampler) Named(name string) zap.Logger {
+	return &Sampler{
+		Logger:      s.Logger.Named(name),
+		samplerCore: s.samplerCore,
+		context:     s.context,
+	}
+}
+
+// Check returns a CheckedMessage if logging a message at the supplied level
+// is enabled and the message is sampled. Panic and Fatal messages aren't
+// sampled, nor are DPanic messages in development mode.
+func (s *Sampler) Check(lvl zap.Level, msg string) *zap.CheckedMessage {
 	switch lvl {
-	case zap.DPanicLevel, zap.PanicLevel, zap.FatalLevel:
-		return cm
+	case zap.PanicLevel, zap.FatalLevel:
+		return s.Logger.Check(lvl, msg)
+	case zap.DPanicLevel:
+		if s.IsDevelopment() {
+			return s.Logger.Check(lvl, msg)
+		}
+		fallthrough
 	default:
-		if !cm.OK() || s.sampled(msg) {
-			return cm
+		if !s.enabled(lvl, msg) || !s.sampled(lvl, msg, nil) {
+			return nil
 		}
-		return nil
+		return s.Logger.Check(lvl, msg)
 	}
 }
 
-func (s *sampler) Log(lvl zap.Level, msg string, fields ...zap.Field) {
+// Log samples and writes a message at the supplied level.
+func (s *Sampler) Log(lvl zap.Level, msg string, fields ...zap.Field) {
 	switch lvl {
 	case zap.PanicLevel, zap.FatalLevel:
 		s.Logger.Log(lvl, msg, fields...)
 	default:
-		if cm := s.Logger.Check(lvl, msg); cm.OK() && s.sampled(msg) {
-			cm.Write(fields...)
+		if s.enabled(lvl, msg) && s.sampled(lvl, msg, fields) {
+			s.Logger.Log(lvl, msg, fields...)
 		}
 	}
 }
 
-func (s *sampler) Debug(msg string, fields ...zap.Field) {
-	if s.Logger.Check(zap.DebugLevel, msg) != nil && s.sampled(msg) {
+// Debug samples and writes a message at DebugLevel.
+func (s *Sampler) Debug(msg string, fields ...zap.Field) {
+	if s.enabled(zap.DebugLevel, msg) && s.sampled(zap.DebugLevel, msg, fields) {
 		s.Logger.Debug(msg, fields...)
 	}
 }
 
-func (s *sampler) Info(msg string, fields ...zap.Field) {
-	if s.Logger.Check(zap.InfoLevel, msg) != nil && s.sampled(msg) {
+// Info samples and writes a message at InfoLevel.
+func (s *Sampler) Info(msg string, fields ...zap.Field) {
+	if s.enabled(zap.InfoLevel, msg) && s.sampled(zap.InfoLevel, msg, fields) {
 		s.Logger.Info(msg, fields...)
 	}
 }
 
-func (s *sampler) Warn(msg string, fields ...zap.Field) {
-	if s.Logger.Check(zap.WarnLevel, msg) != nil && s.sampled(msg) {
+// Warn samples and writes a message at WarnLevel.
+func (s *Sampler) Warn(msg string, fields ...zap.Field) {
+	if s.enabled(zap.WarnLevel, msg) && s.sampled(zap.WarnLevel, msg, fields) {
 		s.Logger.Warn(msg, fields...)
 	}
 }
 
-func (s *sampler) Error(msg string, fields ...zap.Field) {
-	if s.Logger.Check(zap.ErrorLevel, msg) != nil && s.sampled(msg) {
+// Error samples and writes a message at ErrorLevel.
+func (s *Sampler) Error(msg string, fields ...zap.Field) {
+	if s.enabled(zap.ErrorLevel, msg) && s.sampled(zap.ErrorLevel, msg, fields) {
 		s.Logger.Error(msg, fields...)
 	}
 }
 
-func (s *sampler) sampled(msg string) bool {
-	n := s.counts.Inc(msg)
-	if n <= s.first {
-		return true
+// DPanic writes a message at DPanicLevel. In development mode the message
+// isn't sampled, so the underlying logger always panics; otherwise, it's
+// sampled like any other message.
+func (s *Sampler) DPanic(msg string, fields ...zap.Field) {
+	if s.IsDevelopment() {
+		s.Logger.DPanic(msg, fields...)
+		return
+	}
+	if s.enabled(zap.DPanicLevel, msg) && s.sampled(zap.DPanicLevel, msg, fields) {
+		s.Logger.DPanic(msg, fields...)
+	}
+}
+
+// IsDevelopment reports whether the wrapped logger is in development mode.
+func (s *Sampler) IsDevelopment() bool {
+	return zap.IsDevelopment(s.Logger)
+}
+
+// enabled reports whether the wrapped logger is enabled at the supplied
+// level. Checking the level directly, when the logger exposes it, avoids
+// taking (and then abandoning) a pooled CheckedMessage for every dropped call.
+func (s *Sampler) enabled(lvl zap.Level, msg string) bool {
+	if enab, ok := s.Logger.(zap.LevelEnabler); ok {
+		return enab.Enabled(lvl)
 	}
-	if n == s.first+1 {
-		time.AfterFunc(s.tick, func() { s.counts.Reset(msg) })
+	return s.Logger.Check(lvl, msg).OK()
+}
+
+func (s *Sampler) sampled(lvl zap.Level, msg string, fields []zap.Field) bool {
+	e := SampledEntry{
+		Level:   lvl,
+		Message: msg,
+		Context: s.context,
+		Fields:  fields,
+	}
+	ok := s.strategy.Sample(e)
+	if i := int(lvl - zap.DebugLevel); i >= 0 && i < len(s.levels) {
+		s.levels[i].record(ok)
+	}
+	s.keyCounts(s.key(e)).record(ok)
+	return ok
+}
+
+func (c *samplerCore) keyCounts(key string) *keyCounts {
+	h := hashKey(key)
+	kc := &c.keys[h&uint32(len(c.keys)-1)]
+	owner := uint64(h) | 1<<32
+	if o := kc.owner.Load(); o != owner {
+		if o == 0 && kc.owner.CAS(0, owner) {
+			// Storing a key allocates, so only do it once per slot.
+			kc.key.Store(key)
+		} else if !kc.shared.Load() {
+			kc.shared.Store(true)
+		}
+	}
+	return kc
+}
+
+// label returns the key to report the slot's counts under.
+func (kc *keyCounts) label() string {
+	if kc.shared.Load() {
+		return OtherKey
+	}
+	return kc.key.Load()
+}
+
+func (c *samplerCore) summarizeEvery(interval time.Duration) {
+	defer c.done.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.summarize()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// summarize writes an entry for each key that dropped log calls since the
+// last summary. It must not be called concurrently with itself.
+func (c *samplerCore) summarize() {
+	type summary struct {
+		key              string
+		sampled, dropped uint64
+	}
+	var summaries []summary
+	other := summary{key: OtherKey}
+
+	for i := range c.keys {
+		kc := &c.keys[i]
+		now := kc.load()
+		s := summary{
+			key:     kc.label(),
+			sampled: now.Sampled - kc.summarized.Sampled,
+			dropped: now.Dropped - kc.summarized.Dropped,
+		}
+		kc.summarized = now
+		if s.key == OtherKey {
+			other.sampled += s.sampled
+			other.dropped += s.dropped
+		} else if s.dropped > 0 {
+			summaries = append(summaries, s)
+		}
+	}
+	if other.dropped > 0 {
+		summaries = append(summaries, other)
+	}
+
+	for _, s := range summaries {
+		msg := fmt.Sprintf("sampled %d of %d messages for key %q", s.sampled, s.sampled+s.dropped, s.key)
+		if cm := c.root.Check(c.summaryLevel, msg); cm.OK() {
+			cm.Write(
+				zap.String("sampleKey", s.key),
+				zap.Uint64("sampled", s.sampled),
+				zap.Uint64("dropped", s.dropped),
+			)
+		}
 	}
-	return (n-s.first)%s.thereafter == 0
 }
@@ -0,0 +1,104 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zwrap
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/uber-go/zap"
+)
+
+var _sampledFields = []zap.Field{
+	zap.Int("foo", 42),
+	zap.String("bar", "baz"),
+}
+
+func withBenchedSampler(b *testing.B, strategy SamplingStrategy, f func(zap.Logger)) {
+	base := zap.New(zap.NullEncoder(), zap.DebugLevel, zap.DiscardOutput)
+	sampler := NewSampler(base, strategy)
+	b.ReportAllocs()
+	b.ResetTimer()
+	f(sampler)
+}
+
+func BenchmarkSampler_Check(b *testing.B) {
+	withBenchedSampler(b, FirstThereafter(time.Minute, 1, 1000), func(logger zap.Logger) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if cm := logger.Check(zap.InfoLevel, "sample"); cm.OK() {
+					cm.Write(_sampledFields...)
+				}
+			}
+		})
+	})
+}
+
+func BenchmarkSampler_Info(b *testing.B) {
+	withBenchedSampler(b, FirstThereafter(time.Minute, 1, 1000), func(logger zap.Logger) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.Info("sample", _sampledFields...)
+			}
+		})
+	})
+}
+
+func BenchmarkSampler_TokenBucket(b *testing.B) {
+	withBenchedSampler(b, TokenBucket(100, 10), func(logger zap.Logger) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.Info("sample", _sampledFields...)
+			}
+		})
+	})
+}
+
+func BenchmarkSampler_ByLevel(b *testing.B) {
+	strategy := ByLevel(map[zap.Level]SamplingStrategy{
+		zap.DebugLevel: FirstThereafter(time.Minute, 1, 1000),
+	})
+	withBenchedSampler(b, strategy, func(logger zap.Logger) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.Debug("sample", _sampledFields...)
+			}
+		})
+	})
+}
+
+func BenchmarkSampler_HighCardinality(b *testing.B) {
+	// Many more distinct messages than counter slots.
+	msgs := make([]string, 100000)
+	for i := range msgs {
+		msgs[i] = "sample " + strconv.Itoa(i)
+	}
+	withBenchedSampler(b, FirstThereafter(time.Minute, 1, 1000), func(logger zap.Logger) {
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				logger.Info(msgs[i], _sampledFields...)
+				i = (i + 1) % len(msgs)
+			}
+		})
+	})
+}
@@ -21,12 +21,15 @@
 package zwrap
 
 import (
+	"fmt"
+	"io/ioutil"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/uber-go/zap"
 	"github.com/uber-go/zap/spy"
+	"github.com/uber-go/zap/spywrite"
 	"github.com/uber-go/zap/testutils"
 
 	"github.com/stretchr/testify/assert"
@@ -85,7 +88,7 @@ func TestSampler(t *testing.T) {
 		{
 			level:   zap.DPanicLevel,
 			logFunc: func(sampler zap.Logger, n int) { WithIter(sampler, n).DPanic("sample") },
-			sampled: false,
+			sampled: true,
 		},
 		{
 			level: zap.DPanicLevel,
@@ -152,6 +155,23 @@ func TestSamplerWithSharesCounters(t *testing.T) {
 	assert.Equal(t, expected, sink.Logs(), "Expected child loggers to share counters.")
 }
 
+func TestSamplerNamedSharesCounters(t *testing.T) {
+	logger, sink := fakeSampler(zap.DebugLevel, time.Minute, 1, 100, false)
+
+	for i := 1; i < 10; i++ {
+		WithIter(logger.Named("foo"), i).Info("sample")
+		WithIter(logger.Named("bar"), i).Info("sample")
+	}
+
+	expected := []spy.Log{{
+		Level:  zap.InfoLevel,
+		Name:   "foo",
+		Msg:    "sample",
+		Fields: []zap.Field{zap.Int("iter", 1)},
+	}}
+	assert.Equal(t, expected, sink.Logs(), "Expected named loggers to share counters.")
+}
+
 func TestSamplerTicks(t *testing.T) {
 	// Ensure that we're resetting the sampler's counter every tick.
 	sampler, sink := fakeSampler(zap.DebugLevel, time.Millisecond, 1, 1000, false)
@@ -200,6 +220,27 @@ func TestSamplerCheckPanicFatal(t *testing.T) {
 	}
 }
 
+func TestSamplerCheckDPanic(t *testing.T) {
+	sampler, sink := fakeSampler(zap.DebugLevel, time.Minute, 1, 10, false)
+	assert.False(t, zap.IsDevelopment(sampler), "Expected sampler to report the base logger's mode.")
+	for i := 1; i < 4; i++ {
+		if cm := sampler.Check(zap.DPanicLevel, "sample"); cm.OK() {
+			cm.Write(zap.Int("iter", i))
+		}
+	}
+	assert.Equal(t, buildExpectation(zap.DPanicLevel, 1), sink.Logs(), "Expected DPanic to be sampled in production.")
+
+	sampler, sink = fakeSampler(zap.DebugLevel, time.Minute, 1, 10, true)
+	assert.True(t, zap.IsDevelopment(sampler), "Expected sampler to report the base logger's mode.")
+	for i := 1; i < 4; i++ {
+		cm := sampler.Check(zap.DPanicLevel, "sample")
+		if assert.True(t, cm.OK(), "Expected DPanicLevel to always be OK in development.") {
+			assert.Panics(t, func() { cm.Write(zap.Int("iter", i)) }, "Expected DPanic to panic in development.")
+		}
+	}
+	assert.Equal(t, buildExpectation(zap.DPanicLevel, 1, 2, 3), sink.Logs(), "Expected DPanic not to be sampled in development.")
+}
+
 func TestSamplerRaces(t *testing.T) {
 	sampler, _ := fakeSampler(zap.DebugLevel, time.Minute, 1, 1000, false)
 
@@ -220,3 +261,169 @@ func TestSamplerRaces(t *testing.T) {
 	close(start)
 	wg.Wait()
 }
+
+func TestSamplerSync(t *testing.T) {
+	sink := &spywrite.WriteSyncer{Writer: ioutil.Discard}
+	sampler := Sample(zap.New(zap.NullEncoder(), zap.Output(sink)), time.Minute, 1, 10)
+	assert.NoError(t, sampler.With(zap.Int("foo", 42)).Sync(), "Unexpected error syncing sampler.")
+	assert.True(t, sink.Called(), "Expected sampler to sync the underlying logger.")
+}
+
+func TestSamplerStats(t *testing.T) {
+	base, _ := spy.New(zap.InfoLevel)
+	sampler := NewSampler(base, FirstThereafter(time.Minute, 1, 100))
+	for i := 0; i < 3; i++ {
+		sampler.Info("foo")
+		sampler.With(zap.Int("i", i)).Warn("bar")
+		sampler.Debug("disabled")
+	}
+	sampler.Panic("not sampled")
+
+	assert.Equal(t, SamplerStats{
+		Levels: map[zap.Level]SamplingCounts{
+			zap.InfoLevel: {Sampled: 1, Dropped: 2},
+			zap.WarnLevel: {Sampled: 1, Dropped: 2},
+		},
+		Keys: map[string]SamplingCounts{
+			"foo": {Sampled: 1, Dropped: 2},
+			"bar": {Sampled: 1, Dropped: 2},
+		},
+	}, sampler.Stats(), "Unexpected sampler stats.")
+}
+
+func TestSamplerSummaries(t *testing.T) {
+	base, sink := spy.New(zap.DebugLevel)
+	sampler := NewSampler(base, FirstThereafter(time.Minute, 1, 100), Summarize(time.Hour, zap.WarnLevel))
+	summary := func(msg, key string, sampled, dropped uint64) spy.Log {
+		return spy.Log{
+			Level: zap.WarnLevel,
+			Msg:   msg,
+			Fields: []zap.Field{
+				zap.String("sampleKey", key),
+				zap.Uint64("sampled", sampled),
+				zap.Uint64("dropped", dropped),
+			},
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		sampler.Named("child").Info("foo")
+	}
+	sampler.Info("bar")
+	sampler.summarize()
+	sampler.summarize()
+	sampler.Info("foo")
+	sampler.Stop()
+	sampler.Stop()
+
+	logs := sink.Logs()
+	assert.Equal(t, []spy.Log{
+		summary(`sampled 1 of 3 messages for key "foo"`, "foo", 1, 2),
+		summary(`sampled 0 of 1 messages for key "foo"`, "foo", 0, 1),
+	}, logs[2:], "Expected summaries for keys with drops.")
+}
+
+func TestSamplerSummarizesPeriodically(t *testing.T) {
+	base, sink := spy.New(zap.DebugLevel)
+	sampler := NewSampler(base, FirstThereafter(time.Minute, 1, 100), Summarize(time.Millisecond, zap.InfoLevel))
+	defer sampler.Stop()
+
+	sampler.Debug("foo")
+	sampler.Debug("foo")
+	deadline := time.Now().Add(testutils.Timeout(time.Second))
+	for len(sink.Logs()) < 2 && time.Now().Before(deadline) {
+		testutils.Sleep(time.Millisecond)
+	}
+	logs := sink.Logs()
+	if assert.Len(t, logs, 2, "Expected a summary entry.") {
+		assert.Equal(t, `sampled 1 of 2 messages for key "foo"`, logs[1].Msg, "Unexpected summary.")
+	}
+}
+
+func TestSamplerCapacity(t *testing.T) {
+	base, sink := spy.New(zap.DebugLevel)
+	strategy := FirstThereafter(time.Minute, 1, 100, SampleCapacity(1))
+	sampler := NewSampler(base, strategy, StatsCapacity(1))
+	sampler.Info("foo")
+	sampler.Info("bar")
+
+	assert.Len(t, sink.Logs(), 1, "Expected keys in the same bucket to be sampled together.")
+	assert.Equal(t,
+		map[string]SamplingCounts{OtherKey: {Sampled: 1, Dropped: 1}},
+		sampler.Stats().Keys,
+		"Expected shared slots to be reported under OtherKey.",
+	)
+}
+
+func TestSamplerSharedSlots(t *testing.T) {
+	base, sink := spy.New(zap.DebugLevel)
+	sampler := NewSampler(base, FirstThereafter(time.Minute, 1, 100), StatsCapacity(2), Summarize(time.Hour, zap.WarnLevel))
+
+	// Find two keys that share a slot, and one that doesn't.
+	keys := []string{"foo"}
+	for i := 0; len(keys) < 3; i++ {
+		key := fmt.Sprint("key", i)
+		switch shared := slotIndex(key, 2) == slotIndex("foo", 2); {
+		case shared && len(keys) == 1:
+			keys = append(keys, key)
+		case !shared && len(keys) == 2:
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		sampler.Info(key)
+		sampler.Info(key)
+	}
+
+	assert.Equal(t,
+		map[string]SamplingCounts{
+			OtherKey: {Sampled: 2, Dropped: 2},
+			keys[2]:  {Sampled: 1, Dropped: 1},
+		},
+		sampler.Stats().Keys,
+		"Expected only the shared slot to be reported under OtherKey.",
+	)
+
+	sampler.Stop()
+	var summaries []string
+	for _, log := range sink.Logs() {
+		if log.Level == zap.WarnLevel {
+			summaries = append(summaries, log.Msg)
+		}
+	}
+	assert.Equal(t, []string{
+		fmt.Sprintf("sampled 1 of 2 messages for key %q", keys[2]),
+		`sampled 2 of 4 messages for key "<other>"`,
+	}, summaries, "Expected summaries not to attribute shared drops to a single key.")
+}
+
+func TestTableSize(t *testing.T) {
+	tests := []struct{ capacity, size int }{
+		{-1, _defaultCapacity},
+		{0, _defaultCapacity},
+		{1, 1},
+		{3, 4},
+		{4096, 4096},
+		{5000, 8192},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.size, tableSize(tt.capacity), "Unexpected table size for capacity %d.", tt.capacity)
+	}
+}
+
+func TestSamplerDoesNotAllocate(t *testing.T) {
+	fields := []zap.Field{zap.Int("foo", 42)}
+	strategies := map[string]SamplingStrategy{
+		"FirstThereafter": FirstThereafter(time.Minute, 1, 1000),
+		"TokenBucket":     TokenBucket(1, 1),
+	}
+	for name, strategy := range strategies {
+		sampler := NewSampler(zap.New(zap.NullEncoder(), zap.DiscardOutput), strategy)
+		sampler.Info("sample", fields...)
+		allocs := testing.AllocsPerRun(100, func() {
+			sampler.Info("sample", fields...)
+			sampler.Check(zap.InfoLevel, "sample")
+		})
+		assert.Equal(t, 0.0, allocs, "Expected dropping entries with %s not to allocate.", name)
+	}
+}
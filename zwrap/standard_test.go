@@ -124,3 +124,13 @@ func TestStandardLoggerFatal(t *testing.T) {
 	std.Fatalln("foo ", 42)
 	verify()
 }
+
+func TestStandardizeCaller(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := zap.New(zap.NewJSONEncoder(), zap.Output(zap.AddSync(buf)), zap.AddCaller())
+	std, err := Standardize(logger, zap.InfoLevel)
+	require.NoError(t, err, "Unexpected error calling Standardize.")
+
+	std.Printf("foo")
+	assert.Regexp(t, `"caller":"standard_test.go:\d+"`, buf.String(), "Expected caller to be the Print call site.")
+}
@@ -0,0 +1,413 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zwrap
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/uber-go/zap"
+
+	"github.com/uber-go/atomic"
+)
+
+// For tests.
+var _timeNow = time.Now
+
+// A SampledEntry describes a log call that a sampling logger is considering.
+type SampledEntry struct {
+	Level   zap.Level
+	Message string
+	// Context holds the fields added to the logger with With.
+	Context []zap.Field
+	// Fields holds the fields added at the log site. It's always empty when
+	// sampling a call to Check, since the fields haven't been supplied yet.
+	Fields []zap.Field
+}
+
+// A SamplingStrategy decides which log calls a sampling logger writes. It's
+// only consulted for enabled levels. Implementations must be safe for
+// concurrent use.
+type SamplingStrategy interface {
+	Sample(SampledEntry) bool
+}
+
+// SamplingStrategyFunc is a type adapter that allows using a function as a
+// SamplingStrategy.
+type SamplingStrategyFunc func(SampledEntry) bool
+
+// Sample calls the underlying function.
+func (f SamplingStrategyFunc) Sample(e SampledEntry) bool {
+	return f(e)
+}
+
+// A KeyFunc assigns log calls to buckets. Strategies track each bucket
+// separately.
+type KeyFunc func(SampledEntry) string
+
+// MessageKey is the default KeyFunc, which buckets log calls by message.
+func MessageKey(e SampledEntry) string {
+	return e.Message
+}
+
+// FieldKey returns a KeyFunc that buckets log calls by message and by the
+// values of the fields with the supplied keys, whether they were added with
+// With or at the log site. For example, FieldKey("user") samples each user's
+// logs separately. Nested fields aren't inspected, and fields constructed with
+// zap.Lazy or zap.Deferred are skipped, so that sampling never evaluates them
+// for entries it then drops.
+//
+// Unlike MessageKey, FieldKey allocates on every log call it buckets, since
+// it builds a new key from the message and field values.
+func FieldKey(keys ...string) KeyFunc {
+	return func(e SampledEntry) string {
+		kv := &fieldValues{keys: keys, buf: []byte(e.Message)}
+		for _, f := range e.Context {
+			if !f.IsLazy() {
+				f.AddTo(kv)
+			}
+		}
+		for _, f := range e.Fields {
+			if !f.IsLazy() {
+				f.AddTo(kv)
+			}
+		}
+		return string(kv.buf)
+	}
+}
+
+// A SamplingOption configures a SamplingStrategy.
+type SamplingOption interface {
+	apply(*samplingConfig)
+}
+
+type samplingOptionFunc func(*samplingConfig)
+
+func (f samplingOptionFunc) apply(c *samplingConfig) {
+	f(c)
+}
+
+type samplingConfig struct {
+	key       KeyFunc
+	capacity  int
+	onDropped func(key string, dropped uint64)
+}
+
+func newSamplingConfig(opts []SamplingOption) samplingConfig {
+	cfg := samplingConfig{key: MessageKey}
+	for _, opt := range opts {
+		opt.apply(&cfg)
+	}
+	return cfg
+}
+
+func (c samplingConfig) reportDropped(key string, dropped uint64) {
+	if dropped > 0 && c.onDropped != nil {
+		c.onDropped(key, dropped)
+	}
+}
+
+// SampleKey sets the KeyFunc used to bucket log calls. The default is
+// MessageKey.
+func SampleKey(f KeyFunc) SamplingOption {
+	return samplingOptionFunc(func(c *samplingConfig) {
+		c.key = f
+	})
+}
+
+// SampleCapacity sets the number of buckets a strategy tracks, which fixes its
+// memory use. Keys are hashed into buckets, so if there are more distinct keys
+// than buckets, some keys share a bucket (and are sampled together). The
+// capacity is rounded up to a power of two; the default is 4096.
+func SampleCapacity(n int) SamplingOption {
+	return samplingOptionFunc(func(c *samplingConfig) {
+		c.capacity = n
+	})
+}
+
+// OnDropped registers a function that's called with the number of log calls
+// dropped from a bucket each time the bucket's window closes. Windows are
+// closed lazily, by the bucket's next log call, and the function is called on
+// that call's goroutine with that call's key.
+//
+// Since keys are hashed into a fixed number of buckets (see SampleCapacity),
+// reports are per bucket, not per key: if several keys share a bucket, a
+// report counts the calls dropped for all of them, and it's attributed to
+// whichever key happened to close the window.
+func OnDropped(f func(key string, dropped uint64)) SamplingOption {
+	return samplingOptionFunc(func(c *samplingConfig) {
+		c.onDropped = f
+	})
+}
+
+// FirstThereafter returns a strategy that, in each bucket, writes the first N
+// log calls in each tick and every Mth call thereafter. When a tick elapses
+// after the start of a bucket's window, the next call opens a new window. It's
+// the strategy used by Sample.
+//
+// Buckets are updated without locks, so the strategy adds little overhead to
+// each log call and never allocates (though its KeyFunc may; see FieldKey).
+// Windows are timed with millisecond precision, and each bucket counts at
+// most 2^24-1 calls per window; once a bucket's count is exhausted, it drops
+// the remaining calls in the window.
+func FirstThereafter(tick time.Duration, first, thereafter int, opts ...SamplingOption) SamplingStrategy {
+	cfg := newSamplingConfig(opts)
+	ms := uint64(tick / time.Millisecond)
+	if ms == 0 {
+		ms = 1
+	}
+	return &windowStrategy{
+		samplingConfig: cfg,
+		base:           _timeNow(),
+		tick:           ms,
+		first:          uint64(first),
+		thereafter:     uint64(thereafter),
+		windows:        make([]window, tableSize(cfg.capacity)),
+	}
+}
+
+const (
+	_windowCountBits = 24
+	_windowCountMax  = 1<<_windowCountBits - 1
+)
+
+type windowStrategy struct {
+	samplingConfig
+
+	base       time.Time
+	tick       uint64 // milliseconds
+	first      uint64
+	thereafter uint64
+	windows    []window
+}
+
+// A window packs the start of its current window (in milliseconds since the
+// strategy was created) and the number of calls in it into a single word, so
+// that opening a new window and resetting the count is a single
+// compare-and-swap; otherwise, concurrent calls that see the new window
+// could have their increments wiped out by the reset.
+type window struct {
+	state   atomic.Uint64
+	dropped atomic.Uint64
+}
+
+func (s *windowStrategy) Sample(e SampledEntry) bool {
+	key := s.key(e)
+	w := &s.windows[slotIndex(key, len(s.windows))]
+	now := uint64(_timeNow().Sub(s.base) / time.Millisecond)
+
+	var n uint64
+	for {
+		old := w.state.Load()
+		start, count := old>>_windowCountBits, old&_windowCountMax
+		// Another call may have opened a window after we read the clock.
+		opened := now > start && now-start >= s.tick
+		if opened {
+			start, count = now, 0
+		}
+		if count == _windowCountMax {
+			break
+		}
+		if w.state.CAS(old, start<<_windowCountBits|(count+1)) {
+			n = count + 1
+			if opened {
+				s.reportDropped(key, w.dropped.Swap(0))
+			}
+			break
+		}
+	}
+
+	if n > 0 && (n <= s.first || (s.thereafter > 0 && (n-s.first)%s.thereafter == 0)) {
+		return true
+	}
+	w.dropped.Inc()
+	return false
+}
+
+// TokenBucket returns a strategy that rate-limits each bucket: it writes up to
+// burst log calls at once, refilling at rate calls per second. The rate must
+// be positive. For the purposes of OnDropped, a bucket's window closes when it
+// writes a log call after dropping some.
+//
+// Like FirstThereafter, TokenBucket updates buckets without locks and never
+// allocates, apart from any allocations made by its KeyFunc.
+func TokenBucket(rate float64, burst int, opts ...SamplingOption) SamplingStrategy {
+	cfg := newSamplingConfig(opts)
+	interval := int64(float64(time.Second) / rate)
+	return &bucketStrategy{
+		samplingConfig: cfg,
+		interval:       interval,
+		tolerance:      interval * int64(burst-1),
+		buckets:        make([]bucket, tableSize(cfg.capacity)),
+	}
+}
+
+// bucketStrategy implements the token bucket as a generic cell rate
+// algorithm: rather than counting tokens, each bucket stores the theoretical
+// arrival time of its next log call, which can be updated with a single
+// compare-and-swap.
+type bucketStrategy struct {
+	samplingConfig
+
+	interval  int64 // nanoseconds to refill one token
+	tolerance int64 // how far ahead of now the arrival time can be
+	buckets   []bucket
+}
+
+type bucket struct {
+	arrival atomic.Int64
+	dropped atomic.Uint64
+}
+
+func (s *bucketStrategy) Sample(e SampledEntry) bool {
+	key := s.key(e)
+	b := &s.buckets[slotIndex(key, len(s.buckets))]
+	now := _timeNow().UnixNano()
+
+	for {
+		old := b.arrival.Load()
+		arrival := old
+		if arrival < now {
+			arrival = now
+		}
+		if arrival-now > s.tolerance {
+			b.dropped.Inc()
+			return false
+		}
+		if b.arrival.CAS(old, arrival+s.interval) {
+			break
+		}
+	}
+	s.reportDropped(key, b.dropped.Swap(0))
+	return true
+}
+
+// ByLevel returns a strategy that delegates to a different strategy for each
+// level. Log calls at levels without a strategy are never sampled, so
+//
+//	ByLevel(map[zap.Level]SamplingStrategy{
+//		zap.DebugLevel: FirstThereafter(time.Second, 1, 1000),
+//		zap.InfoLevel:  FirstThereafter(time.Second, 100, 100),
+//	})
+//
+// heavily samples Debug logs, lightly samples Info logs, and writes every
+// Warn and Error log.
+func ByLevel(strategies map[zap.Level]SamplingStrategy) SamplingStrategy {
+	m := make(levelStrategy, len(strategies))
+	for lvl, s := range strategies {
+		m[lvl] = s
+	}
+	return m
+}
+
+type levelStrategy map[zap.Level]SamplingStrategy
+
+func (m levelStrategy) Sample(e SampledEntry) bool {
+	if s, ok := m[e.Level]; ok {
+		return s.Sample(e)
+	}
+	return true
+}
+
+// fieldValues is a zap.KeyValue that appends the values of the selected
+// top-level fields to a buffer.
+type fieldValues struct {
+	keys []string
+	buf  []byte
+}
+
+func (kv *fieldValues) selected(key string) bool {
+	for _, k := range kv.keys {
+		if k == key {
+			kv.buf = append(kv.buf, 0)
+			kv.buf = append(kv.buf, key...)
+			kv.buf = append(kv.buf, '=')
+			return true
+		}
+	}
+	return false
+}
+
+func (kv *fieldValues) AddBool(key string, val bool) {
+	if kv.selected(key) {
+		kv.buf = strconv.AppendBool(kv.buf, val)
+	}
+}
+
+func (kv *fieldValues) AddFloat64(key string, val float64) {
+	if kv.selected(key) {
+		kv.buf = strconv.AppendFloat(kv.buf, val, 'g', -1, 64)
+	}
+}
+
+func (kv *fieldValues) AddInt(key string, val int) {
+	kv.AddInt64(key, int64(val))
+}
+
+func (kv *fieldValues) AddInt64(key string, val int64) {
+	if kv.selected(key) {
+		kv.buf = strconv.AppendInt(kv.buf, val, 10)
+	}
+}
+
+func (kv *fieldValues) AddUint(key string, val uint) {
+	kv.AddUint64(key, uint64(val))
+}
+
+func (kv *fieldValues) AddUint64(key string, val uint64) {
+	if kv.selected(key) {
+		kv.buf = strconv.AppendUint(kv.buf, val, 10)
+	}
+}
+
+func (kv *fieldValues) AddUintptr(key string, val uintptr) {
+	kv.AddUint64(key, uint64(val))
+}
+
+func (kv *fieldValues) AddString(key, val string) {
+	if kv.selected(key) {
+		kv.buf = append(kv.buf, val...)
+	}
+}
+
+func (kv *fieldValues) AddTime(key string, val time.Time) {
+	kv.AddInt64(key, val.UnixNano())
+}
+
+func (kv *fieldValues) AddDuration(key string, val time.Duration) {
+	kv.AddInt64(key, int64(val))
+}
+
+func (kv *fieldValues) AddArray(key string, _ zap.ArrayMarshaler) error {
+	return nil
+}
+
+func (kv *fieldValues) AddMarshaler(key string, _ zap.LogMarshaler) error {
+	return nil
+}
+
+func (kv *fieldValues) AddObject(key string, val interface{}) error {
+	if kv.selected(key) {
+		kv.buf = append(kv.buf, fmt.Sprint(val)...)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zwrap
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/uber-go/zap"
+	"github.com/uber-go/zap/spy"
+
+	"github.com/uber-go/atomic"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type dropReport struct {
+	key     string
+	dropped uint64
+}
+
+func withFakeClock(f func(advance func(time.Duration))) {
+	now := time.Unix(0, 0)
+	_timeNow = func() time.Time { return now }
+	defer func() { _timeNow = time.Now }()
+	f(func(d time.Duration) { now = now.Add(d) })
+}
+
+func sampleN(s SamplingStrategy, e SampledEntry, n int) []bool {
+	results := make([]bool, n)
+	for i := range results {
+		results[i] = s.Sample(e)
+	}
+	return results
+}
+
+func TestFirstThereafterStrategy(t *testing.T) {
+	withFakeClock(func(advance func(time.Duration)) {
+		var reports []dropReport
+		s := FirstThereafter(time.Second, 2, 3, OnDropped(func(key string, n uint64) {
+			reports = append(reports, dropReport{key, n})
+		}))
+		foo := SampledEntry{Level: zap.InfoLevel, Message: "foo"}
+		bar := SampledEntry{Level: zap.InfoLevel, Message: "bar"}
+
+		assert.Equal(t,
+			[]bool{true, true, false, false, true, false, false, true},
+			sampleN(s, foo, 8),
+			"Unexpected sampling decisions in the first window.",
+		)
+		assert.Equal(t, []bool{true}, sampleN(s, bar, 1), "Expected buckets to be independent.")
+
+		advance(999 * time.Millisecond)
+		assert.Equal(t, []bool{false}, sampleN(s, foo, 1), "Expected the window to stay open until a tick elapses.")
+		assert.Empty(t, reports, "Expected no reports before the window closes.")
+
+		advance(time.Millisecond)
+		assert.Equal(t, []bool{true, true, false}, sampleN(s, foo, 3), "Expected a new window after a tick.")
+		assert.Equal(t, []dropReport{{"foo", 5}}, reports, "Expected drops to be reported when the window closes.")
+	})
+}
+
+func TestFirstThereafterConcurrentWindows(t *testing.T) {
+	withFakeClock(func(advance func(time.Duration)) {
+		s := FirstThereafter(time.Second, 100, 0)
+		e := SampledEntry{Message: "foo"}
+		sampleN(s, e, 1000)
+		advance(time.Second)
+
+		// Every goroutine sees the new window, so exactly the first 100 calls
+		// across all of them should be written.
+		var sampled atomic.Uint64
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for _, ok := range sampleN(s, e, 1000) {
+					if ok {
+						sampled.Inc()
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, uint64(100), sampled.Load(), "Expected opening a window not to lose concurrent calls.")
+	})
+}
+
+func TestFirstThereafterZero(t *testing.T) {
+	s := FirstThereafter(time.Minute, 1, 0)
+	e := SampledEntry{Message: "foo"}
+	assert.Equal(t, []bool{true, false, false}, sampleN(s, e, 3), "Expected to drop everything after the first N.")
+}
+
+func TestTokenBucketStrategy(t *testing.T) {
+	withFakeClock(func(advance func(time.Duration)) {
+		var reports []dropReport
+		s := TokenBucket(2, 3, OnDropped(func(key string, n uint64) {
+			reports = append(reports, dropReport{key, n})
+		}))
+		foo := SampledEntry{Message: "foo"}
+
+		assert.Equal(t, []bool{true, true, true, false, false}, sampleN(s, foo, 5), "Expected to write a full burst.")
+		assert.Equal(t, []bool{true}, sampleN(s, SampledEntry{Message: "bar"}, 1), "Expected buckets to be independent.")
+
+		advance(250 * time.Millisecond)
+		assert.Equal(t, []bool{false}, sampleN(s, foo, 1), "Expected partial tokens not to be usable.")
+		advance(250 * time.Millisecond)
+		assert.Equal(t, []bool{true, false}, sampleN(s, foo, 2), "Expected tokens to refill at the configured rate.")
+		assert.Equal(t, []dropReport{{"foo", 3}}, reports, "Expected drops to be reported on the next write.")
+
+		advance(time.Hour)
+		assert.Equal(t, []bool{true, true, true, false}, sampleN(s, foo, 4), "Expected refills to be capped at the burst size.")
+	})
+}
+
+func TestByLevelStrategy(t *testing.T) {
+	s := ByLevel(map[zap.Level]SamplingStrategy{
+		zap.DebugLevel: FirstThereafter(time.Minute, 1, 100),
+		zap.InfoLevel:  FirstThereafter(time.Minute, 2, 100),
+	})
+	assert.Equal(t, []bool{true, false, false}, sampleN(s, SampledEntry{Level: zap.DebugLevel}, 3), "Unexpected Debug sampling.")
+	assert.Equal(t, []bool{true, true, false}, sampleN(s, SampledEntry{Level: zap.InfoLevel}, 3), "Unexpected Info sampling.")
+	assert.Equal(t, []bool{true, true, true}, sampleN(s, SampledEntry{Level: zap.ErrorLevel}, 3), "Expected levels without a strategy not to be sampled.")
+}
+
+func TestFieldKey(t *testing.T) {
+	key := FieldKey("user", "n", "ok", "err")
+	assert.Equal(t, "foo", key(SampledEntry{Message: "foo"}), "Expected the message alone without selected fields.")
+	assert.Equal(t,
+		"foo\x00user=alice\x00n=42\x00ok=true\x00err=fail",
+		key(SampledEntry{
+			Message: "foo",
+			Context: []zap.Field{zap.String("user", "alice"), zap.String("other", "ignored")},
+			Fields:  []zap.Field{zap.Int("n", 42), zap.Bool("ok", true), zap.NamedError("err", errors.New("fail"))},
+		}),
+		"Unexpected key including field values.",
+	)
+
+	called := false
+	lazy := zap.Lazy(func() zap.Field {
+		called = true
+		return zap.String("user", "lazy")
+	})
+	deferred := zap.Deferred(func(kv zap.KeyValue) error {
+		called = true
+		kv.AddString("user", "deferred")
+		return nil
+	})
+	assert.Equal(t, "foo", key(SampledEntry{
+		Message: "foo",
+		Context: []zap.Field{lazy},
+		Fields:  []zap.Field{deferred},
+	}), "Expected lazy and deferred fields to be skipped.")
+	assert.False(t, called, "Expected lazy and deferred fields not to be evaluated.")
+}
+
+func TestSampleByFieldKey(t *testing.T) {
+	base, sink := spy.New(zap.DebugLevel)
+	logger := SampleBy(base, FirstThereafter(time.Minute, 1, 100, SampleKey(FieldKey("user"))))
+	alice := logger.With(zap.String("user", "alice"))
+	for i := 0; i < 3; i++ {
+		alice.Info("sample")
+		logger.Info("sample", zap.String("user", "bob"))
+		alice.Named("child").Info("sample")
+	}
+
+	assert.Equal(t, []spy.Log{
+		{Level: zap.InfoLevel, Msg: "sample", Fields: []zap.Field{zap.String("user", "alice")}},
+		{Level: zap.InfoLevel, Msg: "sample", Fields: []zap.Field{zap.String("user", "bob")}},
+	}, sink.Logs(), "Expected each user to be sampled separately.")
+}
+
+func TestSamplingStrategyFunc(t *testing.T) {
+	base, sink := spy.New(zap.DebugLevel)
+	logger := SampleBy(base, SamplingStrategyFunc(func(e SampledEntry) bool {
+		return e.Level >= zap.WarnLevel
+	}))
+	logger.Info("dropped")
+	logger.Warn("kept")
+	assert.Nil(t, logger.Check(zap.InfoLevel, "dropped"), "Expected Check to consult the strategy.")
+	assert.Equal(t, []spy.Log{{Level: zap.WarnLevel, Msg: "kept", Fields: []zap.Field{}}}, sink.Logs(), "Unexpected output from a custom strategy.")
+}